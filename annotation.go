@@ -0,0 +1,197 @@
+package stackerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// annotationKey identifies the kind of value held by an annotated error.
+type annotationKey int
+
+const (
+	codeKey annotationKey = iota
+	severityKey
+	userMessageKey
+	requestIDKey
+	spanKey
+	contextValuesKey
+	categoryKey
+	opKey
+)
+
+// annotated attaches a value to an error without changing its message or formatting.
+type annotated struct {
+	err   error
+	key   annotationKey
+	value interface{}
+}
+
+// annotate wraps err with the value, capturing a stack trace if there isn't one in the unwrap chain. It skips skip
+// frames above the caller of annotate.
+func annotate(err error, key annotationKey, value interface{}, skip int) error {
+	if err == nil {
+		return nil
+	}
+	return annotated{err: wrap(err, skip+1), key: key, value: value}
+}
+
+// lookup returns the value for the first annotated error in the unwrap tree with the key.
+func lookup(err error, key annotationKey) (interface{}, bool) {
+	var out interface{}
+	found := false
+	walk(err, func(e error) bool {
+		if a, ok := e.(annotated); ok && a.key == key {
+			out = a.value
+			found = true
+			return false
+		}
+		return true
+	})
+	return out, found
+}
+
+// Error returns the error message for the wrapped error.
+func (a annotated) Error() string {
+	return a.err.Error()
+}
+
+// Unwrap exposes the error wrapped by annotated.
+func (a annotated) Unwrap() error {
+	return a.err
+}
+
+// Format formats the wrapped error with the same verb and flags, so %+v still outputs the stack trace.
+func (a annotated) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, formatDirective(s, verb), a.err)
+}
+
+// formatDirective rebuilds the formatting directive for the verb and the flags set in the fmt.State.
+func formatDirective(s fmt.State, verb rune) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if s.Flag(int(flag)) {
+			b.WriteRune(flag)
+		}
+	}
+	if w, ok := s.Width(); ok {
+		fmt.Fprintf(&b, "%d", w)
+	}
+	if p, ok := s.Precision(); ok {
+		fmt.Fprintf(&b, ".%d", p)
+	}
+	b.WriteRune(verb)
+	return b.String()
+}
+
+// WithCode attaches an application-specific numeric code to an error. If there is no stack trace in the unwrap chain
+// for err, one is captured. WithCode returns nil when a nil error is passed in.
+func WithCode(err error, code int) error {
+	return annotate(err, codeKey, code, 1)
+}
+
+// Code returns the code attached by the outermost call to WithCode in the unwrap chain for the error. The second
+// return value is false if there is no code.
+func Code(err error) (int, bool) {
+	v, ok := lookup(err, codeKey)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+// WithUserMessage attaches a message that is safe to show to the users of a program, such as the callers of an API,
+// to an error. The error's own message is unchanged, so it can keep the details that are only for the logs. If there
+// is no stack trace in the unwrap chain for err, one is captured. WithUserMessage returns nil when a nil error is
+// passed in.
+func WithUserMessage(err error, msg string) error {
+	return annotate(err, userMessageKey, msg, 1)
+}
+
+// UserMessage returns the message attached by the outermost call to WithUserMessage in the unwrap chain for the
+// error. The second return value is false if there is no user message.
+func UserMessage(err error) (string, bool) {
+	v, ok := lookup(err, userMessageKey)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// WithRequestID attaches the ID of the request that was being handled when the error happened, such as the value of
+// an X-Request-ID header. The request ID is included in the error's LogReport and in the output of the structured
+// encoders, so error logs can be matched with the request. If there is no stack trace in the unwrap chain for err,
+// one is captured. WithRequestID returns nil when a nil error is passed in.
+func WithRequestID(err error, id string) error {
+	return annotate(err, requestIDKey, id, 1)
+}
+
+// RequestID returns the request ID attached by the outermost call to WithRequestID in the unwrap chain for the error.
+// The second return value is false if there is no request ID.
+func RequestID(err error) (string, bool) {
+	v, ok := lookup(err, requestIDKey)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// Severity describes how serious an error is.
+type Severity int
+
+// The severities, from least to most serious. The zero value means no severity was attached.
+const (
+	SeverityDebug Severity = iota + 1
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// String returns the lowercase name of the severity, such as "warning".
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for k, v := range severityNames {
+		if v == string(text) {
+			*s = k
+			return nil
+		}
+	}
+	return fmt.Errorf("stackerr: unknown severity %q", text)
+}
+
+// WithSeverity attaches a severity to an error. If there is no stack trace in the unwrap chain for err, one is
+// captured. WithSeverity returns nil when a nil error is passed in.
+func WithSeverity(err error, s Severity) error {
+	return annotate(err, severityKey, s, 1)
+}
+
+// SeverityOf returns the severity attached by the outermost call to WithSeverity in the unwrap chain for the error.
+// If no severity was attached, it returns SeverityError.
+func SeverityOf(err error) Severity {
+	v, ok := lookup(err, severityKey)
+	if !ok {
+		return SeverityError
+	}
+	return v.(Severity)
+}
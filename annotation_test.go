@@ -0,0 +1,139 @@
+package stackerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestWithCode(t *testing.T) {
+	if stackerr.WithCode(nil, 404) != nil {
+		t.Error("Got non-nil for nil passed to WithCode")
+	}
+	base := errors.New("not found")
+	err := stackerr.WithCode(base, 404)
+	if err.Error() != "not found" || !errors.Is(err, base) || !stackerr.HasStack(err) {
+		t.Errorf("unexpected error `%v`", err)
+	}
+	if code, ok := stackerr.Code(fmt.Errorf("wrapped: %w", err)); !ok || code != 404 {
+		t.Errorf("expected code 404, got %d", code)
+	}
+	if code, _ := stackerr.Code(stackerr.WithCode(err, 410)); code != 410 {
+		t.Errorf("expected the outermost code 410, got %d", code)
+	}
+	if _, ok := stackerr.Code(base); ok {
+		t.Error("didn't expect a code")
+	}
+	if fmt.Sprintf("%+v", err) != fmt.Sprintf("%+v", stackerr.Wrap(err)) {
+		t.Errorf("expected %%+v output to be unchanged by the code")
+	}
+	if fmt.Sprintf("%q", err) != `"not found"` {
+		t.Errorf("unexpected %%q output %q", err)
+	}
+}
+
+func TestWithUserMessage(t *testing.T) {
+	if stackerr.WithUserMessage(nil, "try again") != nil {
+		t.Error("Got non-nil for nil passed to WithUserMessage")
+	}
+	err := stackerr.WithUserMessage(errors.New("dial tcp 10.0.0.7:5432: connection refused"), "try again later")
+	if err.Error() != "dial tcp 10.0.0.7:5432: connection refused" || !stackerr.HasStack(err) {
+		t.Errorf("unexpected error `%v`", err)
+	}
+	if msg, ok := stackerr.UserMessage(fmt.Errorf("wrapped: %w", err)); !ok || msg != "try again later" {
+		t.Errorf("expected the user message, got %q", msg)
+	}
+	if _, ok := stackerr.UserMessage(stackerr.New("plain")); ok {
+		t.Error("didn't expect a user message")
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	if stackerr.WithRequestID(nil, "req-1") != nil {
+		t.Error("Got non-nil for nil passed to WithRequestID")
+	}
+	err := stackerr.WithRequestID(errors.New("timeout"), "req-1")
+	if id, ok := stackerr.RequestID(fmt.Errorf("wrapped: %w", err)); !ok || id != "req-1" {
+		t.Errorf("expected the request ID, got %q", id)
+	}
+	if _, ok := stackerr.RequestID(stackerr.New("plain")); ok {
+		t.Error("didn't expect a request ID")
+	}
+	if r := stackerr.Report(err); r.RequestID != "req-1" {
+		t.Errorf("expected the request ID in the report, got %q", r.RequestID)
+	}
+	if line := stackerr.EncodeLogfmt(err); !strings.HasSuffix(line, " request_id=req-1") {
+		t.Errorf("expected the request ID in `%s`", line)
+	}
+	data, jsonErr := json.Marshal(stackerr.Report(err))
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	decoded, decodeErr := stackerr.DecodeJSON(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if id, _ := stackerr.RequestID(decoded); id != "req-1" {
+		t.Errorf("expected the request ID to round trip, got %q", id)
+	}
+}
+
+func TestWithSeverity(t *testing.T) {
+	err := stackerr.New("disk full")
+	if stackerr.SeverityOf(err) != stackerr.SeverityError {
+		t.Errorf("expected default severity error, got %s", stackerr.SeverityOf(err))
+	}
+	err = stackerr.WithSeverity(err, stackerr.SeverityCritical)
+	if s := stackerr.SeverityOf(fmt.Errorf("wrapped: %w", err)); s != stackerr.SeverityCritical {
+		t.Errorf("expected severity critical, got %s", s)
+	}
+
+	for _, v := range []stackerr.Severity{stackerr.SeverityDebug, stackerr.SeverityInfo, stackerr.SeverityWarning, stackerr.SeverityError, stackerr.SeverityCritical} {
+		text, _ := v.MarshalText()
+		var s stackerr.Severity
+		if unmarshalErr := s.UnmarshalText(text); unmarshalErr != nil || s != v {
+			t.Errorf("expected %s to round trip, got %s (%v)", v, s, unmarshalErr)
+		}
+	}
+	var s stackerr.Severity
+	if s.UnmarshalText([]byte("fatal")) == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+// metadataError returns an error with an operation name, a category, a request ID, trace and span IDs, and a context
+// value, for the encoder tests.
+func metadataError(t *testing.T) error {
+	t.Helper()
+	stackerr.SetContextValues(map[string]stackerr.ContextValue{"tenant": stackerr.ContextKey(tenantKey{})})
+	t.Cleanup(func() { stackerr.SetContextValues(nil) })
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	err := stackerr.WrapCtx(ctx, errors.New("quota exceeded"))
+	err = stackerr.WithCategory(stackerr.WithOp(err, "load"), stackerr.CategoryUnavailable)
+	return stackerr.WithRequestID(stackerr.WithSpanIDs(err, "trace-1", "span-1"), "req-1")
+}
+
+// checkMetadata fails the test if the decoded error doesn't have the IDs, context value, and frames of err, which
+// came from metadataError.
+func checkMetadata(t *testing.T, err, decoded error) {
+	t.Helper()
+	if id, _ := stackerr.RequestID(decoded); id != "req-1" {
+		t.Errorf("expected request ID req-1, got %q", id)
+	}
+	if traceID, spanID, _ := stackerr.SpanIDs(decoded); traceID != "trace-1" || spanID != "span-1" {
+		t.Errorf("expected trace-1 and span-1, got %q and %q", traceID, spanID)
+	}
+	if diff := cmp.Diff(map[string]string{"tenant": "acme"}, stackerr.ContextValues(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), stackerr.Frames(decoded)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,42 @@
+package stackerr
+
+// asStack returns the first *errorStack in the tree of errors for err, in the order that errors.As searches it. Wrap,
+// Errorf, and HasStack call it for every error, and most of those errors already have a stack trace, so it walks the
+// tree with type assertions instead of paying for the reflection in errors.As and the allocation of its target.
+// Errors with their own As method are still asked for an *errorStack, so the result is the same as errors.As, except
+// that asStack also looks inside errors with an Errors() []error method, like the ones from go.uber.org/multierr, and
+// inside errors with a WrappedErrors() []error method, like the ones from github.com/hashicorp/go-multierror.
+func asStack(err error) (*errorStack, bool) {
+	for err != nil {
+		switch x := err.(type) {
+		case *errorStack:
+			return x, true
+		case interface{ As(interface{}) bool }:
+			var se *errorStack
+			if x.As(&se) {
+				return se, true
+			}
+		}
+		switch x := err.(type) {
+		case interface{ WrappedErrors() []error }:
+			return asStackAll(x.WrappedErrors())
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }, interface{ Errors() []error }:
+			return asStackAll(multiUnwrap(x))
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// asStackAll returns the first *errorStack in the trees of errors for errs.
+func asStackAll(errs []error) (*errorStack, bool) {
+	for _, v := range errs {
+		if se, ok := asStack(v); ok {
+			return se, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package stackerr_test
+
+import (
+	"fmt"
+	"testing"
+	"text/template"
+
+	"github.com/jonbodner/stackerr"
+)
+
+var benchErr error
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = stackerr.New("cache miss")
+	}
+}
+
+func BenchmarkNewDeferredCapture(b *testing.B) {
+	stackerr.SetDeferredCapture(true)
+	defer stackerr.SetDeferredCapture(false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = stackerr.New("cache miss")
+	}
+}
+
+func BenchmarkFormatCached(b *testing.B) {
+	err := stackerr.New("cache miss")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
+
+func BenchmarkFormatHotSite(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", stackerr.New("cache miss"))
+	}
+}
+
+func BenchmarkFormatHotSiteNoSymbolCache(b *testing.B) {
+	stackerr.SetSymbolCacheSize(0)
+	defer stackerr.SetSymbolCacheSize(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", stackerr.New("cache miss"))
+	}
+}
+
+// slowStandardFormat has the same layout as StandardFormat, but is executed as a template.
+var slowStandardFormat = template.Must(template.New("slowStandardFormat").Parse("{{.Function}} ({{.File}}:{{.Line}})"))
+
+func BenchmarkTraceStandardFormat(b *testing.B) {
+	err := stackerr.New("cache miss")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = stackerr.Trace(err, stackerr.StandardFormat)
+	}
+}
+
+func BenchmarkTraceTemplate(b *testing.B) {
+	err := stackerr.New("cache miss")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = stackerr.Trace(err, slowStandardFormat)
+	}
+}
+
+func BenchmarkWrapStacked(b *testing.B) {
+	err := fmt.Errorf("query: %w", stackerr.New("cache miss"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = stackerr.Wrap(err)
+	}
+}
+
+func BenchmarkHasStack(b *testing.B) {
+	err := fmt.Errorf("query: %w", stackerr.New("cache miss"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = stackerr.HasStack(err)
+	}
+}
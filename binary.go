@@ -0,0 +1,235 @@
+package stackerr
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// binaryVersion is the first byte of every error encoded by EncodeBinary.
+const binaryVersion = 1
+
+// binaryKinds maps each FrameKind to the byte that encodes it. Unknown kinds are encoded as 0.
+var binaryKinds = map[FrameKind]byte{
+	FrameApp:        1,
+	FrameDependency: 2,
+	FrameStdlib:     3,
+	FrameCgo:        4,
+}
+
+// EncodeBinary encodes an error, in a compact binary format, with the message, chain, code, severity, and frames from
+// its LogReport. The first byte is the version of the format. If the error has a request ID, trace and span IDs,
+// context values, a category, or operation names, they follow the frames; releases that don't know about them ignore
+// them. Use DecodeBinary to turn the result back into an error, even in a different build of the program, since the
+// frames are already symbolized.
+func EncodeBinary(err error) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	r := Report(err)
+	buf := []byte{binaryVersion}
+	str := func(s string) {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	str(r.Message)
+	buf = binary.AppendUvarint(buf, uint64(len(r.Chain)))
+	for _, v := range r.Chain {
+		str(v)
+	}
+	buf = binary.AppendVarint(buf, int64(r.Code))
+	buf = binary.AppendUvarint(buf, uint64(r.Severity))
+	buf = binary.AppendUvarint(buf, uint64(len(r.Frames)))
+	for _, v := range r.Frames {
+		str(v.Function)
+		str(v.File)
+		buf = binary.AppendUvarint(buf, uint64(v.Line))
+		buf = append(buf, binaryKinds[v.Kind])
+	}
+	if r.RequestID == "" && r.TraceID == "" && r.SpanID == "" && len(r.Context) == 0 && r.Category == 0 &&
+		len(r.Ops) == 0 {
+		return buf, nil
+	}
+	str(r.RequestID)
+	str(r.TraceID)
+	str(r.SpanID)
+	buf = binary.AppendUvarint(buf, uint64(len(r.Context)))
+	for _, k := range sortedContextValues(r.Context) {
+		str(k)
+		str(r.Context[k])
+	}
+	buf = binary.AppendUvarint(buf, uint64(r.Category))
+	buf = binary.AppendUvarint(buf, uint64(len(r.Ops)))
+	for _, v := range r.Ops {
+		str(v)
+	}
+	return buf, nil
+}
+
+// MarshalBinary encodes the errorStack with EncodeBinary. This method implements encoding.BinaryMarshaler, so
+// clients for caches such as Redis and memcached can store errors with stack traces directly.
+func (e *errorStack) MarshalBinary() ([]byte, error) {
+	return EncodeBinary(e)
+}
+
+var errBinaryShort = errors.New("stackerr: binary data is truncated")
+
+// binaryDecoder reads the values written by EncodeBinary.
+type binaryDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *binaryDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errBinaryShort
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *binaryDecoder) int() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errBinaryShort
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *binaryDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.err = errBinaryShort
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *binaryDecoder) str() string {
+	n := d.uint()
+	if d.err != nil {
+		return ""
+	}
+	if uint64(len(d.buf)) < n {
+		d.err = errBinaryShort
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+// count reads the length of a list whose entries take at least min bytes each, so that a corrupt length can't cause a
+// huge allocation.
+func (d *binaryDecoder) count(min int) int {
+	n := d.uint()
+	if d.err == nil && uint64(len(d.buf)/min) < n {
+		d.err = errBinaryShort
+		return 0
+	}
+	return int(n)
+}
+
+// DecodeBinary decodes an error encoded by EncodeBinary. The decoded error has the original message, chain, code,
+// severity, frames, request ID, trace and span IDs, context values, category, and operation names. Trace, Frames, and
+// %+v use the decoded frames.
+func DecodeBinary(data []byte) (error, error) {
+	if len(data) == 0 {
+		return nil, errBinaryShort
+	}
+	if data[0] != binaryVersion {
+		return nil, errors.New("stackerr: unknown binary format version")
+	}
+	d := binaryDecoder{buf: data[1:]}
+	var r LogReport
+	r.Message = d.str()
+	r.Chain = make([]string, d.count(1))
+	for i := range r.Chain {
+		r.Chain[i] = d.str()
+	}
+	r.Code = int(d.int())
+	r.Severity = Severity(d.uint())
+	// a frame takes at least two bytes for its strings, one for its line, and one for its kind.
+	r.Frames = make([]Frame, d.count(4))
+	for i := range r.Frames {
+		f := &r.Frames[i]
+		f.Function = d.str()
+		f.File = d.str()
+		f.Line = int(d.uint())
+		kind := d.byte()
+		for k, v := range binaryKinds {
+			if v == kind {
+				f.Kind = k
+			}
+		}
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		r.RequestID = d.str()
+		r.TraceID = d.str()
+		r.SpanID = d.str()
+		// a context value takes at least one byte for each of its two strings.
+		if n := d.count(2); n > 0 {
+			r.Context = make(map[string]string, n)
+			for i := 0; i < n; i++ {
+				k := d.str()
+				r.Context[k] = d.str()
+			}
+		}
+		// the category and operation names were added after the other values, so data from earlier releases ends
+		// before them.
+		if d.err == nil && len(d.buf) > 0 {
+			r.Category = Category(d.uint())
+		}
+		if d.err == nil && len(d.buf) > 0 {
+			r.Ops = make([]string, d.count(1))
+			for i := range r.Ops {
+				r.Ops[i] = d.str()
+			}
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return FromReport(r), nil
+}
+
+// Stored holds an error so it can be saved and loaded through the standard encoding interfaces. Pass a Stored to code
+// that calls MarshalBinary and a *Stored to code that calls UnmarshalBinary, such as a cache client:
+//
+//	rdb.Set(ctx, key, stackerr.Stored{Err: err}, time.Hour)
+//	var s stackerr.Stored
+//	rdb.Get(ctx, key).Scan(&s)
+//
+// After loading, Err is the decoded error, with the frames from when it was stored. Stored also implements
+// driver.Valuer and sql.Scanner, for saving errors in a database column.
+type Stored struct {
+	Err error
+}
+
+// MarshalBinary encodes the error with EncodeBinary.
+func (s Stored) MarshalBinary() ([]byte, error) {
+	return EncodeBinary(s.Err)
+}
+
+// UnmarshalBinary decodes an error encoded by EncodeBinary into Err.
+func (s *Stored) UnmarshalBinary(data []byte) error {
+	err, decodeErr := DecodeBinary(data)
+	if decodeErr != nil {
+		return decodeErr
+	}
+	s.Err = err
+	return nil
+}
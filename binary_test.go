@@ -0,0 +1,139 @@
+package stackerr_test
+
+import (
+	"encoding"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	inner := stackerr.New("inner")
+	err := stackerr.WithSeverity(stackerr.WithCode(fmt.Errorf("outer: %w", inner), -7), stackerr.SeverityWarning)
+	data, encErr := stackerr.EncodeBinary(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	if data[0] != 1 {
+		t.Errorf("expected version 1, got %d", data[0])
+	}
+	decoded, decErr := stackerr.DecodeBinary(data)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	expected, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	got, _ := stackerr.Trace(decoded, stackerr.StandardFormat)
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+	msgpack, _ := stackerr.EncodeMsgpack(err)
+	if len(data) >= len(msgpack) {
+		t.Errorf("expected the binary format to be smaller than MessagePack: %d >= %d", len(data), len(msgpack))
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := stackerr.DecodeBinary(data[:i]); err == nil {
+			t.Errorf("expected an error for %d bytes", i)
+		}
+	}
+	if _, err := stackerr.DecodeBinary([]byte{2}); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+	if _, err := stackerr.EncodeBinary(nil); err == nil {
+		t.Error("expected an error for a nil error")
+	}
+}
+
+func TestBinaryMetadata(t *testing.T) {
+	err := metadataError(t)
+	data, encErr := stackerr.EncodeBinary(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	decoded, decErr := stackerr.DecodeBinary(data)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	checkMetadata(t, err, decoded)
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	// the values follow the frames, each string with a one-byte length and the context values and operation names
+	// with their counts. The category is one byte, between the context values and the operation names.
+	ops := 1 + 1 + len("load")
+	values := 1 + len("req-1") + 1 + len("trace-1") + 1 + len("span-1") + 1 + 1 + len("tenant") + 1 + len("acme")
+	end := len(data) - values - 1 - ops
+	for i := end + 1; i < len(data); i++ {
+		if i == len(data)-ops-1 || i == len(data)-ops {
+			continue
+		}
+		if _, err := stackerr.DecodeBinary(data[:i]); err == nil {
+			t.Errorf("expected an error for %d bytes", i)
+		}
+	}
+	// data without the values, as written by earlier releases, still decodes.
+	if _, decErr = stackerr.DecodeBinary(data[:end]); decErr != nil {
+		t.Error(decErr)
+	}
+	// so does data that ends before the category or before the operation names.
+	decoded, decErr = stackerr.DecodeBinary(data[:len(data)-ops-1])
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if id, _ := stackerr.RequestID(decoded); id != "req-1" {
+		t.Errorf("expected request ID req-1, got %q", id)
+	}
+	if _, ok := stackerr.CategoryOf(decoded); ok {
+		t.Error("expected no category")
+	}
+	decoded, decErr = stackerr.DecodeBinary(data[:len(data)-ops])
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if c, _ := stackerr.CategoryOf(decoded); c != stackerr.CategoryUnavailable {
+		t.Errorf("expected category unavailable, got %v", c)
+	}
+	if ops := stackerr.Ops(decoded); ops != nil {
+		t.Errorf("expected no operation names, got %q", ops)
+	}
+}
+
+func TestStored(t *testing.T) {
+	err := stackerr.New("cached")
+	m, ok := err.(encoding.BinaryMarshaler)
+	if !ok {
+		t.Fatal("expected MarshalBinary method")
+	}
+	data, mErr := m.MarshalBinary()
+	if mErr != nil {
+		t.Fatal(mErr)
+	}
+	stored, sErr := stackerr.Stored{Err: err}.MarshalBinary()
+	if sErr != nil {
+		t.Fatal(sErr)
+	}
+	if diff := cmp.Diff(data, stored); diff != "" {
+		t.Error(diff)
+	}
+
+	var s stackerr.Stored
+	var u encoding.BinaryUnmarshaler = &s
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), stackerr.Frames(s.Err)); diff != "" {
+		t.Error(diff)
+	}
+	if err := u.UnmarshalBinary([]byte{1, 5}); err == nil {
+		t.Error("expected an error for truncated data")
+	}
+	if s.Err.Error() != "cached" {
+		t.Errorf("expected a failed load to leave Err alone, got %v", s.Err)
+	}
+}
@@ -0,0 +1,232 @@
+package stackerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Category classifies what went wrong, independently of the protocol that reports it. HTTPStatusFor and GRPCCodeFor
+// map a category to the status for each protocol, so one classification drives both API layers, and the category is
+// included in the error's LogReport for dashboards.
+type Category int
+
+// The categories. The zero value means no category was attached.
+const (
+	// CategoryInvalidArgument is for requests that are malformed or have invalid values.
+	CategoryInvalidArgument Category = iota + 1
+	// CategoryNotFound is for requests for something that doesn't exist.
+	CategoryNotFound
+	// CategoryAlreadyExists is for requests to create something that already exists.
+	CategoryAlreadyExists
+	// CategoryConflict is for requests that conflict with a concurrent change, such as a failed optimistic lock.
+	CategoryConflict
+	// CategoryPermissionDenied is for callers that aren't allowed to do what they asked.
+	CategoryPermissionDenied
+	// CategoryUnauthenticated is for callers that didn't provide valid credentials.
+	CategoryUnauthenticated
+	// CategoryResourceExhausted is for requests rejected by a quota or rate limit.
+	CategoryResourceExhausted
+	// CategoryFailedPrecondition is for requests that can't be done in the current state of the system.
+	CategoryFailedPrecondition
+	// CategoryUnimplemented is for operations that aren't supported.
+	CategoryUnimplemented
+	// CategoryUnavailable is for failures that are expected to go away if the request is retried.
+	CategoryUnavailable
+	// CategoryDeadlineExceeded is for requests that ran out of time.
+	CategoryDeadlineExceeded
+	// CategoryCanceled is for requests that were canceled by the caller.
+	CategoryCanceled
+	// CategoryInternal is for bugs and other failures that the caller can't do anything about.
+	CategoryInternal
+)
+
+var categoryNames = map[Category]string{
+	CategoryInvalidArgument:    "invalid_argument",
+	CategoryNotFound:           "not_found",
+	CategoryAlreadyExists:      "already_exists",
+	CategoryConflict:           "conflict",
+	CategoryPermissionDenied:   "permission_denied",
+	CategoryUnauthenticated:    "unauthenticated",
+	CategoryResourceExhausted:  "resource_exhausted",
+	CategoryFailedPrecondition: "failed_precondition",
+	CategoryUnimplemented:      "unimplemented",
+	CategoryUnavailable:        "unavailable",
+	CategoryDeadlineExceeded:   "deadline_exceeded",
+	CategoryCanceled:           "canceled",
+	CategoryInternal:           "internal",
+}
+
+// String returns the name of the category, such as "not_found".
+func (c Category) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Category(%d)", int(c))
+}
+
+// MarshalText encodes the category as its name.
+func (c Category) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a category name.
+func (c *Category) UnmarshalText(text []byte) error {
+	for k, v := range categoryNames {
+		if v == string(text) {
+			*c = k
+			return nil
+		}
+	}
+	return fmt.Errorf("stackerr: unknown category %q", text)
+}
+
+// WithCategory attaches a category to an error. If there is no stack trace in the unwrap chain for err, one is
+// captured. WithCategory returns nil when a nil error is passed in.
+func WithCategory(err error, c Category) error {
+	return annotate(err, categoryKey, c, 1)
+}
+
+// CategoryOf returns the category attached by the outermost call to WithCategory in the unwrap chain for the error.
+// Without one, errors that match context.DeadlineExceeded or context.Canceled get CategoryDeadlineExceeded or
+// CategoryCanceled. The second return value is false if the error has no category.
+func CategoryOf(err error) (Category, bool) {
+	if v, ok := lookup(err, categoryKey); ok {
+		return v.(Category), true
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CategoryDeadlineExceeded, true
+	case errors.Is(err, context.Canceled):
+		return CategoryCanceled, true
+	}
+	return 0, false
+}
+
+// categoryHTTPStatus maps each category to an HTTP status code. 499 is the status that nginx and many proxies log for
+// requests canceled by the client.
+var categoryHTTPStatus = map[Category]int{
+	CategoryInvalidArgument:    http.StatusBadRequest,
+	CategoryNotFound:           http.StatusNotFound,
+	CategoryAlreadyExists:      http.StatusConflict,
+	CategoryConflict:           http.StatusConflict,
+	CategoryPermissionDenied:   http.StatusForbidden,
+	CategoryUnauthenticated:    http.StatusUnauthorized,
+	CategoryResourceExhausted:  http.StatusTooManyRequests,
+	CategoryFailedPrecondition: http.StatusBadRequest,
+	CategoryUnimplemented:      http.StatusNotImplemented,
+	CategoryUnavailable:        http.StatusServiceUnavailable,
+	CategoryDeadlineExceeded:   http.StatusGatewayTimeout,
+	CategoryCanceled:           499,
+	CategoryInternal:           http.StatusInternalServerError,
+}
+
+// HTTPStatusFor returns the HTTP status code for the error's category (see CategoryOf), such as 404 for
+// CategoryNotFound. It returns 500 for an error without a category, and 200 for a nil error.
+func HTTPStatusFor(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if c, ok := CategoryOf(err); ok {
+		if status, ok := categoryHTTPStatus[c]; ok {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// categoryGRPCCode maps each category to the value of a gRPC status code.
+var categoryGRPCCode = map[Category]uint32{
+	CategoryCanceled:           1,
+	CategoryInvalidArgument:    3,
+	CategoryDeadlineExceeded:   4,
+	CategoryNotFound:           5,
+	CategoryAlreadyExists:      6,
+	CategoryPermissionDenied:   7,
+	CategoryResourceExhausted:  8,
+	CategoryFailedPrecondition: 9,
+	CategoryConflict:           10,
+	CategoryUnimplemented:      12,
+	CategoryInternal:           13,
+	CategoryUnavailable:        14,
+	CategoryUnauthenticated:    16,
+}
+
+// GRPCCodeFor returns the gRPC status code for the error's category (see CategoryOf), such as 5 (NotFound) for
+// CategoryNotFound, and 10 (Aborted) for CategoryConflict. It returns 2 (Unknown) for an error without a category, and
+// 0 (OK) for a nil error. stackerr doesn't depend on gRPC, so the code is returned as a number; convert it with
+// codes.Code(stackerr.GRPCCodeFor(err)), or connect.Code for Connect, which uses the same numbers.
+func GRPCCodeFor(err error) uint32 {
+	if err == nil {
+		return 0
+	}
+	if c, ok := CategoryOf(err); ok {
+		if code, ok := categoryGRPCCode[c]; ok {
+			return code
+		}
+	}
+	return 2
+}
+
+// categorized implements the category constructors, such as NotFound, skipping the constructor's frame.
+func categorized(c Category, format string, vals []interface{}) error {
+	return annotated{err: defaultFactory.errorf(2, format, vals...), key: categoryKey, value: c}
+}
+
+// Invalid works like Errorf, and attaches CategoryInvalidArgument to the error:
+//
+//	return stackerr.Invalid("page size %d is over %d", size, maxPageSize)
+func Invalid(format string, vals ...interface{}) error {
+	return categorized(CategoryInvalidArgument, format, vals)
+}
+
+// NotFound works like Errorf, and attaches CategoryNotFound to the error.
+func NotFound(format string, vals ...interface{}) error {
+	return categorized(CategoryNotFound, format, vals)
+}
+
+// AlreadyExists works like Errorf, and attaches CategoryAlreadyExists to the error.
+func AlreadyExists(format string, vals ...interface{}) error {
+	return categorized(CategoryAlreadyExists, format, vals)
+}
+
+// Conflict works like Errorf, and attaches CategoryConflict to the error.
+func Conflict(format string, vals ...interface{}) error {
+	return categorized(CategoryConflict, format, vals)
+}
+
+// PermissionDenied works like Errorf, and attaches CategoryPermissionDenied to the error.
+func PermissionDenied(format string, vals ...interface{}) error {
+	return categorized(CategoryPermissionDenied, format, vals)
+}
+
+// Unauthenticated works like Errorf, and attaches CategoryUnauthenticated to the error.
+func Unauthenticated(format string, vals ...interface{}) error {
+	return categorized(CategoryUnauthenticated, format, vals)
+}
+
+// ResourceExhausted works like Errorf, and attaches CategoryResourceExhausted to the error.
+func ResourceExhausted(format string, vals ...interface{}) error {
+	return categorized(CategoryResourceExhausted, format, vals)
+}
+
+// FailedPrecondition works like Errorf, and attaches CategoryFailedPrecondition to the error.
+func FailedPrecondition(format string, vals ...interface{}) error {
+	return categorized(CategoryFailedPrecondition, format, vals)
+}
+
+// Unimplemented works like Errorf, and attaches CategoryUnimplemented to the error.
+func Unimplemented(format string, vals ...interface{}) error {
+	return categorized(CategoryUnimplemented, format, vals)
+}
+
+// Unavailable works like Errorf, and attaches CategoryUnavailable to the error.
+func Unavailable(format string, vals ...interface{}) error {
+	return categorized(CategoryUnavailable, format, vals)
+}
+
+// Internal works like Errorf, and attaches CategoryInternal to the error.
+func Internal(format string, vals ...interface{}) error {
+	return categorized(CategoryInternal, format, vals)
+}
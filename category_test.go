@@ -0,0 +1,122 @@
+package stackerr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestCategory(t *testing.T) {
+	data := []struct {
+		name     string
+		err      error
+		category stackerr.Category
+		status   int
+		grpc     uint32
+	}{
+		{"nil", nil, 0, http.StatusOK, 0},
+		{"none", errors.New("plain"), 0, http.StatusInternalServerError, 2},
+		{"not found", stackerr.WithCategory(errors.New("no user"), stackerr.CategoryNotFound), stackerr.CategoryNotFound, http.StatusNotFound, 5},
+		{"conflict", stackerr.WithCategory(errors.New("stale"), stackerr.CategoryConflict), stackerr.CategoryConflict, http.StatusConflict, 10},
+		{"wrapped", fmt.Errorf("load: %w", stackerr.WithCategory(errors.New("denied"), stackerr.CategoryPermissionDenied)), stackerr.CategoryPermissionDenied, http.StatusForbidden, 7},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), stackerr.CategoryDeadlineExceeded, http.StatusGatewayTimeout, 4},
+		{"canceled", stackerr.Wrap(context.Canceled), stackerr.CategoryCanceled, 499, 1},
+		{"outermost wins", stackerr.WithCategory(stackerr.WithCategory(errors.New("x"), stackerr.CategoryInternal), stackerr.CategoryUnavailable), stackerr.CategoryUnavailable, http.StatusServiceUnavailable, 14},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			c, ok := stackerr.CategoryOf(v.err)
+			if c != v.category || ok != (v.category != 0) {
+				t.Errorf("expected category %v, got %v, %v", v.category, c, ok)
+			}
+			if status := stackerr.HTTPStatusFor(v.err); status != v.status {
+				t.Errorf("expected status %d, got %d", v.status, status)
+			}
+			if code := stackerr.GRPCCodeFor(v.err); code != v.grpc {
+				t.Errorf("expected gRPC code %d, got %d", v.grpc, code)
+			}
+		})
+	}
+
+	if stackerr.WithCategory(nil, stackerr.CategoryInternal) != nil {
+		t.Error("expected nil for a nil error")
+	}
+	err := stackerr.WithCategory(errors.New("no user"), stackerr.CategoryNotFound)
+	if !stackerr.HasStack(err) {
+		t.Error("expected WithCategory to capture a stack trace")
+	}
+	if r := stackerr.Report(err); r.Category != stackerr.CategoryNotFound {
+		t.Errorf("expected the category in the report, got %v", r.Category)
+	}
+	if c, _ := stackerr.CategoryOf(stackerr.FromReport(stackerr.Report(err))); c != stackerr.CategoryNotFound {
+		t.Errorf("expected FromReport to keep the category, got %v", c)
+	}
+	if out := stackerr.EncodeLogfmt(err); !strings.Contains(out, " category=not_found") {
+		t.Errorf("expected the category in %s", out)
+	}
+}
+
+func TestCategoryText(t *testing.T) {
+	for c := stackerr.CategoryInvalidArgument; c <= stackerr.CategoryInternal; c++ {
+		text, err := c.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded stackerr.Category
+		if err := decoded.UnmarshalText(text); err != nil || decoded != c {
+			t.Errorf("%s: expected %v, got %v, %v", text, c, decoded, err)
+		}
+	}
+	var c stackerr.Category
+	if err := c.UnmarshalText([]byte("bogus")); err == nil {
+		t.Error("expected an error for an unknown category")
+	}
+	if s := stackerr.Category(99).String(); s != "Category(99)" {
+		t.Errorf("unexpected name %s", s)
+	}
+}
+
+func TestCategoryConstructors(t *testing.T) {
+	inner := errors.New("connection refused")
+	data := []struct {
+		name     string
+		err      error
+		category stackerr.Category
+	}{
+		{"invalid", stackerr.Invalid("page size %d", 500), stackerr.CategoryInvalidArgument},
+		{"not found", stackerr.NotFound("user %d", 7), stackerr.CategoryNotFound},
+		{"already exists", stackerr.AlreadyExists("user %d", 7), stackerr.CategoryAlreadyExists},
+		{"conflict", stackerr.Conflict("version %d", 3), stackerr.CategoryConflict},
+		{"permission denied", stackerr.PermissionDenied("delete %s", "repo"), stackerr.CategoryPermissionDenied},
+		{"unauthenticated", stackerr.Unauthenticated("expired token"), stackerr.CategoryUnauthenticated},
+		{"resource exhausted", stackerr.ResourceExhausted("quota"), stackerr.CategoryResourceExhausted},
+		{"failed precondition", stackerr.FailedPrecondition("not empty"), stackerr.CategoryFailedPrecondition},
+		{"unimplemented", stackerr.Unimplemented("export"), stackerr.CategoryUnimplemented},
+		{"unavailable", stackerr.Unavailable("dial: %w", inner), stackerr.CategoryUnavailable},
+		{"internal", stackerr.Internal("bad state"), stackerr.CategoryInternal},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			if c, _ := stackerr.CategoryOf(v.err); c != v.category {
+				t.Errorf("expected %v, got %v", v.category, c)
+			}
+			if top := topFrame(t, v.err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestCategoryConstructors ") {
+				t.Errorf("expected the stack trace to start in the test, got %s", top)
+			}
+		})
+	}
+	if msg := data[1].err.Error(); msg != "user 7" {
+		t.Errorf("expected `user 7`, got `%s`", msg)
+	}
+	if !errors.Is(data[9].err, inner) {
+		t.Error("expected errors.Is to find the wrapped error")
+	}
+	if out := fmt.Sprintf("%+v", data[1].err); !strings.HasPrefix(out, "user 7\ngithub.com/jonbodner/stackerr_test.TestCategoryConstructors ") {
+		t.Errorf("expected %%+v to print the stack trace, got %q", out)
+	}
+}
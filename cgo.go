@@ -0,0 +1,76 @@
+package stackerr
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// CgoCollector returns the frames of the C code that most recently returned to Go on the current thread, innermost
+// frame first, or nil if there are none. A collector is usually a thin Go wrapper around a C library that records a
+// backtrace when it fails, such as with libunwind or backtrace(3), and symbolizes it.
+type CgoCollector func() []Frame
+
+var cgoCollector atomic.Value
+
+// SetCgoCollector installs the collector used by WrapCgo. Passing nil removes the collector.
+func SetCgoCollector(c CgoCollector) {
+	cgoCollector.Store(c)
+}
+
+// WrapCgo works like Wrap, for an error built from the result of a call into C. If a CgoCollector is installed, the
+// frames it returns are placed before the Go frames of the stack trace, so the trace starts where the C code failed.
+// Their Kind is FrameCgo. Call WrapCgo right after the C call returns, before anything else runs C code on the
+// thread:
+//
+//	if rc := C.decode_frame(ctx); rc < 0 {
+//		return stackerr.WrapCgo(fmt.Errorf("decode_frame: %d", rc))
+//	}
+//
+// The C frames are kept by Trace, Frames, %+v, and the encodings that keep symbolized frames, but not by
+// EncodeCompact.
+//
+// Errors created in Go code that was called from C don't need WrapCgo: once a traceback function is installed with
+// runtime.SetCgoTraceback, the runtime includes the C frames in every stack trace.
+func WrapCgo(err error) error {
+	if err == nil {
+		return nil
+	}
+	c, _ := cgoCollector.Load().(CgoCollector)
+	if c == nil {
+		return wrap(err, 1)
+	}
+	if _, ok := asStack(err); ok {
+		return err
+	}
+	// the collector runs first, so that capturing the stack can't disturb what it recorded.
+	frames := c()
+	trace := captureStack(1)
+	if len(frames) > 0 {
+		trace = &callStack{head: trace.pcs(), cgo: frames, dropped: trace.dropped}
+	}
+	return publish(&errorStack{
+		Err:      err,
+		trace:    trace,
+		snapshot: takeSnapshot(),
+	})
+}
+
+// cgoFrames returns the frames of the C code for the callStack, followed by the frames for its program counters.
+func (c *callStack) cgoFrames() []runtime.Frame {
+	goFrames := dropHelpers(cachedSymbolize(c.pcs()))
+	out := make([]runtime.Frame, 0, len(c.cgo)+len(goFrames))
+	for _, v := range c.cgo {
+		out = append(out, runtime.Frame{Function: v.Function, File: v.File, Line: v.Line})
+	}
+	return append(out, goFrames...)
+}
+
+// isCgo reports whether f is one of the frames of the C code for the callStack.
+func (c *callStack) isCgo(f Frame) bool {
+	for _, v := range c.cgo {
+		if v.Function == f.Function && v.File == f.File && v.Line == f.Line {
+			return true
+		}
+	}
+	return false
+}
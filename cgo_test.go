@@ -0,0 +1,72 @@
+package stackerr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestWrapCgo(t *testing.T) {
+	if stackerr.WrapCgo(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+	// without a collector, WrapCgo works like Wrap.
+	err := stackerr.WrapCgo(errors.New("no collector"))
+	if f := stackerr.Frames(err); len(f) == 0 || !strings.HasSuffix(f[0].Function, ".TestWrapCgo") {
+		t.Errorf("expected the trace to start in the test, got %v", f)
+	}
+
+	cFrames := []stackerr.Frame{
+		{Function: "decode_frame", File: "/src/codec/decode.c", Line: 120},
+		{Function: "codec_run", File: "/src/codec/run.c", Line: 33},
+	}
+	calls := 0
+	stackerr.SetCgoCollector(func() []stackerr.Frame {
+		calls++
+		return cFrames
+	})
+	defer stackerr.SetCgoCollector(nil)
+
+	err = stackerr.WrapCgo(errors.New("decode_frame: -22"))
+	frames := stackerr.Frames(err)
+	if len(frames) < 3 {
+		t.Fatalf("expected C and Go frames, got %v", frames)
+	}
+	for i, v := range cFrames {
+		v.Kind = stackerr.FrameCgo
+		if diff := cmp.Diff(v, frames[i]); diff != "" {
+			t.Error(diff)
+		}
+	}
+	if !strings.HasSuffix(frames[2].Function, ".TestWrapCgo") || frames[2].Kind != stackerr.FrameApp {
+		t.Errorf("expected the Go frames to follow the C frames, got %+v", frames[2])
+	}
+	lines := traceLines(t, err)
+	if lines[0] != "decode_frame (/src/codec/decode.c:120)" || !strings.HasPrefix(lines[2], frames[2].Function+" ") {
+		t.Errorf("unexpected trace %v", lines)
+	}
+
+	// a C frame that is dropped as a helper frame doesn't shift the kinds of the frames after it.
+	stackerr.RegisterHelperPackage("example.com/codec/shim")
+	cFrames = []stackerr.Frame{
+		{Function: "example.com/codec/shim.Decode", File: "/src/codec/shim/shim.go", Line: 8},
+		{Function: "decode_frame", File: "/src/codec/decode.c", Line: 120},
+	}
+	frames = stackerr.Frames(stackerr.WrapCgo(errors.New("decode_frame: -22")))
+	if len(frames) < 2 || frames[0].Function != "decode_frame" || frames[0].Kind != stackerr.FrameCgo {
+		t.Fatalf("expected the trace to start with the C frame, got %v", frames)
+	}
+	if !strings.HasSuffix(frames[1].Function, ".TestWrapCgo") || frames[1].Kind != stackerr.FrameApp {
+		t.Errorf("expected the Go frames to follow the C frame, got %+v", frames[1])
+	}
+
+	// an error that already has a stack trace is returned as is, and the collector isn't called.
+	calls = 0
+	if stackerr.WrapCgo(err) != err || calls != 0 {
+		t.Error("expected an error with a stack trace to be returned unchanged")
+	}
+}
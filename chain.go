@@ -0,0 +1,186 @@
+package stackerr
+
+import "errors"
+
+// walk calls fn for err and every error in its unwrap tree, depth-first, in the same order that errors.Is and errors.As
+// check them. Errors with an Errors() []error method, like the ones combined by go.uber.org/multierr before it added
+// Unwrap() []error, are treated as wrapping those errors, and so are errors with a WrappedErrors() []error method, like
+// *multierror.Error from github.com/hashicorp/go-multierror, whose Unwrap method only steps from one error to the next
+// without looking inside them. It stops when fn returns false, and returns false if it was stopped.
+func walk(err error, fn func(error) bool) bool {
+	for err != nil {
+		if !fn(err) {
+			return false
+		}
+		switch u := err.(type) {
+		case interface{ WrappedErrors() []error }:
+			return walkAll(u.WrappedErrors(), fn)
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			return walkAll(u.Unwrap(), fn)
+		case interface{ Errors() []error }:
+			return walkAll(u.Errors(), fn)
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// walkAll calls walk for each of the errors, stopping when fn returns false.
+func walkAll(errs []error, fn func(error) bool) bool {
+	for _, v := range errs {
+		if !walk(v, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Chain returns the message for each error in the unwrap tree for err, outermost first. Errors that don't change the
+// message of the error they wrap, like the ones that add a stack trace, are skipped.
+func Chain(err error) []string {
+	var out []string
+	walk(err, func(e error) bool {
+		msg := e.Error()
+		if len(out) == 0 || out[len(out)-1] != msg {
+			out = append(out, msg)
+		}
+		return true
+	})
+	return out
+}
+
+// traceLinesOf implements TraceOf.
+func traceLinesOf(err, target error, t *frameTemplate, opts []Option) ([]string, error) {
+	se, ok := stackFor(err, target)
+	if !ok {
+		return nil, nil
+	}
+	if t == nil {
+		t = StandardFormat
+	}
+	return se.renderTrace(t, newRenderOptions(opts))
+}
+
+// stackFor implements the search for TraceOf, checking branches in the same order as errors.Is.
+func stackFor(err, target error) (*errorStack, bool) {
+	if err == nil || !errors.Is(err, target) {
+		return nil, false
+	}
+	var children []error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		children = []error{u.Unwrap()}
+	case interface{ Unwrap() []error }:
+		children = u.Unwrap()
+	}
+	for _, v := range children {
+		if se, ok := stackFor(v, target); ok {
+			return se, true
+		}
+	}
+	se, ok := err.(*errorStack)
+	return se, ok
+}
+
+// StackedErrors returns every error in the unwrap tree for err that captured a stack trace, outermost first, in the
+// same order that errors.Is checks them. Errors created by Errorf that reuse a stack trace from the error they wrap
+// are left out, since the error that captured the trace is also in the tree. It returns nil if no error in the tree
+// has a stack trace.
+func StackedErrors(err error) []error {
+	var out []error
+	walk(err, func(e error) bool {
+		if se, ok := e.(*errorStack); ok && se.earlier == nil {
+			out = append(out, e)
+		}
+		return true
+	})
+	return out
+}
+
+// LeafError is one of the errors returned by Flatten.
+type LeafError struct {
+	// Err is the leaf, an error that doesn't wrap any other error.
+	Err error
+	// Chain holds the message for each error on the path from the root of the tree to the leaf, outermost first. Like
+	// Chain, it skips errors that don't change the message of the error they wrap.
+	Chain []string
+	// Stack is the innermost error on the path from the root to the leaf that has a stack trace, or nil if there is
+	// none. Pass it to Trace or Frames to get the stack trace for the leaf.
+	Stack error
+}
+
+// Flatten returns each leaf of the unwrap tree for err, in the same order that errors.Is checks them, along with the
+// messages on its path and its nearest stack trace. Use it to report each failure in an error that joins several,
+// such as one from errors.Join or from a batch operation. An error that doesn't wrap more than one error has a single
+// leaf. Flatten returns nil when a nil error is passed in.
+func Flatten(err error) []LeafError {
+	var out []LeafError
+	flatten(err, nil, nil, &out)
+	return out
+}
+
+// flatten adds the leaves under err to out. chain holds the messages on the path to err, and stack the innermost
+// error on the path that has a stack trace.
+func flatten(err error, chain []string, stack error, out *[]LeafError) {
+	for err != nil {
+		if msg := err.Error(); len(chain) == 0 || chain[len(chain)-1] != msg {
+			// copy the chain, so that branches that share a path don't share its memory.
+			chain = append(chain[:len(chain):len(chain)], msg)
+		}
+		if _, ok := err.(*errorStack); ok {
+			stack = err
+		}
+		switch u := err.(type) {
+		case interface{ WrappedErrors() []error }:
+			flattenAll(err, chain, stack, out)
+			return
+		case interface{ Unwrap() error }:
+			next := u.Unwrap()
+			if next == nil {
+				*out = append(*out, LeafError{Err: err, Chain: chain, Stack: stack})
+			}
+			err = next
+		case interface{ Unwrap() []error }, interface{ Errors() []error }:
+			flattenAll(err, chain, stack, out)
+			return
+		default:
+			*out = append(*out, LeafError{Err: err, Chain: chain, Stack: stack})
+			return
+		}
+	}
+}
+
+// flattenAll adds the leaves under each error wrapped by err, an error that wraps more than one, to out. err is a
+// leaf if it doesn't wrap any.
+func flattenAll(err error, chain []string, stack error, out *[]LeafError) {
+	children := multiUnwrap(err)
+	if len(children) == 0 {
+		*out = append(*out, LeafError{Err: err, Chain: chain, Stack: stack})
+	}
+	for _, v := range children {
+		flatten(v, chain, stack, out)
+	}
+}
+
+// Cause returns the innermost error in the unwrap chain for err, following Unwrap() error methods and, for errors
+// from github.com/pkg/errors, Cause() error methods. It stops at an error that wraps more than one error. Cause
+// returns nil when a nil error is passed in.
+func Cause(err error) error {
+	for err != nil {
+		var next error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			next = u.Unwrap()
+		case interface{ Cause() error }:
+			next = u.Cause()
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestTraceOf(t *testing.T) {
+	errX := errors.New("x")
+	errY := errors.New("y")
+	errZ := errors.New("z")
+	a := stackerr.Wrap(errX)
+	b := stackerr.Wrap(errY)
+	joined := errors.Join(a, b, errZ)
+	outer := stackerr.AttachStack(joined, stackerr.CaptureStack(0))
+
+	data := []struct {
+		name     string
+		target   error
+		expected []string
+	}{
+		{"first", errX, traceLines(t, a)},
+		{"second", errY, traceLines(t, b)},
+		{"stacked target", b, traceLines(t, b)},
+		{"no stack below target", errZ, traceLines(t, outer)},
+		{"not in tree", errors.New("missing"), nil},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			lines, err := stackerr.TraceOf(outer, v.target, stackerr.StandardFormat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(v.expected, lines); diff != "" {
+				t.Error(diff)
+			}
+			// like Trace, a nil template means StandardFormat.
+			lines, err = stackerr.TraceOf(outer, v.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(v.expected, lines); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	lines, err := stackerr.TraceOf(joined, errZ, stackerr.StandardFormat)
+	if lines != nil || err != nil {
+		t.Errorf("expected nil results for a target without a stack trace, got %v, %v", lines, err)
+	}
+}
+
+func TestStackedErrors(t *testing.T) {
+	if stackerr.StackedErrors(errors.New("plain")) != nil {
+		t.Error("expected nil for an error without a stack trace")
+	}
+
+	a := stackerr.New("a")
+	b := stackerr.Wrap(errors.New("b"))
+	joined := errors.Join(fmt.Errorf("wrapped: %w", a), errors.New("no stack"), b)
+	outer := stackerr.AttachStack(joined, stackerr.CaptureStack(0))
+	reused := stackerr.Errorf("reused: %w", outer)
+
+	stacked := stackerr.StackedErrors(reused)
+	expected := []error{outer, a, b}
+	if len(stacked) != len(expected) {
+		t.Fatalf("expected %d errors, got %d", len(expected), len(stacked))
+	}
+	for i, v := range expected {
+		if stacked[i] != v {
+			t.Errorf("%d: expected %v, got %v", i, v, stacked[i])
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	if stackerr.Flatten(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errC := errors.New("c")
+	a := stackerr.Wrap(errA)
+	b := fmt.Errorf("wrapped: %w", errB)
+	batch := stackerr.AttachStack(errors.Join(a, b), stackerr.CaptureStack(0))
+	joined := errors.Join(batch, errC)
+	outer := fmt.Errorf("batch: %w", joined)
+
+	leaves := stackerr.Flatten(outer)
+	type leaf struct {
+		Err   error
+		Chain []string
+		Stack error
+	}
+	var got []leaf
+	for _, v := range leaves {
+		got = append(got, leaf(v))
+	}
+	batchMsg := batch.Error()
+	expected := []leaf{
+		{errA, []string{outer.Error(), joined.Error(), batchMsg, "a"}, a},
+		{errB, []string{outer.Error(), joined.Error(), batchMsg, "wrapped: b", "b"}, batch},
+		{errC, []string{outer.Error(), joined.Error(), "c"}, nil},
+	}
+	if diff := cmp.Diff(expected, got, cmp.Comparer(func(x, y error) bool { return x == y })); diff != "" {
+		t.Error(diff)
+	}
+
+	single := stackerr.New("single")
+	if diff := cmp.Diff([]string{"single"}, stackerr.Flatten(single)[0].Chain); diff != "" {
+		t.Error(diff)
+	}
+}
+
+type causer struct {
+	cause error
+}
+
+func (c causer) Error() string {
+	return "causer: " + c.cause.Error()
+}
+
+func (c causer) Cause() error {
+	return c.cause
+}
+
+func TestCause(t *testing.T) {
+	if stackerr.Cause(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+	root := errors.New("root")
+	err := stackerr.Errorf("outer: %w", causer{cause: fmt.Errorf("middle: %w", stackerr.Wrap(root))})
+	if stackerr.Cause(err) != root {
+		t.Errorf("expected the root error, got %v", stackerr.Cause(err))
+	}
+	joined := errors.Join(root, errors.New("other"))
+	if stackerr.Cause(stackerr.Wrap(joined)) != joined {
+		t.Error("expected Cause to stop at an error that wraps more than one error")
+	}
+}
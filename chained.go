@@ -0,0 +1,154 @@
+package stackerr
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// layout selects how %+v arranges messages and stack traces.
+type layout int
+
+const (
+	layoutDefault layout = iota
+	layoutChained
+	layoutCausedBy
+)
+
+// ChainedOutput makes %+v print each layer of the unwrap chain, innermost first, as a message followed by the part of
+// the stack trace that belongs to that layer, like the output of github.com/pkg/errors. The layer that captured the
+// stack trace is followed by all of its frames, and each layer added by an Errorf call that reused it is followed by
+// the frame of that call. When a layer's message ends with ": " and the message of the layer it wraps, only the part
+// before that is printed. Layers without a stack trace of their own, such as ones added by fmt.Errorf, print only
+// their message. The chain ends at an error that wraps more than one error. Pass ChainedOutput to SetFormatOptions;
+// Trace ignores it.
+func ChainedOutput() Option {
+	return func(o *renderOptions) {
+		o.layout = layoutChained
+	}
+}
+
+// CausedByOutput makes %+v print the unwrap chain in the style of a Java stack trace: the outermost message and its
+// frames, then a "Caused by: " section with the message of each error it wraps that changes the message or has a
+// stack trace of its own. The frames are the same as the ones printed by ChainedOutput. Frames start with "\tat "
+// unless FramePrefix is also passed, and when a section's frames end with the same frames as the section before it,
+// those are replaced with a line like "... 3 more". Pass CausedByOutput to SetFormatOptions; Trace ignores it.
+func CausedByOutput() Option {
+	return func(o *renderOptions) {
+		o.layout = layoutCausedBy
+	}
+}
+
+// chainLayer is one message printed by ChainedOutput or CausedByOutput, with the lines of its part of the stack trace.
+type chainLayer struct {
+	msg   string
+	lines []string
+}
+
+// chainLayers returns the layers of the unwrap chain for the errorStack, outermost first.
+func (e *errorStack) chainLayers(o *renderOptions) []chainLayer {
+	var layers []chainLayer
+	for err := error(e); err != nil; {
+		msg := err.Error()
+		if len(layers) == 0 || layers[len(layers)-1].msg != msg {
+			layers = append(layers, chainLayer{msg: msg})
+		}
+		if se, ok := err.(*errorStack); ok {
+			layers[len(layers)-1].lines = se.layerLines(o)
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return layers
+}
+
+// writeChained writes the errorStack in the format described by ChainedOutput.
+func (e *errorStack) writeChained(w io.Writer, o *renderOptions) {
+	layers := e.chainLayers(o)
+	var b strings.Builder
+	for i := len(layers) - 1; i >= 0; i-- {
+		msg := layers[i].msg
+		if i < len(layers)-1 {
+			msg = strings.TrimSuffix(msg, ": "+layers[i+1].msg)
+			b.WriteString("\n")
+		}
+		b.WriteString(msg)
+		if len(layers[i].lines) > 0 {
+			b.WriteString(o.separator)
+			b.WriteString(o.joinFrames(layers[i].lines))
+		}
+	}
+	if o.trailingNewline {
+		b.WriteString("\n")
+	}
+	io.WriteString(w, b.String()) // nolint: errcheck
+}
+
+// writeCausedBy writes the errorStack in the format described by CausedByOutput.
+func (e *errorStack) writeCausedBy(w io.Writer, o *renderOptions) {
+	prefix := o.framePrefix
+	if prefix == "" {
+		prefix = "\tat "
+	}
+	// the prefix is added here, since the line for the frames in common gets a different one.
+	plain := *o
+	plain.framePrefix = ""
+	var b strings.Builder
+	var enclosing []string
+	for i, v := range e.chainLayers(o) {
+		if i > 0 {
+			b.WriteString("\nCaused by: ")
+		}
+		b.WriteString(v.msg)
+		if len(v.lines) == 0 {
+			continue
+		}
+		keep := len(v.lines)
+		common := commonSuffix(v.lines, enclosing)
+		if common < keep {
+			keep -= common
+		}
+		lines := make([]string, 0, keep+1)
+		for _, l := range v.lines[:keep] {
+			lines = append(lines, prefix+l)
+		}
+		if keep < len(v.lines) {
+			lines = append(lines, fmt.Sprintf("\t... %d more", common))
+		}
+		enclosing = v.lines
+		b.WriteString(o.separator)
+		b.WriteString(plain.joinFrames(lines))
+	}
+	if o.trailingNewline {
+		b.WriteString("\n")
+	}
+	io.WriteString(w, b.String()) // nolint: errcheck
+}
+
+// commonSuffix returns the number of lines at the end of a that are the same as the lines at the end of b.
+func commonSuffix(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// layerLines returns the lines that ChainedOutput prints after the errorStack's message: the frame of the Errorf call
+// that created it if it reused an earlier stack trace, and its whole stack trace otherwise.
+func (e *errorStack) layerLines(o *renderOptions) []string {
+	if e.earlier == nil {
+		lines, _ := e.renderTrace(StandardFormat, o)
+		return lines
+	}
+	if e.site == 0 {
+		return nil
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{e.site}).Next()
+	lines, _ := render([]runtime.Frame{frame}, StandardFormat, o)
+	return lines
+}
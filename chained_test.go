@@ -0,0 +1,74 @@
+package stackerr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestChainedOutput(t *testing.T) {
+	only := stackerr.OnlyModules("github.com/jonbodner/stackerr_test.")
+	stackerr.SetFormatOptions(stackerr.ChainedOutput(), only)
+	defer stackerr.SetFormatOptions()
+
+	inner := stackerr.New("inner")
+	outer := stackerr.Errorf("outer: %w", fmt.Errorf("middle: %w", inner))
+
+	innerLines, err := stackerr.Trace(inner, stackerr.StandardFormat, only)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site, ok := stackerr.WrapSite(outer)
+	if !ok {
+		t.Fatal("expected a wrap site")
+	}
+	expected := "inner\n" + strings.Join(innerLines, "\n") + "\nmiddle\nouter\n" +
+		fmt.Sprintf("%s (%s:%d)", site.Function, site.File, site.Line)
+	if diff := cmp.Diff(expected, fmt.Sprintf("%+v", outer)); diff != "" {
+		t.Error(diff)
+	}
+
+	// a single layer prints its message and its whole stack trace.
+	if diff := cmp.Diff("inner\n"+strings.Join(innerLines, "\n"), fmt.Sprintf("%+v", inner)); diff != "" {
+		t.Error(diff)
+	}
+
+	// %v is unchanged.
+	if out := fmt.Sprintf("%v", outer); out != "outer: middle: inner" {
+		t.Errorf("expected `outer: middle: inner`, got `%s`", out)
+	}
+}
+
+func causedByInner() error {
+	return stackerr.New("inner")
+}
+
+func TestCausedByOutput(t *testing.T) {
+	stackerr.SetFormatOptions(stackerr.CausedByOutput())
+	defer stackerr.SetFormatOptions()
+
+	inner := causedByInner()
+	outer := stackerr.Rewrap(fmt.Errorf("outer: %w", inner))
+	outerLines := traceLines(t, outer)
+	innerLines := traceLines(t, inner)
+	// the inner trace has one more frame than the outer one, and the frame for the test function is at a different line.
+	if len(innerLines) != len(outerLines)+1 {
+		t.Fatalf("unexpected traces %v and %v", outerLines, innerLines)
+	}
+	common := len(outerLines) - 1
+	expected := "outer: inner\n\tat " + strings.Join(outerLines, "\n\tat ") +
+		"\nCaused by: inner\n\tat " + strings.Join(innerLines[:2], "\n\tat ") + fmt.Sprintf("\n\t... %d more", common)
+	if diff := cmp.Diff(expected, fmt.Sprintf("%+v", outer)); diff != "" {
+		t.Error(diff)
+	}
+
+	stackerr.SetFormatOptions(stackerr.CausedByOutput(), stackerr.FramePrefix("  "), stackerr.TrailingNewline())
+	expected = "inner\n  " + strings.Join(innerLines, "\n  ") + "\n"
+	if diff := cmp.Diff(expected, fmt.Sprintf("%+v", inner)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,80 @@
+// Command stackerr-fmt reads errors with stack traces from log output and pretty-prints them:
+//
+//	kubectl logs my-pod | stackerr-fmt --only-app
+//
+// It reads the files named on the command line, or standard input if there are none. It finds errors encoded as
+// JSON by stackerr, one per line, and errors printed with %+v, which are a message line followed by frame lines. Other
+// lines are skipped.
+//
+// Colors are used when standard output is a terminal and the NO_COLOR environment variable isn't set; use -color to
+// change this. With -only-app, frames from the standard library and from dependencies are hidden. By default, an error
+// with the same stack trace as an earlier one is printed as a single line; use -dedup=false to print every error in
+// full.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	color := flag.String("color", "auto", "when to use colors: auto, always, or never")
+	onlyApp := flag.Bool("only-app", false, "hide frames from the standard library and from dependencies")
+	dedup := flag.Bool("dedup", true, "print errors with the same stack trace as an earlier error on a single line")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: stackerr-fmt [-color auto|always|never] [-only-app] [-dedup=false] [file ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	useColor, err := colorMode(*color, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	p := newPrinter(os.Stdout, useColor, *onlyApp, *dedup)
+	if flag.NArg() == 0 {
+		if err := readEntries(os.Stdin, p.print); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	failed := false
+	for _, v := range flag.Args() {
+		if err := readFile(v, p); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readFile(name string, p *printer) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return readEntries(f, p.print)
+}
+
+// colorMode reports whether to use colors for the output.
+func colorMode(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+		info, err := out.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("unknown color mode %q", mode)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// entry is an error read from the input.
+type entry struct {
+	Message string
+	Frames  []stackerr.Frame
+}
+
+// frameLine matches a frame rendered with stackerr.StandardFormat, such as "main.main (/src/main.go:12)".
+var frameLine = regexp.MustCompile(`^\s*(\S+) \((.+):(\d+)\)$`)
+
+// readEntries reads errors from r and calls fn with each one. A line that holds a JSON object with a message and
+// frames, as written by MarshalJSON or as a LogReport, is one error. Otherwise, errors are read from the output of %+v:
+// a run of frame lines is an error, and its message is the line before the run. Other lines are skipped.
+func readEntries(r io.Reader, fn func(entry)) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	var cur *entry
+	var last string
+	flush := func() {
+		if cur != nil {
+			fn(*cur)
+			cur = nil
+		}
+	}
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if e, ok := jsonEntry(line); ok {
+			flush()
+			fn(e)
+			last = ""
+			continue
+		}
+		if m := frameLine.FindStringSubmatch(line); m != nil {
+			if cur == nil {
+				cur = &entry{Message: last}
+			}
+			n, _ := strconv.Atoi(m[3])
+			cur.Frames = append(cur.Frames, stackerr.Frame{Function: m[1], File: m[2], Line: n})
+			continue
+		}
+		// lines such as "[3 stdlib frames]" are written in place of frames by some options.
+		if trimmed := strings.TrimSpace(line); cur != nil && strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+		flush()
+		last = line
+	}
+	flush()
+	return s.Err()
+}
+
+// jsonEntry decodes an error from a line that holds a JSON object, starting at the first {. It reports false if the
+// line doesn't hold an error with frames.
+func jsonEntry(line string) (entry, bool) {
+	start := strings.IndexByte(line, '{')
+	if start == -1 {
+		return entry{}, false
+	}
+	var r stackerr.LogReport
+	if err := json.Unmarshal([]byte(line[start:]), &r); err != nil || r.Frames == nil {
+		return entry{}, false
+	}
+	return entry{Message: r.Message, Frames: r.Frames}, true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestReadEntries(t *testing.T) {
+	in := `starting up
+2024/05/01 12:00:00 request failed: boom
+main.handle (/src/app/main.go:20)
+[2 stdlib frames]
+net/http.HandlerFunc.ServeHTTP (/usr/local/go/src/net/http/server.go:2136)
+listening
+{"level":"error","message":"bad input","frames":[{"function":"main.parse","file":"/src/app/parse.go","line":7,"kind":"app"}]}
+{"level":"info","msg":"not an error"}
+main.main (/src/app/main.go:9)
+`
+	var got []entry
+	if err := readEntries(strings.NewReader(in), func(e entry) { got = append(got, e) }); err != nil {
+		t.Fatal(err)
+	}
+	expected := []entry{
+		{
+			Message: "2024/05/01 12:00:00 request failed: boom",
+			Frames: []stackerr.Frame{
+				{Function: "main.handle", File: "/src/app/main.go", Line: 20},
+				{Function: "net/http.HandlerFunc.ServeHTTP", File: "/usr/local/go/src/net/http/server.go", Line: 2136},
+			},
+		},
+		{
+			Message: "bad input",
+			Frames:  []stackerr.Frame{{Function: "main.parse", File: "/src/app/parse.go", Line: 7, Kind: stackerr.FrameApp}},
+		},
+		{
+			Message: `{"level":"info","msg":"not an error"}`,
+			Frames:  []stackerr.Frame{{Function: "main.main", File: "/src/app/main.go", Line: 9}},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadEntriesFromStackerr(t *testing.T) {
+	err := stackerr.New("boom")
+	frames := stackerr.Frames(err)
+	var got []entry
+	readErr := readEntries(strings.NewReader(stackerrText(err)), func(e entry) { got = append(got, e) })
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(got) != 1 || got[0].Message != "boom" || len(got[0].Frames) != len(frames) {
+		t.Fatalf("unexpected entries %v", got)
+	}
+	if got[0].Frames[0].Function != frames[0].Function || got[0].Frames[0].Line != frames[0].Line {
+		t.Errorf("expected %v, got %v", frames[0], got[0].Frames[0])
+	}
+}
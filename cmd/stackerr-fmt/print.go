@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+)
+
+const (
+	reset = "\x1b[0m"
+	bold  = "\x1b[1m"
+	dim   = "\x1b[2m"
+	red   = "\x1b[31m"
+	cyan  = "\x1b[36m"
+)
+
+// printer writes entries to w.
+type printer struct {
+	w io.Writer
+	// color turns on ANSI colors.
+	color bool
+	// onlyApp leaves out frames from the standard library and from dependencies.
+	onlyApp bool
+	// dedup prints a single line for an error with the same stack trace as an earlier one.
+	dedup bool
+
+	count int
+	seen  map[string]int
+}
+
+func newPrinter(w io.Writer, color, onlyApp, dedup bool) *printer {
+	return &printer{w: w, color: color, onlyApp: onlyApp, dedup: dedup, seen: map[string]int{}}
+}
+
+func (p *printer) paint(code, s string) string {
+	if !p.color {
+		return s
+	}
+	return code + s + reset
+}
+
+// print writes the error's message, followed by its frames.
+func (p *printer) print(e entry) {
+	p.count++
+	if p.dedup {
+		key := stackKey(e)
+		if first, ok := p.seen[key]; ok {
+			fmt.Fprintf(p.w, "%s %s\n", p.paint(bold+red, fmt.Sprintf("#%d", p.count)),
+				p.paint(dim, fmt.Sprintf("%s (same stack trace as #%d)", e.Message, first)))
+			return
+		}
+		p.seen[key] = p.count
+	}
+	fmt.Fprintf(p.w, "%s %s\n", p.paint(bold+red, fmt.Sprintf("#%d", p.count)), p.paint(bold, e.Message))
+	hidden := 0
+	for _, f := range e.Frames {
+		if p.onlyApp && !isApp(f) {
+			hidden++
+			continue
+		}
+		switch {
+		case f.Kind == stackerr.FrameCgo:
+			fmt.Fprintf(p.w, "    %s\n", p.paint(dim, "[cgo/unknown frame]"))
+		case f.IsStdlib():
+			fmt.Fprintf(p.w, "    %s\n        %s\n", p.paint(dim, f.Function), p.paint(dim, fmt.Sprintf("%s:%d", f.File, f.Line)))
+		case f.Kind == stackerr.FrameApp:
+			fmt.Fprintf(p.w, "    %s\n        %s\n", p.paint(bold+cyan, f.Function), fmt.Sprintf("%s:%d", f.File, f.Line))
+		default:
+			fmt.Fprintf(p.w, "    %s\n        %s\n", f.Function, p.paint(dim, fmt.Sprintf("%s:%d", f.File, f.Line)))
+		}
+	}
+	switch {
+	case hidden == 1:
+		fmt.Fprintf(p.w, "    %s\n", p.paint(dim, "[1 frame hidden]"))
+	case hidden > 1:
+		fmt.Fprintf(p.w, "    %s\n", p.paint(dim, fmt.Sprintf("[%d frames hidden]", hidden)))
+	}
+	fmt.Fprintln(p.w)
+}
+
+// isApp reports whether the frame is from the program's own code. Frames read from %+v output have no kind, so
+// every frame that isn't from the standard library counts.
+func isApp(f stackerr.Frame) bool {
+	if f.Kind == "" {
+		return !f.IsStdlib()
+	}
+	return f.Kind == stackerr.FrameApp
+}
+
+// stackKey identifies the stack trace for an error. An error without frames is identified by its message.
+func stackKey(e entry) string {
+	if len(e.Frames) == 0 {
+		return "message:" + e.Message
+	}
+	var b strings.Builder
+	for _, f := range e.Frames {
+		fmt.Fprintf(&b, "%s %s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// stackerrText returns the %+v output for an error.
+func stackerrText(err error) string {
+	return fmt.Sprintf("%+v\n", err)
+}
+
+var printEntries = []entry{
+	{
+		Message: "boom",
+		Frames: []stackerr.Frame{
+			{Function: "main.handle", File: "/src/app/main.go", Line: 20, Kind: stackerr.FrameApp},
+			{Function: "github.com/lib/pq.query", File: "/mod/pq/conn.go", Line: 5, Kind: stackerr.FrameDependency},
+			{Function: "net/http.HandlerFunc.ServeHTTP", File: "/go/src/net/http/server.go", Line: 2136},
+		},
+	},
+	{
+		Message: "boom again",
+		Frames: []stackerr.Frame{
+			{Function: "main.handle", File: "/src/app/main.go", Line: 20, Kind: stackerr.FrameApp},
+			{Function: "github.com/lib/pq.query", File: "/mod/pq/conn.go", Line: 5, Kind: stackerr.FrameDependency},
+			{Function: "net/http.HandlerFunc.ServeHTTP", File: "/go/src/net/http/server.go", Line: 2136},
+		},
+	},
+}
+
+func TestPrinter(t *testing.T) {
+	var b bytes.Buffer
+	p := newPrinter(&b, false, false, true)
+	for _, v := range printEntries {
+		p.print(v)
+	}
+	expected := `#1 boom
+    main.handle
+        /src/app/main.go:20
+    github.com/lib/pq.query
+        /mod/pq/conn.go:5
+    net/http.HandlerFunc.ServeHTTP
+        /go/src/net/http/server.go:2136
+
+#2 boom again (same stack trace as #1)
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPrinterOnlyApp(t *testing.T) {
+	var b bytes.Buffer
+	p := newPrinter(&b, false, true, false)
+	for _, v := range printEntries {
+		p.print(v)
+	}
+	if strings.Contains(b.String(), "pq.query") || strings.Contains(b.String(), "ServeHTTP") {
+		t.Errorf("expected only app frames, got\n%s", b.String())
+	}
+	if strings.Count(b.String(), "[2 frames hidden]") != 2 {
+		t.Errorf("expected both errors printed in full, got\n%s", b.String())
+	}
+	// frames read from %+v output have no kind.
+	b.Reset()
+	p.print(entry{Message: "text", Frames: []stackerr.Frame{
+		{Function: "github.com/ourco/app.Run", File: "/src/app/run.go", Line: 3},
+		{Function: "runtime.goexit", File: "/go/src/runtime/asm_amd64.s", Line: 1700},
+	}})
+	if !strings.Contains(b.String(), "app.Run") || strings.Contains(b.String(), "goexit") {
+		t.Errorf("unexpected output\n%s", b.String())
+	}
+}
+
+func TestPrinterColor(t *testing.T) {
+	var b bytes.Buffer
+	p := newPrinter(&b, true, false, false)
+	p.print(printEntries[0])
+	if !strings.Contains(b.String(), bold+cyan+"main.handle"+reset) {
+		t.Errorf("expected app frames in color, got %q", b.String())
+	}
+	if !strings.Contains(b.String(), dim+"net/http.HandlerFunc.ServeHTTP"+reset) {
+		t.Errorf("expected stdlib frames dimmed, got %q", b.String())
+	}
+	b.Reset()
+	p.print(entry{Message: "cgo", Frames: []stackerr.Frame{{Kind: stackerr.FrameCgo}}})
+	if !strings.Contains(b.String(), dim+"[cgo/unknown frame]"+reset) {
+		t.Errorf("expected cgo frames marked, got %q", b.String())
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	err := stackerr.New("encoded")
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var b bytes.Buffer
+	p := newPrinter(&b, false, false, true)
+	if err := readEntries(bytes.NewReader(data), p.print); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "#1 encoded\n    github.com/jonbodner/stackerr/cmd/stackerr-fmt.TestPrintJSON\n") {
+		t.Errorf("unexpected output\n%s", b.String())
+	}
+}
@@ -0,0 +1,102 @@
+// Command stackerr-migrate rewrites code that uses github.com/pkg/errors to use stackerr instead:
+//
+//	go run github.com/jonbodner/stackerr/cmd/stackerr-migrate -w ./...
+//
+// The calls are rewritten as follows:
+//
+//	errors.New(msg)                  stackerr.New(msg)
+//	errors.Errorf(format, args...)   stackerr.Errorf(format, args...)
+//	errors.WithStack(err)            stackerr.Wrap(err)
+//	errors.Wrap(err, "msg")          stackerr.Errorf("msg: %w", err)
+//	errors.Wrapf(err, "f", args...)  stackerr.Errorf("f: %w", args..., err)
+//	errors.WithMessage(err, "msg")   stackerr.Errorf("msg: %w", err)
+//	errors.WithMessagef(err, ...)    stackerr.Errorf(..., err)
+//	errors.Cause(err)                stackerr.Cause(err)
+//	errors.Is, errors.As, errors.Unwrap  the same functions in the standard library's errors package
+//
+// Wrap, Wrapf, WithMessage, and WithMessagef return nil when they are passed a nil error, and stackerr.Errorf does
+// not, so they are only rewritten inside an if block that checks that the error is not nil. Every use of pkg/errors
+// that isn't rewritten is reported, and the pkg/errors import is kept for files that still use it. Run go mod tidy
+// afterwards to update go.mod.
+//
+// Without -w, stackerr-migrate lists the files that would change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the changes to the files instead of listing them")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: stackerr-migrate [-w] [path ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	failed := false
+	for _, v := range paths {
+		if err := walk(strings.TrimSuffix(v, "/..."), *write); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// walk migrates the Go files in root and the directories below it, skipping vendor and testdata directories and
+// directories whose names start with a . or _.
+func walk(root string, write bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		return migrateFile(path, write)
+	})
+}
+
+func migrateFile(path string, write bool) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, changed, warnings, err := migrate(path, src)
+	if err != nil {
+		return err
+	}
+	for _, v := range warnings {
+		fmt.Fprintln(os.Stderr, v)
+	}
+	if !changed {
+		return nil
+	}
+	if !write {
+		fmt.Println(path)
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const (
+	pkgErrorsPath = "github.com/pkg/errors"
+	stackerrPath  = "github.com/jonbodner/stackerr"
+)
+
+// warning describes a use of pkg/errors that migrate left alone.
+type warning struct {
+	Pos token.Position
+	Msg string
+}
+
+func (w warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Pos, w.Msg)
+}
+
+// migrate rewrites the pkg/errors calls in a Go source file into stackerr calls and adjusts the imports. It returns
+// the new source, whether anything changed, and a warning for each use of pkg/errors that it couldn't rewrite. The
+// pkg/errors import is only removed when every use was rewritten.
+func migrate(filename string, src []byte) ([]byte, bool, []warning, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	spec := findImport(f, pkgErrorsPath)
+	if spec == nil {
+		return src, false, nil, nil
+	}
+	name := "errors"
+	if spec.Name != nil {
+		name = spec.Name.Name
+	}
+	if name == "_" || name == "." {
+		return src, false, []warning{{Pos: fset.Position(spec.Pos()), Msg: "pkg/errors imported as " + name + "; migrate by hand"}}, nil
+	}
+
+	r := &rewriter{
+		fset:     fset,
+		pkg:      name,
+		stackerr: importName(f, stackerrPath, "stackerr"),
+		fmt:      importName(f, "fmt", "fmt"),
+		errors:   importName(f, "errors", "errors"),
+		needs:    map[string]bool{},
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && r.isPkgErrors(sel.X) {
+			r.uses++
+		}
+		return true
+	})
+	var stack []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		if call, ok := n.(*ast.CallExpr); ok {
+			r.rewrite(call, stack)
+		}
+		return true
+	})
+	// Is, As, and Unwrap in pkg/errors call the standard library, so they move there. That only works if the
+	// pkg/errors import goes away, when its name is errors.
+	if r.uses == r.rewritten+len(r.stdlib) || r.pkg != "errors" {
+		for _, call := range r.stdlib {
+			call.Fun.(*ast.SelectorExpr).X = ast.NewIdent(r.errors)
+			r.rewritten++
+			r.needs["errors"] = true
+		}
+	} else {
+		for _, call := range r.stdlib {
+			r.warn(call, "left in pkg/errors, which still has uses that migrate can't rewrite")
+		}
+	}
+	if r.rewritten == 0 {
+		return src, false, r.warnings, nil
+	}
+
+	if r.uses == r.rewritten {
+		if r.needs[stackerrPath] && findImport(f, stackerrPath) == nil {
+			// stackerr takes the place of pkg/errors in the imports.
+			spec.Name = nil
+			spec.Path.Value = strconv.Quote(stackerrPath)
+		} else {
+			deleteImport(f, spec)
+		}
+	}
+	for _, v := range []string{stackerrPath, "fmt", "errors"} {
+		if r.needs[v] && findImport(f, v) == nil {
+			addImport(f, v, spec)
+		}
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); err != nil {
+		return nil, false, nil, err
+	}
+	// format the output again, so the added imports are sorted using their real positions.
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return out, !bytes.Equal(out, src), r.warnings, nil
+}
+
+// rewriter holds the state for rewriting the calls in a single file.
+type rewriter struct {
+	fset *token.FileSet
+	// pkg, stackerr, fmt, and errors are the names used in the file for pkg/errors, stackerr, fmt, and the standard
+	// library's errors package.
+	pkg      string
+	stackerr string
+	fmt      string
+	errors   string
+	// needs holds the import paths used by the rewritten calls.
+	needs map[string]bool
+	// uses counts the references to pkg/errors and rewritten counts the ones that were rewritten.
+	uses      int
+	rewritten int
+	// stdlib holds the calls to Is, As, and Unwrap, which are rewritten once every other call has been visited.
+	stdlib   []*ast.CallExpr
+	warnings []warning
+}
+
+// isPkgErrors reports whether the expression refers to the pkg/errors import. An identifier that resolves to a local
+// declaration shadows the import.
+func (r *rewriter) isPkgErrors(x ast.Expr) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == r.pkg && id.Obj == nil
+}
+
+func (r *rewriter) warn(n ast.Node, msg string) {
+	r.warnings = append(r.warnings, warning{Pos: r.fset.Position(n.Pos()), Msg: msg})
+}
+
+// rewrite rewrites a call to a pkg/errors function in place. The stack holds the call and the nodes that enclose it.
+func (r *rewriter) rewrite(call *ast.CallExpr, stack []ast.Node) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !r.isPkgErrors(sel.X) {
+		return
+	}
+	switch fn := sel.Sel.Name; fn {
+	case "New", "Errorf":
+		r.call(call, fn, call.Args)
+	case "WithStack":
+		r.call(call, "Wrap", call.Args)
+	case "Cause":
+		r.call(call, "Cause", call.Args)
+	case "Is", "As", "Unwrap":
+		r.stdlib = append(r.stdlib, call)
+	case "Wrap", "Wrapf", "WithMessage", "WithMessagef":
+		if len(call.Args) < 2 {
+			return
+		}
+		// pkg/errors returns nil when it wraps a nil error, and stackerr.Errorf doesn't, so the call is only
+		// rewritten when it is guarded by a nil check on the error.
+		errArg := call.Args[0]
+		if call.Ellipsis.IsValid() {
+			r.warn(call, fmt.Sprintf("%s.%s is called with ...; rewrite it by hand", r.pkg, fn))
+			return
+		}
+		if !guarded(errArg, stack) {
+			r.warn(call, fmt.Sprintf("%s.%s is not inside an if block that checks %s != nil; rewrite it by hand", r.pkg, fn, exprString(r.fset, errArg)))
+			return
+		}
+		msg, args := call.Args[1], call.Args[2:]
+		if fn == "Wrap" || fn == "WithMessage" {
+			args = nil
+		}
+		var format ast.Expr
+		if lit, ok := msg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			s, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return
+			}
+			if fn == "Wrap" || fn == "WithMessage" {
+				s = strings.ReplaceAll(s, "%", "%%")
+			}
+			format = &ast.BasicLit{ValuePos: lit.Pos(), Kind: token.STRING, Value: strconv.Quote(s + ": %w")}
+		} else {
+			if fn == "Wrapf" || fn == "WithMessagef" {
+				msg = &ast.CallExpr{
+					Fun:  &ast.SelectorExpr{X: ast.NewIdent(r.fmt), Sel: ast.NewIdent("Sprintf")},
+					Args: call.Args[1:],
+				}
+				r.needs["fmt"] = true
+			}
+			format = &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("%s: %w")}
+			args = []ast.Expr{msg}
+		}
+		out := append([]ast.Expr{format}, args...)
+		r.call(call, "Errorf", append(out, errArg))
+	}
+}
+
+// call replaces the function and arguments of a call with a call to the stackerr function.
+func (r *rewriter) call(call *ast.CallExpr, fn string, args []ast.Expr) {
+	sel := call.Fun.(*ast.SelectorExpr)
+	sel.X = &ast.Ident{NamePos: sel.X.Pos(), Name: r.stackerr}
+	sel.Sel = &ast.Ident{NamePos: sel.Sel.Pos(), Name: fn}
+	call.Args = args
+	r.needs[stackerrPath] = true
+	r.rewritten++
+}
+
+// guarded reports whether one of the nodes in the stack is the body of an if statement whose condition checks that
+// the expression is not nil.
+func guarded(x ast.Expr, stack []ast.Node) bool {
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		s, ok := stack[i].(*ast.IfStmt)
+		if !ok || stack[i+1] != s.Body {
+			continue
+		}
+		if notNil(s.Cond, id.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// notNil reports whether the condition is name != nil, on its own or as one side of an &&.
+func notNil(cond ast.Expr, name string) bool {
+	b, ok := cond.(*ast.BinaryExpr)
+	if !ok {
+		return false
+	}
+	switch b.Op {
+	case token.LAND:
+		return notNil(b.X, name) || notNil(b.Y, name)
+	case token.NEQ:
+		return isIdent(b.X, name) && isIdent(b.Y, "nil") || isIdent(b.X, "nil") && isIdent(b.Y, name)
+	}
+	return false
+}
+
+func isIdent(x ast.Expr, name string) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+func exprString(fset *token.FileSet, x ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, fset, x) // nolint: errcheck
+	return b.String()
+}
+
+// findImport returns the import spec for the path, or nil if the file doesn't import it.
+func findImport(f *ast.File, path string) *ast.ImportSpec {
+	for _, v := range f.Imports {
+		if p, err := strconv.Unquote(v.Path.Value); err == nil && p == path {
+			return v
+		}
+	}
+	return nil
+}
+
+// importName returns the name the file uses for the import path, or def if the file doesn't import it.
+func importName(f *ast.File, path, def string) string {
+	spec := findImport(f, path)
+	if spec == nil || spec.Name == nil {
+		return def
+	}
+	return spec.Name.Name
+}
+
+// deleteImport removes the import spec from the file, along with its declaration if it was the only spec in it.
+func deleteImport(f *ast.File, spec *ast.ImportSpec) {
+	for i, v := range f.Imports {
+		if v == spec {
+			f.Imports = append(f.Imports[:i], f.Imports[i+1:]...)
+			break
+		}
+	}
+	for i, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		for j, v := range gd.Specs {
+			if v != spec {
+				continue
+			}
+			gd.Specs = append(gd.Specs[:j], gd.Specs[j+1:]...)
+			if len(gd.Specs) == 0 {
+				f.Decls = append(f.Decls[:i], f.Decls[i+1:]...)
+			}
+			return
+		}
+	}
+}
+
+// addImport adds an import for the path to the file's first import declaration, or to a new one if there isn't one.
+// Standard library imports are placed after the first import in the declaration and other imports after near, so
+// that they end up in the same group once the imports are sorted.
+func addImport(f *ast.File, path string, near *ast.ImportSpec) {
+	spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+	f.Imports = append(f.Imports, spec)
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		if !gd.Lparen.IsValid() {
+			gd.Lparen = gd.Specs[0].Pos()
+		}
+		i := len(gd.Specs) - 1
+		for j, v := range gd.Specs {
+			if v == near {
+				i = j
+			}
+		}
+		if !strings.Contains(path, ".") {
+			i = 0
+		}
+		spec.Path.ValuePos = gd.Specs[i].End()
+		gd.Specs = append(gd.Specs[:i+1], append([]ast.Spec{spec}, gd.Specs[i+1:]...)...)
+		return
+	}
+	f.Decls = append([]ast.Decl{&ast.GenDecl{Tok: token.IMPORT, Specs: []ast.Spec{spec}}}, f.Decls...)
+}
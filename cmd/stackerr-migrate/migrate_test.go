@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMigrate(t *testing.T) {
+	data := []struct {
+		name     string
+		in       string
+		out      string
+		warnings []string
+	}{
+		{
+			name: "all calls",
+			in: `package a
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+func f(r io.Reader) error {
+	if _, err := r.Read(nil); err != nil {
+		return errors.Wrap(err, "read 100%")
+	}
+	if err := g(); err != nil && r != nil {
+		return errors.Wrapf(err, "g %d", 1)
+	}
+	msg := "x"
+	if err := g(); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	if err := g(); err != nil {
+		return errors.WithMessagef(err, msg, 2)
+	}
+	if errors.Is(g(), io.EOF) {
+		return errors.Cause(errors.WithStack(g()))
+	}
+	return errors.New("boom")
+}
+
+func g() error { return errors.Errorf("g %d", 3) }
+`,
+			out: `package a
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func f(r io.Reader) error {
+	if _, err := r.Read(nil); err != nil {
+		return stackerr.Errorf("read 100%%: %w", err)
+	}
+	if err := g(); err != nil && r != nil {
+		return stackerr.Errorf("g %d: %w", 1, err)
+	}
+	msg := "x"
+	if err := g(); err != nil {
+		return stackerr.Errorf("%s: %w", msg, err)
+	}
+	if err := g(); err != nil {
+		return stackerr.Errorf("%s: %w", fmt.Sprintf(msg, 2), err)
+	}
+	if errors.Is(g(), io.EOF) {
+		return stackerr.Cause(stackerr.Wrap(g()))
+	}
+	return stackerr.New("boom")
+}
+
+func g() error { return stackerr.Errorf("g %d", 3) }
+`,
+		},
+		{
+			name: "unguarded wrap",
+			in: `package a
+
+import "github.com/pkg/errors"
+
+func h() error {
+	return errors.Wrap(g(), "h")
+}
+
+func k() error {
+	if errors.Is(h(), nil) {
+		return nil
+	}
+	return errors.New("k")
+}
+`,
+			out: `package a
+
+import (
+	"github.com/jonbodner/stackerr"
+	"github.com/pkg/errors"
+)
+
+func h() error {
+	return errors.Wrap(g(), "h")
+}
+
+func k() error {
+	if errors.Is(h(), nil) {
+		return nil
+	}
+	return stackerr.New("k")
+}
+`,
+			warnings: []string{
+				"a.go:6:9: errors.Wrap is not inside an if block that checks g() != nil; rewrite it by hand",
+				"a.go:10:5: left in pkg/errors, which still has uses that migrate can't rewrite",
+			},
+		},
+		{
+			name: "renamed imports",
+			in: `package a
+
+import (
+	stderrors "errors"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var errBase = stderrors.New("base")
+
+func f(err error) error {
+	if err != nil {
+		return pkgerrors.Wrap(err, "f")
+	}
+	return pkgerrors.WithStack(pkgerrors.Unwrap(errBase))
+}
+`,
+			out: `package a
+
+import (
+	stderrors "errors"
+
+	"github.com/jonbodner/stackerr"
+)
+
+var errBase = stderrors.New("base")
+
+func f(err error) error {
+	if err != nil {
+		return stackerr.Errorf("f: %w", err)
+	}
+	return stackerr.Wrap(stderrors.Unwrap(errBase))
+}
+`,
+		},
+		{
+			name: "shadowed",
+			in: `package a
+
+import "github.com/pkg/errors"
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+func f() error {
+	errors := []error{}
+	return errors[0]
+}
+`,
+		},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			out, changed, warnings, err := migrate("a.go", []byte(v.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != (v.out != "") {
+				t.Errorf("expected changed to be %t", v.out != "")
+			}
+			if v.out != "" {
+				if diff := cmp.Diff(v.out, string(out)); diff != "" {
+					t.Error(diff)
+				}
+			}
+			var got []string
+			for _, w := range warnings {
+				got = append(got, w.String())
+			}
+			if diff := cmp.Diff(v.warnings, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestMigrateNoPkgErrors(t *testing.T) {
+	in := "package a\n\nimport \"errors\"\n\nvar err = errors.New(\"x\")\n"
+	out, changed, _, err := migrate("a.go", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || string(out) != in {
+		t.Error("expected a file without pkg/errors to be left alone")
+	}
+	if _, _, _, err := migrate("a.go", []byte("package")); err == nil || !strings.Contains(err.Error(), "a.go") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Command stackerr-symbolize resolves the stack traces of errors encoded by stackerr.EncodeCompact, using the binary
+// that encoded them:
+//
+//	stackerr-symbolize ./server c2UBUGFiY2Qv...
+//
+// Each error after the binary is the base64 encoding of the data returned by EncodeCompact. If there are none, they
+// are read from standard input, one per line. Each error is printed the same way as %+v, with its message followed by
+// a line for each frame, so the output can be piped into stackerr-fmt.
+//
+// The binary must be the exact build that encoded the errors; errors encoded by a different build are reported and
+// skipped. Inlined calls are reported as part of the function they were inlined into.
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: stackerr-symbolize binary [encoded error ...]")
+		os.Exit(2)
+	}
+	b, err := openBinary(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var failed bool
+	if len(os.Args) > 2 {
+		for _, v := range os.Args[2:] {
+			failed = !symbolizeOne(os.Stdout, b, v) || failed
+		}
+	} else {
+		failed = !symbolizeAll(os.Stdout, os.Stdin, b)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// symbolizeAll symbolizes each line of r. It reports false if any line could not be symbolized.
+func symbolizeAll(w io.Writer, r io.Reader, b *binary) bool {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	ok := true
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			ok = symbolizeOne(w, b, line) && ok
+		}
+	}
+	if err := s.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return ok
+}
+
+// symbolizeOne decodes a base64-encoded compact trace and writes it to w. Problems are reported on standard error,
+// and symbolizeOne reports false.
+func symbolizeOne(w io.Writer, b *binary, encoded string) bool {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	c, err := stackerr.ParseCompact(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	frames, err := b.symbolize(c)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", c.Message, err)
+		return false
+	}
+	io.WriteString(w, render(c, frames)) // nolint: errcheck
+	return true
+}
+
+// decodeBase64 accepts the standard and URL-safe base64 encodings, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
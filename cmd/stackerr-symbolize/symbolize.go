@@ -0,0 +1,139 @@
+package main
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/internal/buildid"
+)
+
+// binary holds the symbol and line tables of a Go executable.
+type binary struct {
+	// buildID is the content ID of the binary, the last part of its build ID.
+	buildID string
+	table   *gosym.Table
+	anchor  uint64
+}
+
+// openBinary reads the line table of the Go executable at path, and finds the entry address of stackerr's
+// CompactAnchor function.
+func openBinary(path string) (*binary, error) {
+	id, err := buildid.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	pclntab, text, err := readPCLineTable(path)
+	if err != nil {
+		return nil, err
+	}
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, text))
+	if err != nil {
+		return nil, err
+	}
+	fn := table.LookupFunc(stackerr.CompactAnchor)
+	if fn == nil {
+		return nil, fmt.Errorf("%s does not use stackerr.EncodeCompact", path)
+	}
+	return &binary{buildID: buildid.ContentID(id), table: table, anchor: fn.Entry}, nil
+}
+
+// readPCLineTable returns the pclntab of the executable at path and the address where its text starts.
+func readPCLineTable(path string) ([]byte, uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	if ef, err := elf.NewFile(f); err == nil {
+		pclntab, text := ef.Section(".gopclntab"), ef.Section(".text")
+		if pclntab == nil || text == nil {
+			return nil, 0, errors.New("no Go line table in the binary")
+		}
+		data, err := pclntab.Data()
+		return data, text.Addr, err
+	}
+	if mf, err := macho.NewFile(f); err == nil {
+		pclntab, text := mf.Section("__gopclntab"), mf.Section("__text")
+		if pclntab == nil || text == nil {
+			return nil, 0, errors.New("no Go line table in the binary")
+		}
+		data, err := pclntab.Data()
+		return data, text.Addr, err
+	}
+	if pf, err := pe.NewFile(f); err == nil {
+		return peTable(pf)
+	}
+	return nil, 0, errors.New("unknown executable format")
+}
+
+// peTable finds the pclntab of a Windows executable, which is marked by the runtime.pclntab and runtime.epclntab
+// symbols rather than being in its own section.
+func peTable(f *pe.File) ([]byte, uint64, error) {
+	var imageBase uint64
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase = uint64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		imageBase = oh.ImageBase
+	}
+	var start, end *pe.Symbol
+	for _, s := range f.Symbols {
+		switch s.Name {
+		case "runtime.pclntab":
+			start = s
+		case "runtime.epclntab":
+			end = s
+		}
+	}
+	text := f.Section(".text")
+	if start == nil || end == nil || start.SectionNumber != end.SectionNumber || start.SectionNumber < 1 ||
+		int(start.SectionNumber) > len(f.Sections) || text == nil {
+		return nil, 0, errors.New("no Go line table in the binary")
+	}
+	data, err := f.Sections[start.SectionNumber-1].Data()
+	if err != nil {
+		return nil, 0, err
+	}
+	if start.Value > end.Value || int(end.Value) > len(data) {
+		return nil, 0, errors.New("malformed Go line table in the binary")
+	}
+	return data[start.Value:end.Value], imageBase + uint64(text.VirtualAddress), nil
+}
+
+// symbolize resolves the frames of a compact trace. It returns an error if the trace was encoded by a different
+// build of the binary.
+func (b *binary) symbolize(c stackerr.CompactTrace) ([]stackerr.Frame, error) {
+	if c.BuildID != b.buildID {
+		return nil, fmt.Errorf("encoded by build %q, but the binary is build %q", c.BuildID, b.buildID)
+	}
+	pcs := c.PCs(b.anchor)
+	out := make([]stackerr.Frame, len(pcs))
+	for i, pc := range pcs {
+		// the program counters are return addresses, which can belong to the next line or function.
+		file, line, fn := b.table.PCToLine(pc - 1)
+		if fn == nil {
+			out[i] = stackerr.Frame{Function: fmt.Sprintf("unknown pc %#x", pc)}
+			continue
+		}
+		out[i] = stackerr.Frame{Function: fn.Name, File: file, Line: line}
+	}
+	return out, nil
+}
+
+// render formats the error's message and frames the same way as %+v.
+func render(c stackerr.CompactTrace, frames []stackerr.Frame) string {
+	var b strings.Builder
+	b.WriteString(c.Message)
+	b.WriteByte('\n')
+	for _, f := range frames {
+		fmt.Fprintf(&b, "%s (%s:%d)\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
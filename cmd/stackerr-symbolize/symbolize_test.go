@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSymbolize(t *testing.T) {
+	// the test binary encodes the error, so it is the binary to symbolize it with.
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := openBinary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encErr := stackerr.New("compact")
+	data, err := stackerr.EncodeCompact(encErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := stackerr.ParseCompact(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := b.symbolize(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := stackerr.Frames(encErr)
+	if len(frames) != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), len(frames))
+	}
+	for i, v := range expected {
+		if frames[i].Function != v.Function || frames[i].File != v.File || frames[i].Line != v.Line {
+			t.Errorf("frame %d: expected %v, got %v", i, v, frames[i])
+		}
+	}
+
+	var out bytes.Buffer
+	in := base64.StdEncoding.EncodeToString(data) + "\n\n" + base64.RawURLEncoding.EncodeToString(data) + "\n"
+	if !symbolizeAll(&out, strings.NewReader(in), b) {
+		t.Fatal("symbolizeAll failed")
+	}
+	want := render(c, frames)
+	if out.String() != want+want {
+		t.Errorf("unexpected output\n%s", out.String())
+	}
+	if !strings.HasPrefix(want, "compact\ngithub.com/jonbodner/stackerr/cmd/stackerr-symbolize.TestSymbolize (") {
+		t.Errorf("unexpected output\n%s", want)
+	}
+}
+
+func TestSymbolizeWrongBuild(t *testing.T) {
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := openBinary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.symbolize(stackerr.CompactTrace{BuildID: "other", Message: "x", Offsets: []int64{0}})
+	if err == nil || !strings.Contains(err.Error(), `"other"`) {
+		t.Errorf("expected a build ID mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+package stackerr
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// The type names that github.com/cockroachdb/errors records for the errors in an EncodedError. Messages that aren't
+// a prefix of the message they wrap are encoded with the type name of remoteError, and the values attached to the
+// error are encoded with the type name of annotated; cockroachdb/errors decodes both as opaque errors that keep their
+// message and payload, so they survive being passed on.
+const (
+	cockroachWithStackType = "github.com/cockroachdb/errors/withstack/*withstack.withStack"
+	cockroachPrefixType    = "github.com/cockroachdb/errors/errutil/*errutil.withPrefix"
+	cockroachRemoteType    = "github.com/jonbodner/stackerr/*stackerr.remoteError"
+	cockroachValuesType    = "github.com/jonbodner/stackerr/*stackerr.annotated"
+)
+
+// The field numbers of the messages in errorspb/errors.proto from github.com/cockroachdb/errors.
+const (
+	// EncodedError
+	cockroachLeafField    = 1
+	cockroachWrapperField = 2
+	// EncodedErrorLeaf
+	cockroachLeafMessageField = 1
+	cockroachLeafDetailsField = 2
+	// EncodedWrapper
+	cockroachCauseField       = 1
+	cockroachPrefixField      = 2
+	cockroachDetailsField     = 3
+	cockroachMessageTypeField = 4
+	// EncodedErrorDetails
+	cockroachTypeNameField = 1
+	cockroachTypeMarkField = 2
+	cockroachPayloadField  = 3
+	// ErrorTypeMark
+	cockroachFamilyNameField = 1
+)
+
+// cockroachFullMessage is the value of the MessageType enum for a wrapper whose message replaces the message of its
+// cause, instead of being a prefix of it.
+const cockroachFullMessage = 1
+
+// cockroachMaxDepth is the most wrappers that DecodeCockroach decodes around a leaf, so crafted data can't use up the
+// stack of the decoding goroutine.
+const cockroachMaxDepth = 64
+
+var (
+	errCockroachInvalid = errors.New("stackerr: invalid cockroachdb error encoding")
+	errCockroachDeep    = errors.New("stackerr: cockroachdb error encoding is nested too deeply")
+)
+
+// EncodeCockroach encodes an error as an EncodedError protobuf message from github.com/cockroachdb/errors, so a
+// service that uses cockroachdb/errors can decode it with errors.DecodeError. The message chain is encoded as a leaf
+// with the innermost message and a wrapper for each message that wraps it, and the frames are encoded as the stack
+// trace of a withstack wrapper around the leaf, in the format that cockroachdb/errors uses. The code, the severity if
+// it isn't SeverityError, the category, operation names, request ID, trace and span IDs, and context values are
+// encoded as the reportable payload of an outermost wrapper that doesn't change the message, one "name=value" string
+// each, such as "code=3" or "context.tenant=acme". cockroachdb/errors may include the reportable payload in error
+// reports, such as to Sentry, so don't attach context values that can't be sent there. Use DecodeCockroach to turn the
+// result back into an error.
+func EncodeCockroach(err error) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	r := Report(err)
+	chain := r.Chain
+	if len(chain) == 0 || chain[0] != r.Message {
+		chain = append([]string{r.Message}, chain...)
+	}
+	last := len(chain) - 1
+	var leaf protoEncoder
+	leaf.str(cockroachLeafMessageField, chain[last])
+	leaf.message(cockroachLeafDetailsField, cockroachDetails(cockroachRemoteType, nil))
+	var out protoEncoder
+	out.message(cockroachLeafField, leaf.buf)
+	if len(r.Frames) > 0 {
+		var b strings.Builder
+		for _, v := range r.Frames {
+			b.WriteString("\n" + v.Function + "\n\t" + v.File + ":" + strconv.Itoa(v.Line))
+		}
+		out = cockroachWrapper(out.buf, "", cockroachDetails(cockroachWithStackType, []string{b.String()}), false)
+	}
+	for i := last - 1; i >= 0; i-- {
+		if prefix := strings.TrimSuffix(chain[i], ": "+chain[i+1]); prefix != chain[i] {
+			out = cockroachWrapper(out.buf, prefix, cockroachDetails(cockroachPrefixType, nil), false)
+			continue
+		}
+		out = cockroachWrapper(out.buf, chain[i], cockroachDetails(cockroachRemoteType, nil), true)
+	}
+	if values := cockroachValues(r); len(values) > 0 {
+		out = cockroachWrapper(out.buf, "", cockroachDetails(cockroachValuesType, values), false)
+	}
+	return out.buf, nil
+}
+
+// cockroachValues returns the reportable payload for the values in the report that have no place in an EncodedError.
+func cockroachValues(r LogReport) []string {
+	var out []string
+	if r.Code != 0 {
+		out = append(out, "code="+strconv.Itoa(r.Code))
+	}
+	if r.Severity != 0 && r.Severity != SeverityError {
+		out = append(out, "severity="+r.Severity.String())
+	}
+	if r.Category != 0 {
+		out = append(out, "category="+r.Category.String())
+	}
+	for _, v := range r.Ops {
+		out = append(out, "op="+v)
+	}
+	for _, v := range []struct{ name, value string }{
+		{"request_id", r.RequestID},
+		{"trace_id", r.TraceID},
+		{"span_id", r.SpanID},
+	} {
+		if v.value != "" {
+			out = append(out, v.name+"="+v.value)
+		}
+	}
+	for _, k := range sortedContextValues(r.Context) {
+		out = append(out, "context."+k+"="+r.Context[k])
+	}
+	return out
+}
+
+// parseCockroachValues sets the fields of the report from the reportable payload written by cockroachValues. Unknown
+// names are ignored.
+func parseCockroachValues(payload []string, r *LogReport) error {
+	for _, v := range payload {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return errCockroachInvalid
+		}
+		switch {
+		case name == "code":
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return errCockroachInvalid
+			}
+			r.Code = code
+		case name == "severity":
+			if r.Severity.UnmarshalText([]byte(value)) != nil {
+				return errCockroachInvalid
+			}
+		case name == "category":
+			if r.Category.UnmarshalText([]byte(value)) != nil {
+				return errCockroachInvalid
+			}
+		case name == "op":
+			r.Ops = append(r.Ops, value)
+		case name == "request_id":
+			r.RequestID = value
+		case name == "trace_id":
+			r.TraceID = value
+		case name == "span_id":
+			r.SpanID = value
+		case strings.HasPrefix(name, "context."):
+			if r.Context == nil {
+				r.Context = map[string]string{}
+			}
+			r.Context[strings.TrimPrefix(name, "context.")] = value
+		}
+	}
+	return nil
+}
+
+// cockroachDetails encodes an EncodedErrorDetails message for the type name and payload.
+func cockroachDetails(typeName string, payload []string) []byte {
+	var mark protoEncoder
+	mark.str(cockroachFamilyNameField, typeName)
+	var e protoEncoder
+	e.str(cockroachTypeNameField, typeName)
+	e.message(cockroachTypeMarkField, mark.buf)
+	for _, v := range payload {
+		e.str(cockroachPayloadField, v)
+	}
+	return e.buf
+}
+
+// cockroachWrapper encodes an EncodedError message with a wrapper around the encoded cause.
+func cockroachWrapper(cause []byte, prefix string, details []byte, full bool) protoEncoder {
+	var w protoEncoder
+	w.message(cockroachCauseField, cause)
+	w.str(cockroachPrefixField, prefix)
+	w.message(cockroachDetailsField, details)
+	if full {
+		w.uint(cockroachMessageTypeField, cockroachFullMessage)
+	}
+	var out protoEncoder
+	out.message(cockroachWrapperField, w.buf)
+	return out
+}
+
+// DecodeCockroach decodes an EncodedError protobuf message from github.com/cockroachdb/errors, such as one written by
+// errors.EncodeError in a service that uses cockroachdb/errors, or by EncodeCockroach. The decoded error has the
+// message of each error in the encoded chain, and the frames of the innermost stack trace recorded by a withstack
+// wrapper; Trace, Frames, and %+v use the decoded frames. The values written by EncodeCockroach, such as the code and
+// request ID, are attached to the decoded error. Pass the result to AttachLocal to add the local call stack. The
+// details that only cockroachdb/errors understands, such as the types of the errors, are dropped. Errors with more
+// than 64 wrappers around the leaf are rejected.
+func DecodeCockroach(data []byte) (error, error) {
+	var r LogReport
+	chain, frames, err := decodeCockroachError(data, 0, &r)
+	if err != nil {
+		return nil, err
+	}
+	r.Message, r.Chain, r.Frames = chain[0], chain, frames
+	return FromReport(r), nil
+}
+
+// decodeCockroachError decodes an EncodedError message that is depth wrappers inside the outermost one, returning its
+// messages, outermost first, and the frames of its innermost stack trace. The values written by EncodeCockroach are
+// set in r.
+func decodeCockroachError(data []byte, depth int, r *LogReport) ([]string, []Frame, error) {
+	if depth > cockroachMaxDepth {
+		return nil, nil, errCockroachDeep
+	}
+	var chain []string
+	var frames []Frame
+	found := false
+	err := protoFields(data, func(field int, value []byte, _ uint64) error {
+		var err error
+		switch field {
+		case cockroachLeafField:
+			chain, err = decodeCockroachLeaf(value)
+		case cockroachWrapperField:
+			chain, frames, err = decodeCockroachWrapper(value, depth, r)
+		default:
+			return nil
+		}
+		found = true
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, errCockroachInvalid
+	}
+	return chain, frames, nil
+}
+
+// decodeCockroachLeaf decodes an EncodedErrorLeaf message.
+func decodeCockroachLeaf(data []byte) ([]string, error) {
+	var msg string
+	err := protoFields(data, func(field int, value []byte, _ uint64) error {
+		if field == cockroachLeafMessageField {
+			msg = string(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []string{msg}, nil
+}
+
+// decodeCockroachWrapper decodes an EncodedWrapper message that is depth wrappers inside the outermost one.
+func decodeCockroachWrapper(data []byte, depth int, r *LogReport) ([]string, []Frame, error) {
+	var chain []string
+	var frames []Frame
+	var prefix, typeName string
+	var payload []string
+	full := false
+	err := protoFields(data, func(field int, value []byte, v uint64) error {
+		var err error
+		switch field {
+		case cockroachCauseField:
+			chain, frames, err = decodeCockroachError(value, depth+1, r)
+		case cockroachPrefixField:
+			prefix = string(value)
+		case cockroachDetailsField:
+			typeName, payload, err = decodeCockroachDetails(value)
+		case cockroachMessageTypeField:
+			full = v == cockroachFullMessage
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if chain == nil {
+		return nil, nil, errCockroachInvalid
+	}
+	if frames == nil && typeName == cockroachWithStackType && len(payload) > 0 {
+		frames = parseCockroachStack(payload[0])
+	}
+	if typeName == cockroachValuesType {
+		if err := parseCockroachValues(payload, r); err != nil {
+			return nil, nil, err
+		}
+	}
+	msg := chain[0]
+	switch {
+	case full:
+		msg = prefix
+	case prefix != "":
+		msg = prefix + ": " + msg
+	}
+	if msg != chain[0] {
+		chain = append([]string{msg}, chain...)
+	}
+	return chain, frames, nil
+}
+
+// decodeCockroachDetails decodes an EncodedErrorDetails message, returning the type's family name and the reportable
+// payload.
+func decodeCockroachDetails(data []byte) (string, []string, error) {
+	var typeName string
+	var payload []string
+	err := protoFields(data, func(field int, value []byte, _ uint64) error {
+		switch field {
+		case cockroachTypeMarkField:
+			return protoFields(value, func(field int, value []byte, _ uint64) error {
+				if field == cockroachFamilyNameField {
+					typeName = string(value)
+				}
+				return nil
+			})
+		case cockroachPayloadField:
+			payload = append(payload, string(value))
+		}
+		return nil
+	})
+	return typeName, payload, err
+}
+
+// parseCockroachStack parses a stack trace in the format written by cockroachdb/errors and github.com/pkg/errors for
+// %+v: a line with the function followed by a line with a tab, the file, and the line number, for each frame.
+func parseCockroachStack(s string) []Frame {
+	lines := strings.Split(strings.TrimPrefix(s, "\n"), "\n")
+	frames := make([]Frame, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i += 2 {
+		frame := Frame{Function: lines[i], File: strings.TrimPrefix(lines[i+1], "\t")}
+		if pos := strings.LastIndexByte(frame.File, ':'); pos >= 0 {
+			if n, err := strconv.Atoi(frame.File[pos+1:]); err == nil {
+				frame.File, frame.Line = frame.File[:pos], n
+			}
+		}
+		frame.Kind = classify(runtime.Frame{Function: frame.Function, File: frame.File})
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// protoEncoder writes the subset of the protobuf wire format needed to encode errors.
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) key(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field<<3|wireType))
+}
+
+func (e *protoEncoder) uint(field int, v uint64) {
+	e.key(field, 0)
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// str writes a string field. Empty strings are left out, like proto3 does for default values.
+func (e *protoEncoder) str(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.key(field, 2)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *protoEncoder) message(field int, data []byte) {
+	e.key(field, 2)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(data)))
+	e.buf = append(e.buf, data...)
+}
+
+// protoFields calls fn for each field of a protobuf message, with the contents of a length-delimited field or the
+// value of a varint field. Fixed-size fields are skipped.
+func protoFields(data []byte, fn func(field int, value []byte, v uint64) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 {
+			return errCockroachInvalid
+		}
+		data = data[n:]
+		field := int(key >> 3)
+		var value []byte
+		var v uint64
+		switch key & 7 {
+		case 0:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errCockroachInvalid
+			}
+			data = data[n:]
+		case 1, 5:
+			size := 8
+			if key&7 == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return errCockroachInvalid
+			}
+			data = data[size:]
+			continue
+		case 2:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errCockroachInvalid
+			}
+			value, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return errCockroachInvalid
+		}
+		if err := fn(field, value, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
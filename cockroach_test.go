@@ -0,0 +1,194 @@
+package stackerr_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// protoField builds a length-delimited protobuf field.
+func protoField(field int, data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(field<<3|2))
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	return append(out, data...)
+}
+
+func protoConcat(parts ...[]byte) []byte {
+	var out []byte
+	for _, v := range parts {
+		out = append(out, v...)
+	}
+	return out
+}
+
+// cockroachGolden is the EncodedError that errors.EncodeError from github.com/cockroachdb/errors writes for
+// errors.Wrap(errors.New("connection refused"), "query users"), hex encoded. cockroachdb/errors isn't a dependency of
+// this module, so it was assembled by hand, field by field, from errorspb/errors.proto and the encoders of the errutil
+// and withstack packages, rather than captured from EncodeError. Wrap and New each add a withstack wrapper, with the
+// frames of their own call at lines 42 and 41 of query.go.
+const cockroachGolden = "" +
+	"12ad060aa60412a3040a99031296030a8f010a8c010a12636f6e6e656374696f6e207265667573656412760a386769746875622e" +
+	"636f6d2f636f636b726f61636864622f6572726f72732f6572727574696c2f2a6572727574696c2e6c6561664572726f72123a0a" +
+	"386769746875622e636f6d2f636f636b726f61636864622f6572726f72732f6572727574696c2f2a6572727574696c2e6c656166" +
+	"4572726f721a81020a3c6769746875622e636f6d2f636f636b726f61636864622f6572726f72732f77697468737461636b2f2a77" +
+	"697468737461636b2e77697468537461636b123e0a3c6769746875622e636f6d2f636f636b726f61636864622f6572726f72732f" +
+	"77697468737461636b2f2a77697468737461636b2e77697468537461636b1a80010a6769746875622e636f6d2f6578616d706c65" +
+	"2f64622e51756572790a092f7372632f64622f71756572792e676f3a34310a6d61696e2e6d61696e0a092f7372632f6d61696e2e" +
+	"676f3a31300a72756e74696d652e6d61696e0a092f7573722f6c6f63616c2f676f2f7372632f72756e74696d652f70726f632e67" +
+	"6f3a323731120b71756572792075736572731a780a396769746875622e636f6d2f636f636b726f61636864622f6572726f72732f" +
+	"6572727574696c2f2a6572727574696c2e77697468507265666978123b0a396769746875622e636f6d2f636f636b726f61636864" +
+	"622f6572726f72732f6572727574696c2f2a6572727574696c2e776974685072656669781a81020a3c6769746875622e636f6d2f" +
+	"636f636b726f61636864622f6572726f72732f77697468737461636b2f2a77697468737461636b2e77697468537461636b123e0a" +
+	"3c6769746875622e636f6d2f636f636b726f61636864622f6572726f72732f77697468737461636b2f2a77697468737461636b2e" +
+	"77697468537461636b1a80010a6769746875622e636f6d2f6578616d706c652f64622e51756572790a092f7372632f64622f7175" +
+	"6572792e676f3a34320a6d61696e2e6d61696e0a092f7372632f6d61696e2e676f3a31300a72756e74696d652e6d61696e0a092f" +
+	"7573722f6c6f63616c2f676f2f7372632f72756e74696d652f70726f632e676f3a323731"
+
+func TestEncodeCockroach(t *testing.T) {
+	err := stackerr.WithCode(stackerr.Errorf("load config: %w", stackerr.New("missing")), 3)
+	data, encodeErr := stackerr.EncodeCockroach(err)
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	decoded, decodeErr := stackerr.DecodeCockroach(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if decoded.Error() != "load config: missing" {
+		t.Errorf("unexpected message `%s`", decoded.Error())
+	}
+	if diff := cmp.Diff(stackerr.Chain(err), stackerr.Chain(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(traceLines(t, err), traceLines(t, decoded)); diff != "" {
+		t.Error(diff)
+	}
+	if code, ok := stackerr.Code(decoded); !ok || code != 3 {
+		t.Errorf("expected code 3, got %d", code)
+	}
+
+	// the values attached to the error are kept.
+	err = stackerr.WithSeverity(metadataError(t), stackerr.SeverityCritical)
+	data, encodeErr = stackerr.EncodeCockroach(err)
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	decoded, decodeErr = stackerr.DecodeCockroach(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	checkMetadata(t, err, decoded)
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	// a message that isn't a prefix of the message it wraps is kept as is.
+	replaced := stackerr.Errorf("replaced %v", stackerr.New("original"))
+	data, encodeErr = stackerr.EncodeCockroach(fmt.Errorf("outer: %w", replaced))
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	decoded, decodeErr = stackerr.DecodeCockroach(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if diff := cmp.Diff([]string{"outer: replaced original", "replaced original"}, stackerr.Chain(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, encodeErr = stackerr.EncodeCockroach(nil); encodeErr == nil {
+		t.Error("expected an error for a nil error")
+	}
+}
+
+func TestDecodeCockroach(t *testing.T) {
+	typeDetails := func(name string, payload ...string) []byte {
+		out := protoConcat(protoField(1, []byte(name)), protoField(2, protoField(1, []byte(name))))
+		for _, v := range payload {
+			out = append(out, protoField(3, []byte(v))...)
+		}
+		return out
+	}
+	leaf := protoField(1, protoConcat(
+		protoField(1, []byte("connection refused")),
+		protoField(2, typeDetails("errors/*errors.errorString")),
+	))
+	stack := "\ngithub.com/example/db.Query\n\t/src/db/query.go:42\nmain.main\n\t/src/main.go:10"
+	withStack := protoField(2, protoConcat(
+		protoField(1, leaf),
+		protoField(3, typeDetails("github.com/cockroachdb/errors/withstack/*withstack.withStack", stack)),
+	))
+	withPrefix := protoField(2, protoConcat(
+		protoField(1, withStack),
+		protoField(2, []byte("query users")),
+		protoField(3, typeDetails("github.com/cockroachdb/errors/errutil/*errutil.withPrefix")),
+	))
+
+	err, decodeErr := stackerr.DecodeCockroach(withPrefix)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if diff := cmp.Diff([]string{"query users: connection refused", "connection refused"}, stackerr.Chain(err)); diff != "" {
+		t.Error(diff)
+	}
+	expected := []string{
+		"github.com/example/db.Query (/src/db/query.go:42)",
+		"main.main (/src/main.go:10)",
+	}
+	if diff := cmp.Diff(expected, traceLines(t, err)); diff != "" {
+		t.Error(diff)
+	}
+	frames := stackerr.Frames(err)
+	if len(frames) != 2 || frames[0].Kind != stackerr.FrameDependency || frames[1].Kind != stackerr.FrameApp {
+		t.Errorf("unexpected frames %+v", frames)
+	}
+
+	golden, _ := hex.DecodeString(cockroachGolden)
+	err, decodeErr = stackerr.DecodeCockroach(golden)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if diff := cmp.Diff([]string{"query users: connection refused", "connection refused"}, stackerr.Chain(err)); diff != "" {
+		t.Error(diff)
+	}
+	// the frames come from the innermost stack trace, which was recorded by New.
+	expected = []string{
+		"github.com/example/db.Query (/src/db/query.go:41)",
+		"main.main (/src/main.go:10)",
+		"runtime.main (/usr/local/go/src/runtime/proc.go:271)",
+	}
+	if diff := cmp.Diff(expected, traceLines(t, err)); diff != "" {
+		t.Error(diff)
+	}
+
+	values := protoField(2, protoConcat(
+		protoField(1, withPrefix),
+		protoField(3, typeDetails("github.com/jonbodner/stackerr/*stackerr.annotated", "code=7", "other=ignored")),
+	))
+	err, decodeErr = stackerr.DecodeCockroach(values)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if code, ok := stackerr.Code(err); !ok || code != 7 || err.Error() != "query users: connection refused" {
+		t.Errorf("expected code 7 and the original message, got %d and `%s`", code, err)
+	}
+
+	badValues := protoField(2, protoConcat(
+		protoField(1, leaf),
+		protoField(3, typeDetails("github.com/jonbodner/stackerr/*stackerr.annotated", "code=x")),
+	))
+	deep := leaf
+	for i := 0; i < 100; i++ {
+		deep = protoField(2, protoField(1, deep))
+	}
+	for _, v := range [][]byte{nil, {0xff}, protoField(1, []byte{0x0a, 0x10}), badValues, deep} {
+		if _, decodeErr := stackerr.DecodeCockroach(v); decodeErr == nil {
+			t.Errorf("expected an error for %v", v)
+		}
+	}
+}
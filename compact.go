@@ -0,0 +1,185 @@
+package stackerr
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/jonbodner/stackerr/internal/buildid"
+)
+
+// compactMagic starts every error encoded by EncodeCompact, followed by the format version.
+const (
+	compactMagic   = "se"
+	compactVersion = 1
+)
+
+// CompactAnchor is the name of the function whose entry address the program counters in a compact trace are relative
+// to. Since the program counters don't depend on where the binary was loaded, a symbolizer finds the entry address of
+// this function in the binary's symbol table and adds it to each offset. See CompactTrace.PCs.
+const CompactAnchor = "github.com/jonbodner/stackerr.compactAnchor"
+
+var compactInfo struct {
+	once    sync.Once
+	anchor  uintptr
+	buildID string
+}
+
+// compactAnchor returns its own entry address. It must not be inlined, so that it has an entry in the symbol table.
+//
+//go:noinline
+func compactAnchor() uintptr {
+	var pc [1]uintptr
+	// runtimes without stack traces, such as TinyGo's, capture nothing.
+	if runtime.Callers(1, pc[:]) == 0 {
+		return 0
+	}
+	f := runtime.FuncForPC(pc[0])
+	if f == nil {
+		return 0
+	}
+	return f.Entry()
+}
+
+func loadCompactInfo() {
+	compactInfo.once.Do(func() {
+		compactInfo.anchor = compactAnchor()
+		if path, err := os.Executable(); err == nil {
+			if id, err := buildid.Read(path); err == nil {
+				compactInfo.buildID = buildid.ContentID(id)
+			}
+		}
+	})
+}
+
+// EncodeCompact encodes an error's message and the raw program counters of its stack trace, along with the content ID
+// of the running binary, which is the last part of the Go build ID reported by go tool buildid. Nothing is
+// symbolized, so the result is small and cheap to build: a few bytes for each frame and 20 for the content ID, plus
+// the message. Use DecodeCompact in another copy of the same binary, or the stackerr-symbolize command with the binary
+// that encoded the error, to turn the program counters back into functions, files, and lines. The stack trace is
+// encoded as it was captured, so it may start with frames from helper functions.
+func EncodeCompact(err error) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	loadCompactInfo()
+	var pcs []uintptr
+	se, ok := asStack(err)
+	if ok {
+		pcs = se.pcs()
+	}
+	msg := err.Error()
+	buf := make([]byte, 0, len(compactMagic)+1+len(compactInfo.buildID)+len(msg)+3*binary.MaxVarintLen16+len(pcs)*4)
+	buf = append(buf, compactMagic...)
+	buf = append(buf, compactVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(compactInfo.buildID)))
+	buf = append(buf, compactInfo.buildID...)
+	buf = binary.AppendUvarint(buf, uint64(len(msg)))
+	buf = append(buf, msg...)
+	buf = binary.AppendUvarint(buf, uint64(len(pcs)))
+	for _, v := range pcs {
+		buf = binary.AppendVarint(buf, int64(v-compactInfo.anchor))
+	}
+	return buf, nil
+}
+
+// pcs returns the program counters of the errorStack's stack trace.
+func (e *errorStack) pcs() []uintptr {
+	if e.earlier != nil {
+		return e.earlier.pcs()
+	}
+	return e.trace.pcs()
+}
+
+// CompactTrace is the content of an error encoded by EncodeCompact.
+type CompactTrace struct {
+	// BuildID is the content ID of the binary that encoded the error, the last part of its Go build ID.
+	BuildID string
+	// Message is the error's message.
+	Message string
+	// Offsets holds the program counter for each frame of the stack trace, innermost frame first, as an offset from
+	// the entry address of the CompactAnchor function.
+	Offsets []int64
+}
+
+// PCs returns the program counters for the frames of the stack trace, given the entry address of the CompactAnchor
+// function in the binary that encoded the error. Like the program counters returned by runtime.Callers, they are
+// return addresses, so subtract one from each before looking up its line.
+func (c CompactTrace) PCs(anchor uint64) []uint64 {
+	out := make([]uint64, len(c.Offsets))
+	for i, v := range c.Offsets {
+		out[i] = anchor + uint64(v)
+	}
+	return out
+}
+
+var errCompactShort = errors.New("stackerr: compact data is truncated")
+
+// ParseCompact decodes the data written by EncodeCompact.
+func ParseCompact(data []byte) (CompactTrace, error) {
+	if len(data) < len(compactMagic)+1 || string(data[:len(compactMagic)]) != compactMagic {
+		return CompactTrace{}, errors.New("stackerr: not a compact trace")
+	}
+	if v := data[len(compactMagic)]; v != compactVersion {
+		return CompactTrace{}, errors.New("stackerr: unknown compact trace version")
+	}
+	data = data[len(compactMagic)+1:]
+	str := func() (string, error) {
+		n, size := binary.Uvarint(data)
+		if size <= 0 || uint64(len(data)-size) < n {
+			return "", errCompactShort
+		}
+		s := string(data[size : size+int(n)])
+		data = data[size+int(n):]
+		return s, nil
+	}
+	var c CompactTrace
+	var err error
+	if c.BuildID, err = str(); err != nil {
+		return CompactTrace{}, err
+	}
+	if c.Message, err = str(); err != nil {
+		return CompactTrace{}, err
+	}
+	n, size := binary.Uvarint(data)
+	// every offset takes at least one byte.
+	if size <= 0 || uint64(len(data)-size) < n {
+		return CompactTrace{}, errCompactShort
+	}
+	data = data[size:]
+	c.Offsets = make([]int64, n)
+	for i := range c.Offsets {
+		v, size := binary.Varint(data)
+		if size <= 0 {
+			return CompactTrace{}, errCompactShort
+		}
+		c.Offsets[i] = v
+		data = data[size:]
+	}
+	return c, nil
+}
+
+// DecodeCompact decodes an error encoded by EncodeCompact in another copy of the running binary, such as another
+// replica of the same service. The program counters are symbolized with the running binary, so Trace, Frames, and %+v
+// work as they do for an error created in this process. DecodeCompact returns an error if the data was encoded by a
+// different build; use the stackerr-symbolize command with the binary that encoded it instead.
+func DecodeCompact(data []byte) (error, error) {
+	c, err := ParseCompact(data)
+	if err != nil {
+		return nil, err
+	}
+	loadCompactInfo()
+	if c.BuildID == "" || c.BuildID != compactInfo.buildID {
+		return nil, errors.New("stackerr: compact trace was encoded by a different build")
+	}
+	pcs := make([]uintptr, len(c.Offsets))
+	for i, v := range c.PCs(uint64(compactInfo.anchor)) {
+		pcs[i] = uintptr(v)
+	}
+	return &errorStack{
+		Err:   &remoteError{msg: c.Message},
+		trace: &callStack{head: pcs},
+	}, nil
+}
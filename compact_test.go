@@ -0,0 +1,107 @@
+package stackerr_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// skipWithoutBuildID skips tests that need to read the build ID of the test binary, which WebAssembly programs can't
+// open.
+func skipWithoutBuildID(t *testing.T) {
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skip("the test binary can't be read on " + runtime.GOOS)
+	}
+}
+
+func TestEncodeCompact(t *testing.T) {
+	skipWithoutBuildID(t)
+	err := stackerr.Errorf("wrapped: %w", stackerr.New("compact"))
+	data, encErr := stackerr.EncodeCompact(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	c, parseErr := stackerr.ParseCompact(data)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if c.Message != "wrapped: compact" {
+		t.Errorf("unexpected message %q", c.Message)
+	}
+	if c.BuildID == "" {
+		t.Error("expected the build ID of the test binary")
+	}
+	pcs := stackerr.CaptureStack(0).PCs()
+	if len(c.Offsets) == 0 || len(c.Offsets) > len(pcs)+1 {
+		t.Errorf("unexpected number of program counters: %d", len(c.Offsets))
+	}
+	// the offsets don't depend on the anchor, so moving it moves every program counter.
+	a, b := c.PCs(0x1000), c.PCs(0x2000)
+	for i := range a {
+		if b[i]-a[i] != 0x1000 {
+			t.Errorf("frame %d: expected the program counters to move with the anchor", i)
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := stackerr.ParseCompact(data[:i]); err == nil {
+			t.Errorf("expected an error for %d bytes", i)
+		}
+	}
+}
+
+func TestEncodeCompactNoStack(t *testing.T) {
+	if _, err := stackerr.EncodeCompact(nil); err == nil {
+		t.Error("expected an error for a nil error")
+	}
+	data, err := stackerr.EncodeCompact(errors.New("plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := stackerr.ParseCompact(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Message != "plain" || len(c.Offsets) != 0 {
+		t.Errorf("unexpected trace %+v", c)
+	}
+	if _, err := stackerr.ParseCompact([]byte("not compact")); err == nil {
+		t.Error("expected an error for data that isn't a compact trace")
+	}
+}
+
+func TestDecodeCompact(t *testing.T) {
+	skipWithoutBuildID(t)
+	err := stackerr.New("compact")
+	data, encErr := stackerr.EncodeCompact(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	// the content ID and a dozen or so frames fit in well under 100 bytes.
+	if len(data) > 100 {
+		t.Errorf("expected a compact encoding, got %d bytes", len(data))
+	}
+	decoded, decErr := stackerr.DecodeCompact(data)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if decoded.Error() != "compact" {
+		t.Errorf("unexpected message %q", decoded.Error())
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), stackerr.Frames(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	c, _ := stackerr.ParseCompact(data)
+	if len(c.BuildID) != 20 {
+		t.Errorf("expected a 20-byte content ID, got %q", c.BuildID)
+	}
+	other := []byte("se\x01\x05other\x01x\x00")
+	if _, err := stackerr.DecodeCompact(other); err == nil {
+		t.Error("expected an error for a different build")
+	}
+}
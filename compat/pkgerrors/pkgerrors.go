@@ -0,0 +1,131 @@
+// Package pkgerrors has the API of github.com/pkg/errors, backed by stackerr. Services that use pkg/errors can switch
+// to stackerr by changing only the import path:
+//
+//	import errors "github.com/jonbodner/stackerr/compat/pkgerrors"
+//
+// The errors it returns have stackerr stack traces, so Trace, %+v, and the encoders in stackerr work on them. Unlike
+// pkg/errors, Wrap, Wrapf, and WithStack reuse the stack trace of an error that already has one, instead of capturing
+// another. The exception is a stack trace captured while a package was being initialized, such as the one of a sentinel
+// error created with New in a package-level variable: like pkg/errors, they capture a new one, so the stack trace
+// shows where the sentinel was wrapped instead of the package's initialization. To rewrite the calls to use stackerr
+// directly, see the stackerr-migrate command.
+package pkgerrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/internal/initstack"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/compat/pkgerrors")
+}
+
+// fundamental is the error created by New and Errorf, under the layer with the stack trace. Cause stops at that
+// layer, so it returns the error that New returned, like the one in pkg/errors does.
+type fundamental struct {
+	msg string
+}
+
+func (f *fundamental) Error() string {
+	return f.msg
+}
+
+// New returns an error with the message and a stack trace.
+func New(message string) error {
+	return stackerr.Wrap(&fundamental{msg: message})
+}
+
+// Errorf formats the message like fmt.Sprintf and returns an error with it and a stack trace. Like pkg/errors, it
+// doesn't wrap errors passed with %w.
+func Errorf(format string, args ...interface{}) error {
+	return stackerr.Wrap(&fundamental{msg: fmt.Sprintf(format, args...)})
+}
+
+// WithStack returns err with a stack trace, like stackerr.Wrap. It returns nil when a nil error is passed in.
+func WithStack(err error) error {
+	if initstack.Captured(err) {
+		return stackerr.Rewrap(err)
+	}
+	return stackerr.Wrap(err)
+}
+
+// Wrap returns an error that puts the message in front of the message of err, separated by ": ", with a stack trace.
+// It returns nil when a nil error is passed in.
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, message)
+}
+
+// Wrapf works like Wrap, with the message formatted like fmt.Sprintf.
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, fmt.Sprintf(format, args...))
+}
+
+// wrap implements Wrap and Wrapf.
+func wrap(err error, message string) error {
+	if initstack.Captured(err) {
+		return stackerr.Rewrap(fmt.Errorf("%s: %w", message, err))
+	}
+	return stackerr.Errorf("%s: %w", message, err)
+}
+
+// WithMessage returns an error that puts the message in front of the message of err, separated by ": ", without
+// capturing a stack trace. It returns nil when a nil error is passed in.
+func WithMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// WithMessagef works like WithMessage, with the message formatted like fmt.Sprintf.
+func WithMessagef(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
+}
+
+// Cause returns the underlying cause of the error, following Unwrap() error methods and Cause() error methods, like
+// stackerr.Cause. For an error that wraps one created by New or Errorf, it returns the error that New or Errorf
+// returned, so it can be compared with ==. Cause returns nil when a nil error is passed in.
+func Cause(err error) error {
+	for err != nil {
+		var next error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			next = u.Unwrap()
+		case interface{ Cause() error }:
+			next = u.Cause()
+		}
+		if _, ok := next.(*fundamental); ok || next == nil {
+			return err
+		}
+		err = next
+	}
+	return nil
+}
+
+// Is reports whether any error in the tree for err matches target. See errors.Is in the standard library.
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// As finds the first error in the tree for err that matches target, and if one is found, sets target to it and
+// returns true. See errors.As in the standard library.
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
+// Unwrap returns the error wrapped by err, or nil if there isn't one. See errors.Unwrap in the standard library.
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
@@ -0,0 +1,114 @@
+package pkgerrors_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/compat/pkgerrors"
+)
+
+var errSentinel = pkgerrors.New("sentinel")
+
+func topFrame(t *testing.T, err error) string {
+	t.Helper()
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if len(lines) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	return lines[0]
+}
+
+func TestWrap(t *testing.T) {
+	err := pkgerrors.Wrap(io.EOF, "read header")
+	if err.Error() != "read header: EOF" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/compat/pkgerrors_test.TestWrap ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+	if pkgerrors.Cause(err) != io.EOF || !pkgerrors.Is(err, io.EOF) {
+		t.Error("expected io.EOF to be the cause")
+	}
+
+	err = pkgerrors.Wrapf(err, "parse %s", "config.yaml")
+	if err.Error() != "parse config.yaml: read header: EOF" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if pkgerrors.Cause(err) != io.EOF {
+		t.Error("expected io.EOF to be the cause")
+	}
+
+	for _, v := range []error{
+		pkgerrors.Wrap(nil, "msg"),
+		pkgerrors.Wrapf(nil, "msg %d", 1),
+		pkgerrors.WithMessage(nil, "msg"),
+		pkgerrors.WithMessagef(nil, "msg %d", 1),
+		pkgerrors.WithStack(nil),
+		pkgerrors.Cause(nil),
+	} {
+		if v != nil {
+			t.Errorf("expected nil, got %v", v)
+		}
+	}
+}
+
+func TestWithMessageAndStack(t *testing.T) {
+	err := pkgerrors.WithMessage(io.EOF, "read body")
+	if err.Error() != "read body: EOF" || stackerr.HasStack(err) {
+		t.Errorf("expected a message without a stack trace, got %+v", err)
+	}
+	if pkgerrors.Cause(err) != io.EOF {
+		t.Error("expected io.EOF to be the cause")
+	}
+
+	err = pkgerrors.WithStack(err)
+	if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/compat/pkgerrors_test.TestWithMessageAndStack ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+	if err = pkgerrors.WithMessagef(err, "request %d", 7); err.Error() != "request 7: read body: EOF" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+}
+
+func TestCause(t *testing.T) {
+	err := pkgerrors.Wrap(pkgerrors.WithMessage(errSentinel, "lookup"), "handle")
+	if pkgerrors.Cause(err) != errSentinel {
+		t.Errorf("expected the sentinel from New to be the cause, got %v", pkgerrors.Cause(err))
+	}
+	if !errors.Is(err, errSentinel) {
+		t.Error("expected errors.Is to find the sentinel")
+	}
+
+	formatted := pkgerrors.Errorf("bad value %d", 3)
+	if formatted.Error() != "bad value 3" {
+		t.Errorf("unexpected message `%s`", formatted.Error())
+	}
+	if pkgerrors.Cause(fmt.Errorf("outer: %w", formatted)) != formatted {
+		t.Error("expected the error from Errorf to be the cause")
+	}
+	if top := topFrame(t, formatted); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/compat/pkgerrors_test.TestCause ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+}
+
+func TestSentinelStack(t *testing.T) {
+	for _, err := range []error{
+		pkgerrors.Wrap(errSentinel, "lookup"),
+		pkgerrors.Wrapf(errSentinel, "lookup %d", 1),
+		pkgerrors.WithStack(errSentinel),
+	} {
+		if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/compat/pkgerrors_test.TestSentinelStack ") {
+			t.Errorf("expected the caller on top instead of the package's initialization, got %s", top)
+		}
+		if pkgerrors.Cause(err) != errSentinel {
+			t.Errorf("expected the sentinel to be the cause, got %v", pkgerrors.Cause(err))
+		}
+	}
+}
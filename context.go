@@ -0,0 +1,21 @@
+package stackerr
+
+import "context"
+
+// CancelWithStack cancels a context created by context.WithCancelCause, using err with a stack trace as the cause.
+// If err doesn't have a stack trace, one is captured from the caller of CancelWithStack. If err is nil, the cause is
+// context.Canceled with a stack trace, so it's still possible to find out where the context was cancelled.
+func CancelWithStack(cancel context.CancelCauseFunc, err error) {
+	if err == nil {
+		err = context.Canceled
+	}
+	cancel(wrap(err, 1))
+}
+
+// CauseWithStack returns the cause of the context's cancellation, as reported by context.Cause. If the cause doesn't
+// have a stack trace (for example, the context was cancelled by a plain cancel function or its deadline passed), it is
+// wrapped with a stack trace from the caller of CauseWithStack. CauseWithStack returns nil if the context hasn't been
+// cancelled.
+func CauseWithStack(ctx context.Context) error {
+	return wrap(context.Cause(ctx), 1)
+}
@@ -0,0 +1,54 @@
+package stackerr_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func cancelDeep(cancel context.CancelCauseFunc, err error) {
+	stackerr.CancelWithStack(cancel, err)
+}
+
+func TestCancelWithStack(t *testing.T) {
+	sentinel := errors.New("shutting down")
+	data := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"cause", sentinel, sentinel},
+		{"nil cause", nil, context.Canceled},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			cancelDeep(cancel, v.err)
+			cause := stackerr.CauseWithStack(ctx)
+			if !errors.Is(cause, v.expected) {
+				t.Errorf("expected cause to be `%v`, got `%v`", v.expected, cause)
+			}
+			if top := topFrame(t, cause); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.cancelDeep ") {
+				t.Errorf("expected stack trace from cancelDeep, got `%s`", top)
+			}
+		})
+	}
+}
+
+func TestCauseWithStack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if stackerr.CauseWithStack(ctx) != nil {
+		t.Error("expected nil cause for a context that hasn't been cancelled")
+	}
+	cancel()
+	cause := stackerr.CauseWithStack(ctx)
+	if !errors.Is(cause, context.Canceled) {
+		t.Errorf("expected context.Canceled, got `%v`", cause)
+	}
+	if top := topFrame(t, cause); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestCauseWithStack ") {
+		t.Errorf("expected stack trace from TestCauseWithStack, got `%s`", top)
+	}
+}
@@ -0,0 +1,141 @@
+package stackerr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var crashDir atomic.Value
+
+var crashGoroutines int32
+
+// crashMaxSuffix is the highest number that WriteCrashFile adds to a file name before giving up.
+const crashMaxSuffix = 1000
+
+// SetCrashDir sets the directory where WriteCrashFile writes crash reports. Until it is called, crash reports are
+// written to the directory returned by os.TempDir.
+func SetCrashDir(dir string) {
+	crashDir.Store(dir)
+}
+
+// SetCrashGoroutines turns on or off the dump of every goroutine's stack at the end of crash reports. The dump can be
+// large in programs with many goroutines, so it is off by default.
+func SetCrashGoroutines(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&crashGoroutines, v)
+}
+
+// WriteCrash writes a crash report for err to w. The report has the time, the error's message and chain, its code and
+// severity, the stack trace for every error in its unwrap tree that captured one (see StackedErrors), and the build
+// information for the program. If SetRecentErrors was turned on, the report lists the recent errors, newest first, with
+// their time and fingerprint. If SetCrashGoroutines was turned on, the report ends with the stack of every goroutine.
+func WriteCrash(w io.Writer, err error) error {
+	var b bytes.Buffer
+	writeCrash(&b, err, time.Now())
+	if _, wErr := w.Write(b.Bytes()); wErr != nil {
+		return Wrap(wErr)
+	}
+	return nil
+}
+
+// WriteCrashFile writes a crash report for err, as written by WriteCrash, to a new file in the directory set with
+// SetCrashDir. The file is named after the time and the process ID, such as crash-20240501T120000.000-1234.txt. An
+// existing file is never replaced: if there is already a file with that name, such as from another crash in the same
+// millisecond, a number is added, as in crash-20240501T120000.000-1234-2.txt. It returns the path of the file. Call it
+// just before exiting on a fatal error, so the details are on the host for a postmortem even if nothing was logged.
+func WriteCrashFile(err error) (string, error) {
+	dir, _ := crashDir.Load().(string)
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	now := time.Now()
+	base := fmt.Sprintf("crash-%s-%d", now.UTC().Format("20060102T150405.000"), os.Getpid())
+	var b bytes.Buffer
+	writeCrash(&b, err, now)
+	path := filepath.Join(dir, base+".txt")
+	f, oErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	for i := 2; errors.Is(oErr, fs.ErrExist) && i <= crashMaxSuffix; i++ {
+		path = filepath.Join(dir, base+"-"+strconv.Itoa(i)+".txt")
+		f, oErr = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	}
+	if oErr != nil {
+		return "", Wrap(oErr)
+	}
+	_, wErr := f.Write(b.Bytes())
+	if cErr := f.Close(); wErr == nil {
+		wErr = cErr
+	}
+	if wErr != nil {
+		return "", Wrap(wErr)
+	}
+	return path, nil
+}
+
+func writeCrash(b *bytes.Buffer, err error, now time.Time) {
+	fmt.Fprintf(b, "time: %s\n", now.UTC().Format(time.RFC3339Nano))
+	if err == nil {
+		b.WriteString("error: <nil>\n")
+	} else {
+		fmt.Fprintf(b, "error: %s\n", err.Error())
+		b.WriteString("\nchain:\n")
+		for _, v := range Chain(err) {
+			fmt.Fprintf(b, "\t%s\n", v)
+		}
+		if c, ok := Code(err); ok {
+			fmt.Fprintf(b, "code: %d\n", c)
+		}
+		fmt.Fprintf(b, "severity: %s\n", SeverityOf(err))
+		for i, v := range StackedErrors(err) {
+			fmt.Fprintf(b, "\nstack %d: %s\n", i+1, v.Error())
+			lines, _ := Trace(v, StandardFormat)
+			for _, l := range lines {
+				fmt.Fprintf(b, "\t%s\n", l)
+			}
+		}
+	}
+	b.WriteString("\nbuild:\n")
+	fmt.Fprintf(b, "\tgo: %s\n", runtime.Version())
+	fmt.Fprintf(b, "\tplatform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(b, "\tpath: %s\n", bi.Path)
+		fmt.Fprintf(b, "\tmain: %s %s\n", bi.Main.Path, bi.Main.Version)
+		for _, v := range bi.Settings {
+			fmt.Fprintf(b, "\t%s: %s\n", v.Key, v.Value)
+		}
+	}
+	if recentErrs := RecentErrors(); len(recentErrs) > 0 {
+		b.WriteString("\nrecent errors:\n")
+		for _, v := range recentErrs {
+			fmt.Fprintf(b, "\t%s %s %s\n", v.Time.UTC().Format(time.RFC3339Nano), v.Fingerprint, v.Err.Error())
+		}
+	}
+	if atomic.LoadInt32(&crashGoroutines) != 0 {
+		b.WriteString("\ngoroutines:\n")
+		b.Write(allGoroutines())
+	}
+}
+
+// allGoroutines returns the stack of every goroutine, in the format used by runtime.Stack.
+func allGoroutines() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
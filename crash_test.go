@@ -0,0 +1,82 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestWriteCrash(t *testing.T) {
+	inner := stackerr.New("disk full")
+	err := stackerr.WithCode(errors.Join(stackerr.Errorf("save: %w", inner), stackerr.New("flush failed")), 74)
+	var b bytes.Buffer
+	if wErr := stackerr.WriteCrash(&b, err); wErr != nil {
+		t.Fatal(wErr)
+	}
+	out := b.String()
+	for _, v := range []string{
+		"error: save: disk full\nflush failed\n",
+		"\nchain:\n\tsave: disk full\nflush failed\n\tsave: disk full\n\tdisk full\n\tflush failed\n",
+		"code: 74\n",
+		"severity: error\n",
+		"\nstack 1: disk full\n\tgithub.com/jonbodner/stackerr_test.TestWriteCrash (",
+		"\nstack 2: flush failed\n\tgithub.com/jonbodner/stackerr_test.TestWriteCrash (",
+		"\nbuild:\n\tgo: go",
+	} {
+		if !strings.Contains(out, v) {
+			t.Errorf("expected %q in\n%s", v, out)
+		}
+	}
+	if strings.Contains(out, "goroutines:") {
+		t.Error("expected no goroutine dump by default")
+	}
+}
+
+func TestWriteCrashFile(t *testing.T) {
+	dir := t.TempDir()
+	stackerr.SetCrashDir(dir)
+	stackerr.SetCrashGoroutines(true)
+	defer stackerr.SetCrashDir("")
+	defer stackerr.SetCrashGoroutines(false)
+	path, err := stackerr.WriteCrashFile(stackerr.New("fatal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "crash-") {
+		t.Errorf("unexpected path %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "error: fatal\n") || !strings.Contains(string(data), "\ngoroutines:\ngoroutine ") {
+		t.Errorf("unexpected crash report\n%s", data)
+	}
+
+	// reports written in the same millisecond get their own files instead of replacing each other.
+	stackerr.SetCrashGoroutines(false)
+	paths := map[string]bool{path: true}
+	for i := 0; i < 50; i++ {
+		p, err := stackerr.WriteCrashFile(stackerr.New("fatal"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if paths[p] {
+			t.Fatalf("expected a new file, got %s again", p)
+		}
+		paths[p] = true
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(paths) {
+		t.Errorf("expected %d files, got %d", len(paths), len(entries))
+	}
+
+	stackerr.SetCrashDir(filepath.Join(dir, "missing"))
+	if _, err := stackerr.WriteCrashFile(stackerr.New("fatal")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,109 @@
+package stackerr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// ContextValue reads a value to copy from a context onto an error. The second return value is false if the context
+// doesn't have the value.
+type ContextValue func(ctx context.Context) (string, bool)
+
+// ContextKey returns a ContextValue that reads the value for key with ctx.Value and formats it with fmt.Sprint. A nil
+// value is treated as missing.
+func ContextKey(key interface{}) ContextValue {
+	return func(ctx context.Context) (string, bool) {
+		v := ctx.Value(key)
+		if v == nil {
+			return "", false
+		}
+		return fmt.Sprint(v), true
+	}
+}
+
+// contextValueList is stored in contextValues, with the names sorted so values are always read in the same order.
+type contextValueList struct {
+	names  []string
+	values map[string]ContextValue
+}
+
+var contextValues atomic.Value
+
+// SetContextValues sets the allowlist of values that NewCtx, ErrorfCtx, and WrapCtx copy from their context onto the
+// error, so details such as the tenant or the enabled feature flags travel with the error to wherever it is logged.
+// Each entry maps the name recorded on the error to the ContextValue that reads it. Passing nil clears the list.
+//
+//	stackerr.SetContextValues(map[string]stackerr.ContextValue{
+//		"tenant": stackerr.ContextKey(tenantKey{}),
+//	})
+//
+// Only allowlisted values are copied, since a context can hold anything, including secrets.
+func SetContextValues(values map[string]ContextValue) {
+	l := contextValueList{values: make(map[string]ContextValue, len(values))}
+	for k, v := range values {
+		l.names = append(l.names, k)
+		l.values[k] = v
+	}
+	sort.Strings(l.names)
+	contextValues.Store(l)
+}
+
+// ContextValues returns the values copied from contexts by NewCtx, ErrorfCtx, and WrapCtx for the errors in the
+// unwrap chain for err, keyed by the names passed to SetContextValues. It returns nil if there are none.
+func ContextValues(err error) map[string]string {
+	v, ok := lookup(err, contextValuesKey)
+	if !ok {
+		return nil
+	}
+	values := v.(*attachedValues).values
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
+
+// withContextValues attaches the allowlisted values from ctx to err, which already has a stack trace. Values that are
+// already attached to err are kept.
+func withContextValues(ctx context.Context, err error) error {
+	l, _ := contextValues.Load().(contextValueList)
+	if len(l.names) == 0 {
+		return err
+	}
+	values := ContextValues(err)
+	added := false
+	for _, name := range l.names {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		if v, ok := l.values[name](ctx); ok {
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[name] = v
+			added = true
+		}
+	}
+	if !added {
+		return err
+	}
+	return annotated{err: err, key: contextValuesKey, value: &attachedValues{values: values}}
+}
+
+// attachedValues holds the context values attached to an error. annotated is compared with == by errors.Is, so the
+// map is stored behind a pointer, since comparing maps panics.
+type attachedValues struct {
+	values map[string]string
+}
+
+// sortedContextValues returns the names of the values in sorted order, for the encoders.
+func sortedContextValues(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
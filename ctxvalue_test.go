@@ -0,0 +1,81 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+type tenantKey struct{}
+
+type flagsKey struct{}
+
+func TestSetContextValues(t *testing.T) {
+	stackerr.SetContextValues(map[string]stackerr.ContextValue{
+		"tenant": stackerr.ContextKey(tenantKey{}),
+		"flags":  stackerr.ContextKey(flagsKey{}),
+	})
+	defer stackerr.SetContextValues(nil)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	err := stackerr.WrapCtx(ctx, errors.New("quota exceeded"))
+	if diff := cmp.Diff(map[string]string{"tenant": "acme"}, stackerr.ContextValues(fmt.Errorf("wrapped: %w", err))); diff != "" {
+		t.Error(diff)
+	}
+
+	// values already on the error are kept, and new ones are added.
+	ctx = context.WithValue(context.WithValue(ctx, tenantKey{}, "other"), flagsKey{}, []string{"beta"})
+	err = stackerr.WrapCtx(ctx, err)
+	expected := map[string]string{"tenant": "acme", "flags": "[beta]"}
+	if diff := cmp.Diff(expected, stackerr.ContextValues(err)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(expected, stackerr.Report(err).Context); diff != "" {
+		t.Error(diff)
+	}
+	if line := stackerr.EncodeLogfmt(err); !strings.HasSuffix(line, " context.flags=[beta] context.tenant=acme") {
+		t.Errorf("expected the context values in `%s`", line)
+	}
+	if doc := stackerr.EncodeYAML(err); !strings.Contains(doc, "\ncontext:\n  flags: \"[beta]\"\n  tenant: acme\n") {
+		t.Errorf("expected the context values in\n%s", doc)
+	}
+	var logs bytes.Buffer
+	stackerr.WrapAndLog(slog.New(slog.NewJSONHandler(&logs, nil)), err, "failed") // nolint: errcheck
+	if !strings.Contains(logs.String(), `"context":{"flags":"[beta]","tenant":"acme"}`) {
+		t.Errorf("expected the context values in the logs, got %s", logs.String())
+	}
+
+	data, jsonErr := json.Marshal(stackerr.Report(err))
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	decoded, decodeErr := stackerr.DecodeJSON(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if diff := cmp.Diff(expected, stackerr.ContextValues(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	// errors.Is compares the annotated errors, so the values must not make them uncomparable.
+	if !errors.Is(err, err) || !errors.Is(decoded, decoded) || errors.Is(err, decoded) {
+		t.Error("expected errors.Is to compare errors with context values")
+	}
+
+	if values := stackerr.ContextValues(stackerr.WrapCtx(context.Background(), errors.New("plain"))); values != nil {
+		t.Errorf("expected no context values, got %v", values)
+	}
+	stackerr.SetContextValues(nil)
+	if values := stackerr.ContextValues(stackerr.NewCtx(ctx, "not allowlisted")); values != nil {
+		t.Errorf("expected no context values, got %v", values)
+	}
+}
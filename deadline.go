@@ -0,0 +1,67 @@
+package stackerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeadlineError adds details about a context deadline to a context.DeadlineExceeded error. Use errors.As to get it
+// from an error returned by WrapDeadline.
+type DeadlineError struct {
+	// Err is the original error.
+	Err error
+	// Deadline is the deadline of the context. It is the zero value if the context had no deadline.
+	Deadline time.Time
+	// Elapsed is how long the operation ran before the error was wrapped. It is only set if the context was returned
+	// by WithStartTime.
+	Elapsed time.Duration
+}
+
+// Error returns the original error message, followed by the deadline details.
+func (de *DeadlineError) Error() string {
+	var details []string
+	if !de.Deadline.IsZero() {
+		details = append(details, "deadline "+de.Deadline.Format(time.RFC3339Nano))
+	}
+	if de.Elapsed > 0 {
+		details = append(details, "ran "+de.Elapsed.String())
+	}
+	if len(details) == 0 {
+		return de.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", de.Err.Error(), strings.Join(details, ", "))
+}
+
+// Unwrap exposes the original error.
+func (de *DeadlineError) Unwrap() error {
+	return de.Err
+}
+
+type startTimeKey struct{}
+
+// WithStartTime returns a copy of ctx that records the current time as the start of an operation. WrapDeadline uses
+// it to report how long the operation ran.
+func WithStartTime(ctx context.Context) context.Context {
+	return context.WithValue(ctx, startTimeKey{}, time.Now())
+}
+
+// WrapDeadline works like Wrap, but when err is (or wraps) context.DeadlineExceeded, it is first wrapped in a
+// *DeadlineError with the deadline of ctx and, if ctx came from WithStartTime, how long the operation ran.
+// WrapDeadline returns nil when a nil error is passed in.
+func WrapDeadline(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return wrap(err, 1)
+	}
+	de := &DeadlineError{Err: err}
+	de.Deadline, _ = ctx.Deadline()
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		de.Elapsed = time.Since(start)
+	}
+	return wrap(de, 1)
+}
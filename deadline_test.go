@@ -0,0 +1,52 @@
+package stackerr_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestWrapDeadline(t *testing.T) {
+	if stackerr.WrapDeadline(context.Background(), nil) != nil {
+		t.Error("Got non-nil for nil passed to WrapDeadline")
+	}
+
+	ctx, cancel := context.WithTimeout(stackerr.WithStartTime(context.Background()), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	err := stackerr.WrapDeadline(ctx, ctx.Err())
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected error to wrap context.DeadlineExceeded")
+	}
+	var de *stackerr.DeadlineError
+	if !errors.As(err, &de) {
+		t.Fatal("expected a *DeadlineError")
+	}
+	deadline, _ := ctx.Deadline()
+	if !de.Deadline.Equal(deadline) {
+		t.Errorf("expected deadline %v, got %v", deadline, de.Deadline)
+	}
+	if de.Elapsed < time.Millisecond {
+		t.Errorf("expected elapsed time of at least 1ms, got %v", de.Elapsed)
+	}
+	if !strings.HasPrefix(err.Error(), "context deadline exceeded (deadline ") || !strings.Contains(err.Error(), ", ran ") {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestWrapDeadline ") {
+		t.Errorf("expected stack trace from TestWrapDeadline, got `%s`", top)
+	}
+
+	other := errors.New("not a timeout")
+	err = stackerr.WrapDeadline(ctx, other)
+	if errors.As(err, &de) {
+		t.Error("didn't expect a *DeadlineError for a different error")
+	}
+	if err.Error() != "not a timeout" || !stackerr.HasStack(err) {
+		t.Errorf("expected other errors to be wrapped with a stack trace, got `%v`", err)
+	}
+}
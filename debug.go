@@ -0,0 +1,73 @@
+package stackerr
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync/atomic"
+)
+
+// RuntimeSnapshot holds runtime statistics that are recorded when an errorStack is created while debug mode is on.
+// HeapInUse is measured in bytes and matches runtime.MemStats.HeapInuse.
+type RuntimeSnapshot struct {
+	Goroutines int
+	HeapInUse  uint64
+	GOMAXPROCS int
+}
+
+var debugMode int32
+
+// SetDebugMode turns debug mode on or off. While debug mode is on, every stack trace captured by New, Wrap, and
+// Errorf also records a RuntimeSnapshot. Debug mode is off by default.
+func SetDebugMode(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&debugMode, v)
+}
+
+// heapSamples are the runtime/metrics values that add up to runtime.MemStats.HeapInuse. They are read instead of
+// calling runtime.ReadMemStats, which stops the world.
+var heapSamples = []string{
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/heap/unused:bytes",
+}
+
+// takeSnapshot returns nil when debug mode is off.
+func takeSnapshot() *RuntimeSnapshot {
+	if atomic.LoadInt32(&debugMode) == 0 {
+		return nil
+	}
+	samples := make([]metrics.Sample, len(heapSamples))
+	for i, name := range heapSamples {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	var heap uint64
+	for _, s := range samples {
+		if s.Value.Kind() == metrics.KindUint64 {
+			heap += s.Value.Uint64()
+		}
+	}
+	return &RuntimeSnapshot{
+		Goroutines: runtime.NumGoroutine(),
+		HeapInUse:  heap,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+}
+
+// Snapshot returns the RuntimeSnapshot recorded with the stack trace in the unwrap chain for the error. The second
+// return value is false if there is no stack trace or it was captured while debug mode was off.
+func Snapshot(e error) (RuntimeSnapshot, bool) {
+	se, ok := asStack(e)
+	if !ok {
+		return RuntimeSnapshot{}, false
+	}
+	if se.earlier != nil {
+		se = se.earlier
+	}
+	if se.snapshot == nil {
+		return RuntimeSnapshot{}, false
+	}
+	return *se.snapshot, true
+}
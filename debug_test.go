@@ -0,0 +1,40 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSnapshot(t *testing.T) {
+	stackerr.SetDebugMode(true)
+	err := stackerr.New("under load")
+	wrapped := stackerr.Errorf("outer: %w", err)
+	stackerr.SetDebugMode(false)
+
+	for _, e := range []error{err, wrapped, fmt.Errorf("fmt: %w", err)} {
+		snap, ok := stackerr.Snapshot(e)
+		if !ok {
+			t.Fatalf("expected a snapshot for `%v`", e)
+		}
+		if snap.Goroutines < 1 {
+			t.Errorf("expected at least one goroutine, got %d", snap.Goroutines)
+		}
+		if snap.GOMAXPROCS != runtime.GOMAXPROCS(0) {
+			t.Errorf("expected GOMAXPROCS %d, got %d", runtime.GOMAXPROCS(0), snap.GOMAXPROCS)
+		}
+		if snap.HeapInUse == 0 {
+			t.Error("expected non-zero heap in use")
+		}
+	}
+
+	if _, ok := stackerr.Snapshot(stackerr.New("debug off")); ok {
+		t.Error("didn't expect a snapshot when debug mode is off")
+	}
+	if _, ok := stackerr.Snapshot(errors.New("no stack")); ok {
+		t.Error("didn't expect a snapshot for an error without a stack trace")
+	}
+}
@@ -0,0 +1,100 @@
+package stackerr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// decayMaxEntries is the size at which a LogDecay's table is pruned. It is also the most entries the table holds.
+const decayMaxEntries = 4096
+
+// decayEntry tracks one fingerprint: when its window started and how many errors were seen since then.
+type decayEntry struct {
+	start time.Time
+	count int
+}
+
+// LogDecay decides how much detail to log for errors that keep happening. The first occurrences of each fingerprint in
+// a window are rendered with their full stack trace; after that, errors with the same fingerprint are rendered on one
+// line with their origin and how many times they have been seen. Once the window has passed since the first
+// occurrence, the count starts over. It is meant for logging adapters, so the ten-thousandth copy of an error doesn't
+// cost as much to log as the first. A LogDecay is safe for concurrent use.
+type LogDecay struct {
+	full   int
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*decayEntry
+}
+
+// NewLogDecay returns a LogDecay that renders the first full occurrences of each fingerprint in every window with
+// their stack trace.
+func NewLogDecay(full int, window time.Duration) *LogDecay {
+	return &LogDecay{
+		full:    full,
+		window:  window,
+		entries: map[string]*decayEntry{},
+	}
+}
+
+// Occurrence counts err and returns how many times its fingerprint has been seen in the current window, including
+// this time, and whether it should be logged with its full stack trace. Errors without a stack trace are not counted;
+// Occurrence returns 0 and true for them.
+func (d *LogDecay) Occurrence(err error) (count int, full bool) {
+	fp := Fingerprint(err)
+	if fp == "" {
+		return 0, true
+	}
+	return d.occurrence(fp, time.Now())
+}
+
+func (d *LogDecay) occurrence(fp string, now time.Time) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[fp]
+	if !ok || now.Sub(e.start) >= d.window {
+		if !ok && len(d.entries) >= decayMaxEntries {
+			d.prune(now)
+		}
+		e = &decayEntry{start: now}
+		d.entries[fp] = e
+	}
+	e.count++
+	return e.count, e.count <= d.full
+}
+
+// prune removes the entries whose window has passed. If none has, it removes the entry whose window started first, so
+// the table never grows past decayMaxEntries.
+func (d *LogDecay) prune(now time.Time) {
+	oldest := ""
+	var oldestStart time.Time
+	for k, v := range d.entries {
+		if now.Sub(v.start) >= d.window {
+			delete(d.entries, k)
+		} else if oldest == "" || v.start.Before(oldestStart) {
+			oldest, oldestStart = k, v.start
+		}
+	}
+	if len(d.entries) >= decayMaxEntries {
+		delete(d.entries, oldest)
+	}
+}
+
+// Render counts err with Occurrence and returns the text to log for it. An occurrence that should be logged in full
+// is rendered with %+v. Any other occurrence is rendered as the error's message, followed by its origin and count:
+//
+//	open config: not found (main.loadConfig config.go:42, seen 12 times)
+//
+// Render returns an empty string for a nil error.
+func (d *LogDecay) Render(err error) string {
+	if err == nil {
+		return ""
+	}
+	count, full := d.Occurrence(err)
+	if full {
+		return fmt.Sprintf("%+v", err)
+	}
+	origin, _ := Origin(err)
+	return fmt.Sprintf("%s (%s %s:%d, seen %d times)", err.Error(), origin.Function, origin.BaseFile(), origin.Line, count)
+}
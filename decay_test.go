@@ -0,0 +1,71 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func failOpen() error {
+	return stackerr.New("open config: not found")
+}
+
+func TestLogDecay(t *testing.T) {
+	// errors from the same call site have the same fingerprint.
+	errs := make([]error, 4)
+	for i := range errs {
+		errs[i] = failOpen()
+	}
+	d := stackerr.NewLogDecay(2, time.Hour)
+	for i := 0; i < 2; i++ {
+		if out := d.Render(errs[i]); out != fmt.Sprintf("%+v", errs[i]) {
+			t.Errorf("expected occurrence %d in full, got %q", i+1, out)
+		}
+	}
+	out := d.Render(errs[2])
+	if !regexp.MustCompile(`^open config: not found \(github.com/jonbodner/stackerr_test.failOpen decay_test.go:\d+, seen 3 times\)$`).MatchString(out) {
+		t.Errorf("unexpected one-line render %q", out)
+	}
+	if count, full := d.Occurrence(errs[3]); count != 4 || full {
+		t.Errorf("expected the fourth occurrence to be one line, got %d %v", count, full)
+	}
+	// a different fingerprint has its own count.
+	if _, full := d.Occurrence(stackerr.New("other")); !full {
+		t.Error("expected a new fingerprint in full")
+	}
+	if count, full := d.Occurrence(errors.New("no stack")); count != 0 || !full {
+		t.Errorf("expected errors without a stack trace in full, got %d %v", count, full)
+	}
+	if d.Render(nil) != "" {
+		t.Error("expected an empty string for a nil error")
+	}
+
+	// when the table is full of fingerprints whose windows haven't passed, the oldest one is forgotten.
+	d = stackerr.NewLogDecay(1, time.Hour)
+	withFingerprint := func(i int) error {
+		return stackerr.FromReport(stackerr.LogReport{Message: "full", Fingerprint: fmt.Sprintf("fp-%d", i)})
+	}
+	d.Occurrence(withFingerprint(0))
+	time.Sleep(time.Millisecond)
+	for i := 1; i <= 4096; i++ {
+		d.Occurrence(withFingerprint(i))
+	}
+	if count, _ := d.Occurrence(withFingerprint(0)); count != 1 {
+		t.Errorf("expected the oldest fingerprint to be forgotten, got count %d", count)
+	}
+	if count, _ := d.Occurrence(withFingerprint(4096)); count != 2 {
+		t.Errorf("expected the newest fingerprint to be kept, got count %d", count)
+	}
+
+	d = stackerr.NewLogDecay(1, time.Nanosecond)
+	d.Render(errs[0]) // nolint: errcheck
+	time.Sleep(time.Millisecond)
+	if out := d.Render(errs[1]); !strings.Contains(out, "\n") {
+		t.Errorf("expected the count to start over after the window, got %q", out)
+	}
+}
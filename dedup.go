@@ -0,0 +1,72 @@
+package stackerr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var dedupWindow int64
+
+// dedupMaxEntries is the size at which the dedup table is pruned.
+const dedupMaxEntries = 4096
+
+// dedupEntry tracks one fingerprint: when its last event was sent and how many events were held back since then.
+type dedupEntry struct {
+	sent       time.Time
+	suppressed int
+}
+
+var deduper = struct {
+	sync.Mutex
+	entries map[string]*dedupEntry
+}{
+	entries: map[string]*dedupEntry{},
+}
+
+// SetDedupWindow turns on deduplication of error events. After an event is sent for an error, events for errors with
+// the same fingerprint are counted instead of sent until the window has passed. The next event sent for that
+// fingerprint reports the count in its Repeats field. Pass 0 to turn deduplication off, which also forgets the counts.
+// Deduplication is off by default.
+func SetDedupWindow(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	deduper.Lock()
+	deduper.entries = map[string]*dedupEntry{}
+	deduper.Unlock()
+	atomic.StoreInt64(&dedupWindow, int64(d))
+}
+
+// dedup reports whether an event should be sent for an error with the fingerprint at now, and if so, how many events
+// for the fingerprint were held back since the last one was sent.
+func dedup(fp string, now time.Time) (bool, int) {
+	window := time.Duration(atomic.LoadInt64(&dedupWindow))
+	deduper.Lock()
+	defer deduper.Unlock()
+	e, ok := deduper.entries[fp]
+	if ok && now.Sub(e.sent) < window {
+		e.suppressed++
+		return false, 0
+	}
+	if !ok {
+		if len(deduper.entries) >= dedupMaxEntries {
+			pruneDedup(now, window)
+		}
+		e = &dedupEntry{}
+		deduper.entries[fp] = e
+	}
+	repeats := e.suppressed
+	e.sent = now
+	e.suppressed = 0
+	return true, repeats
+}
+
+// pruneDedup removes the entries whose window has passed and that have no held-back events to report.
+func pruneDedup(now time.Time, window time.Duration) {
+	for k, v := range deduper.entries {
+		if v.suppressed == 0 && now.Sub(v.sent) >= window {
+			delete(deduper.entries, k)
+		}
+	}
+}
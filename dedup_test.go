@@ -0,0 +1,50 @@
+package stackerr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSetDedupWindow(t *testing.T) {
+	ch := stackerr.Subscribe()
+	defer stackerr.Unsubscribe(ch)
+	stackerr.SetDedupWindow(50 * time.Millisecond)
+	defer stackerr.SetDedupWindow(0)
+
+	storm := func() error {
+		return stackerr.New("storm")
+	}
+	for i := 0; i < 5; i++ {
+		if i == 4 {
+			time.Sleep(60 * time.Millisecond)
+		}
+		_ = storm()
+	}
+	other := stackerr.New("other")
+
+	var events []stackerr.ErrorEvent
+	for len(ch) > 0 {
+		events = append(events, <-ch)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Repeats != 0 {
+		t.Errorf("expected no repeats on the first event, got %d", events[0].Repeats)
+	}
+	if events[1].Repeats != 3 {
+		t.Errorf("expected 3 repeats, got %d", events[1].Repeats)
+	}
+	if events[2].Err != other || events[2].Repeats != 0 {
+		t.Errorf("unexpected event %v with %d repeats", events[2].Err, events[2].Repeats)
+	}
+
+	stackerr.SetDedupWindow(0)
+	_ = storm()
+	_ = storm()
+	if len(ch) != 2 {
+		t.Errorf("expected 2 events with deduplication off, got %d", len(ch))
+	}
+}
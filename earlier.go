@@ -0,0 +1,27 @@
+package stackerr
+
+import "runtime"
+
+// Earlier returns the error that captured the stack trace used by the first error with a stack trace in the unwrap
+// chain for err, when that error was created by an Errorf call that reused an existing stack trace instead of
+// capturing its own. The second return value is false if there is no stack trace, or if the first error with a
+// stack trace captured it itself.
+func Earlier(err error) (error, bool) {
+	se, ok := asStack(err)
+	if !ok || se.earlier == nil {
+		return nil, false
+	}
+	return se.earlier, true
+}
+
+// WrapSite returns the frame for the Errorf call that created the first error with a stack trace in the unwrap chain
+// for err, when that call reused an existing stack trace. The stack trace only shows where the earlier error was
+// created; WrapSite shows where it was wrapped. The second return value is false whenever Earlier's would be.
+func WrapSite(err error) (Frame, bool) {
+	se, ok := asStack(err)
+	if !ok || se.earlier == nil || se.site == 0 {
+		return Frame{}, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{se.site}).Next()
+	return toFrames([]runtime.Frame{frame})[0], true
+}
@@ -0,0 +1,54 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestEarlier(t *testing.T) {
+	inner := stackerr.New("inner")
+	outer := stackerr.Errorf("outer: %w", inner)
+	outermost := fmt.Errorf("outermost: %w", stackerr.Errorf("again: %w", outer))
+
+	for _, err := range []error{outer, outermost} {
+		earlier, ok := stackerr.Earlier(err)
+		if !ok {
+			t.Fatalf("expected %v to have an earlier error", err)
+		}
+		if earlier != inner {
+			t.Errorf("expected the error that captured the trace, got %v", earlier)
+		}
+	}
+	for _, err := range []error{inner, stackerr.Errorf("fresh"), errors.New("plain")} {
+		if _, ok := stackerr.Earlier(err); ok {
+			t.Errorf("expected %v not to have an earlier error", err)
+		}
+	}
+}
+
+func TestWrapSite(t *testing.T) {
+	inner := stackerr.New("inner")
+	outer := func() error {
+		return stackerr.Errorf("outer: %w", inner)
+	}()
+	site, ok := stackerr.WrapSite(outer)
+	if !ok {
+		t.Fatal("expected a wrap site")
+	}
+	if site.Function != "github.com/jonbodner/stackerr_test.TestWrapSite.func1" {
+		t.Errorf("unexpected wrap site function `%s`", site.Function)
+	}
+	if site.Kind != stackerr.FrameApp || site.BaseFile() != "earlier_test.go" {
+		t.Errorf("unexpected wrap site %v", site)
+	}
+	origin, _ := stackerr.Origin(outer)
+	if origin.Function != "github.com/jonbodner/stackerr_test.TestWrapSite" || origin.Line >= site.Line {
+		t.Errorf("expected the origin to be where inner was created, got %v", origin)
+	}
+	if _, ok := stackerr.WrapSite(inner); ok {
+		t.Error("expected no wrap site for an error that captured its own trace")
+	}
+}
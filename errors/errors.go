@@ -0,0 +1,72 @@
+// Package errors is a drop-in replacement for the standard library's errors package that captures stack traces. Its
+// functions have the same signatures as the ones in the standard library, so a codebase can adopt stackerr by changing
+// the import path:
+//
+//	import "github.com/jonbodner/stackerr/errors"
+//
+// New, Errorf, and Join return errors with a stack trace, and Is, As, and Unwrap see through it, so code written for
+// the standard library keeps working. A sentinel error created with New in a package-level variable gets the stack
+// trace of the package's initialization, so Errorf and Join capture a new stack trace instead of reusing that one.
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"runtime"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/internal/initstack"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/errors")
+}
+
+// New returns an error with the message and a stack trace, like stackerr.New.
+func New(text string) error {
+	return stackerr.New(text)
+}
+
+// Errorf formats the error like fmt.Errorf, including wrapping the errors passed with %w, and returns it with a stack
+// trace, like stackerr.Errorf. If a wrapped error already has a stack trace, it is reused, unless it was captured while
+// a package was being initialized; then a new one is captured, like stackerr.Rewrap does.
+func Errorf(format string, a ...interface{}) error {
+	for _, v := range a {
+		if err, ok := v.(error); ok && initstack.Captured(err) {
+			return stackerr.Rewrap(fmt.Errorf(format, a...))
+		}
+	}
+	return stackerr.Errorf(format, a...)
+}
+
+// Join returns an error that wraps the errors, like errors.Join in the standard library. If none of the errors has a
+// stack trace, or the one it has was captured while a package was being initialized, one is captured. Join returns
+// nil if every error is nil.
+func Join(errs ...error) error {
+	err := stderrors.Join(errs...)
+	if initstack.Captured(err) {
+		return stackerr.Rewrap(err)
+	}
+	return stackerr.Wrap(err)
+}
+
+// Is reports whether any error in the tree for err matches target. See errors.Is in the standard library.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in the tree for err that matches target, and if one is found, sets target to it and
+// returns true. See errors.As in the standard library.
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap returns the error wrapped by err, or nil if there isn't one. See errors.Unwrap in the standard library. The
+// layer that holds the stack trace of an error from New, Errorf, or Join is skipped, so Unwrap returns the same error
+// that the standard library would for the error that layer holds, such as the error passed to Errorf with %w.
+func Unwrap(err error) error {
+	if _, ok := err.(interface{ StackTrace() *runtime.Frames }); ok {
+		err = stderrors.Unwrap(err)
+	}
+	return stderrors.Unwrap(err)
+}
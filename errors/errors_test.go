@@ -0,0 +1,100 @@
+package errors_test
+
+import (
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/errors"
+)
+
+var errSentinel = errors.New("sentinel")
+
+func topFrame(t *testing.T, err error) string {
+	t.Helper()
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if len(lines) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	return lines[0]
+}
+
+func TestNewAndErrorf(t *testing.T) {
+	base := errors.New("missing")
+	if base.Error() != "missing" {
+		t.Errorf("unexpected message `%s`", base.Error())
+	}
+	if top := topFrame(t, base); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/errors_test.TestNewAndErrorf ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+
+	err := errors.Errorf("load: %w", base)
+	if err.Error() != "load: missing" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("expected Is to find the wrapped error")
+	}
+	if errors.Unwrap(err) != base {
+		t.Error("expected Unwrap to return the error passed with %w")
+	}
+	if errors.Unwrap(base) != nil {
+		t.Error("expected nil from Unwrap for an error from New")
+	}
+	if errors.Unwrap(stderrors.New("plain")) != nil {
+		t.Error("expected nil from Unwrap for a plain error")
+	}
+}
+
+func TestAs(t *testing.T) {
+	_, openErr := os.Open("/does/not/exist")
+	err := errors.Errorf("config: %w", openErr)
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr != openErr {
+		t.Error("expected As to find the *fs.PathError")
+	}
+	if !stackerr.HasStack(err) {
+		t.Error("expected a stack trace")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if errors.Join(nil, nil) != nil {
+		t.Error("expected nil when every error is nil")
+	}
+	first := stderrors.New("first")
+	second := stderrors.New("second")
+	err := errors.Join(first, nil, second)
+	if err.Error() != "first\nsecond" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Error("expected Is to find both errors")
+	}
+	if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/errors_test.TestJoin ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+	if errors.Unwrap(err) != nil {
+		t.Error("expected nil from Unwrap for a joined error")
+	}
+}
+
+func TestSentinel(t *testing.T) {
+	for _, err := range []error{errors.Errorf("find: %w", errSentinel), errors.Join(errSentinel)} {
+		if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr/errors_test.TestSentinel ") {
+			t.Errorf("expected the caller on top instead of the package's initialization, got %s", top)
+		}
+		if !errors.Is(err, errSentinel) {
+			t.Error("expected Is to find the sentinel")
+		}
+	}
+	if errors.Unwrap(errors.Errorf("find: %w", errSentinel)) != errSentinel {
+		t.Error("expected Unwrap to return the sentinel")
+	}
+}
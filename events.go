@@ -0,0 +1,96 @@
+package stackerr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorEvent describes a stacked error when it is created. See Subscribe.
+type ErrorEvent struct {
+	// Err is the new error.
+	Err error
+	// Time is when the error was created.
+	Time time.Time
+	// Repeats is the number of errors with the same fingerprint whose events were held back since the last event for
+	// that fingerprint was sent. It is always 0 unless SetDedupWindow was called.
+	Repeats int
+}
+
+// eventBuffer is the capacity of each subscriber's channel.
+const eventBuffer = 256
+
+var (
+	subscriberCount int32
+	droppedEvents   uint64
+)
+
+var subscribers = struct {
+	sync.Mutex
+	chans []chan ErrorEvent
+}{}
+
+// Subscribe returns a channel that receives an ErrorEvent for every new stack trace attached to an error: errors from
+// New and AttachStack, and errors from Errorf and Wrap when there is no stack trace in the unwrap chain yet. Errors
+// decoded from another process are not reported. Events are sent without blocking; when the
+// channel's buffer is full the event is dropped and counted by DroppedEvents. Call Unsubscribe when you are done
+// reading from the channel.
+func Subscribe() <-chan ErrorEvent {
+	ch := make(chan ErrorEvent, eventBuffer)
+	subscribers.Lock()
+	subscribers.chans = append(subscribers.chans, ch)
+	atomic.StoreInt32(&subscriberCount, int32(len(subscribers.chans)))
+	subscribers.Unlock()
+	return ch
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe and closes it. Unsubscribing a channel that
+// isn't subscribed does nothing.
+func Unsubscribe(ch <-chan ErrorEvent) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	for i, v := range subscribers.chans {
+		if v == ch {
+			subscribers.chans = append(subscribers.chans[:i], subscribers.chans[i+1:]...)
+			atomic.StoreInt32(&subscriberCount, int32(len(subscribers.chans)))
+			close(v)
+			return
+		}
+	}
+}
+
+// DroppedEvents returns the number of events that were dropped because a subscriber's channel was full.
+func DroppedEvents() uint64 {
+	return atomic.LoadUint64(&droppedEvents)
+}
+
+// publish records err in the buffer turned on by SetRecentErrors, sends an event for it to every subscriber, and
+// returns err. It does nothing when the buffer is off and there are no subscribers.
+func publish(err error) error {
+	if atomic.LoadInt32(&recentSize) == 0 && atomic.LoadInt32(&subscriberCount) == 0 {
+		return err
+	}
+	now := time.Now()
+	addRecent(err, now)
+	if atomic.LoadInt32(&subscriberCount) == 0 {
+		return err
+	}
+	event := ErrorEvent{Err: err, Time: now}
+	if se, ok := err.(*errorStack); ok && atomic.LoadInt64(&dedupWindow) != 0 {
+		send, repeats := dedup(fingerprint(se), event.Time)
+		if !send {
+			return err
+		}
+		event.Repeats = repeats
+	}
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	for _, ch := range subscribers.chans {
+		select {
+		case ch <- event:
+		default:
+			atomic.AddUint64(&droppedEvents, 1)
+		}
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSubscribe(t *testing.T) {
+	ch := stackerr.Subscribe()
+	defer stackerr.Unsubscribe(ch)
+
+	first := stackerr.New("first")
+	second := stackerr.Wrap(errors.New("second"))
+	_ = stackerr.Wrap(second)
+	_ = stackerr.Errorf("reused: %w", first)
+	third := stackerr.Errorf("third")
+
+	for _, expected := range []error{first, second, third} {
+		select {
+		case event := <-ch:
+			if event.Err != expected {
+				t.Errorf("expected %v, got %v", expected, event.Err)
+			}
+			if event.Time.IsZero() {
+				t.Error("expected a time")
+			}
+		default:
+			t.Fatalf("expected an event for %v", expected)
+		}
+	}
+	select {
+	case event := <-ch:
+		t.Errorf("unexpected event %v", event.Err)
+	default:
+	}
+}
+
+func TestSubscribeDropsWhenFull(t *testing.T) {
+	ch := stackerr.Subscribe()
+	before := stackerr.DroppedEvents()
+	for i := 0; i < 300; i++ {
+		_ = stackerr.New(fmt.Sprint(i))
+	}
+	if dropped := stackerr.DroppedEvents() - before; dropped != 300-uint64(cap(ch)) {
+		t.Errorf("expected %d dropped events, got %d", 300-cap(ch), dropped)
+	}
+	stackerr.Unsubscribe(ch)
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != cap(ch) {
+		t.Errorf("expected %d buffered events, got %d", cap(ch), count)
+	}
+	stackerr.Unsubscribe(ch)
+}
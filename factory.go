@@ -0,0 +1,69 @@
+package stackerr
+
+import "errors"
+
+// StackProvider captures the call stacks for the errors created by a Factory.
+type StackProvider interface {
+	// CaptureStack captures a call stack, skipping skip frames, with 0 identifying the caller of CaptureStack. It is
+	// called in the same way as the package-level CaptureStack.
+	CaptureStack(skip int) Stack
+}
+
+// fixedStack is a StackProvider that always returns the same stack.
+type fixedStack struct {
+	s Stack
+}
+
+func (f fixedStack) CaptureStack(int) Stack {
+	return f.s
+}
+
+// FixedStack returns a StackProvider that gives every error the stack trace made of the frames, innermost first. Use
+// it in tests to create errors whose stack traces don't depend on line numbers or on how the test was run.
+func FixedStack(frames ...Frame) StackProvider {
+	decoded := make([]Frame, len(frames))
+	copy(decoded, frames)
+	return fixedStack{s: Stack{cs: &callStack{decoded: decoded}}}
+}
+
+// Factory creates errors the same way as the package-level functions, using its StackProvider to capture their stack
+// traces. The zero value and a nil *Factory capture stack traces with runtime.Callers, like the package-level
+// functions.
+type Factory struct {
+	provider StackProvider
+}
+
+var defaultFactory *Factory
+
+// NewFactory returns a Factory that uses the provider to capture stack traces. A nil provider uses runtime.Callers.
+func NewFactory(provider StackProvider) *Factory {
+	return &Factory{provider: provider}
+}
+
+// stack captures a call stack with the Factory's provider, skipping skip frames above the caller of stack.
+func (f *Factory) stack(skip int) *callStack {
+	if f == nil || f.provider == nil {
+		return captureStack(skip + 1)
+	}
+	return f.provider.CaptureStack(skip + 1).cs
+}
+
+// New works like the package-level New.
+func (f *Factory) New(msg string) error {
+	return f.rewrap(errors.New(msg), 1)
+}
+
+// Errorf works like the package-level Errorf.
+func (f *Factory) Errorf(format string, vals ...interface{}) error {
+	return f.errorf(1, format, vals...)
+}
+
+// Wrap works like the package-level Wrap.
+func (f *Factory) Wrap(err error) error {
+	return f.wrap(err, 1)
+}
+
+// Rewrap works like the package-level Rewrap.
+func (f *Factory) Rewrap(err error) error {
+	return f.rewrap(err, 1)
+}
@@ -0,0 +1,86 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+var fixedFrames = []stackerr.Frame{
+	{Function: "github.com/ourco/app/store.Load", File: "/src/app/store/load.go", Line: 10, Kind: stackerr.FrameApp},
+	{Function: "main.main", File: "/src/app/main.go", Line: 3, Kind: stackerr.FrameApp},
+}
+
+func TestFactoryFixedStack(t *testing.T) {
+	f := stackerr.NewFactory(stackerr.FixedStack(fixedFrames...))
+	expected := []string{
+		"github.com/ourco/app/store.Load (/src/app/store/load.go:10)",
+		"main.main (/src/app/main.go:3)",
+	}
+	for _, err := range []error{
+		f.New("boom"),
+		f.Errorf("boom %d", 1),
+		f.Wrap(errors.New("boom")),
+		f.Rewrap(stackerr.New("boom")),
+	} {
+		trace, _ := stackerr.Trace(err, stackerr.StandardFormat)
+		if diff := cmp.Diff(expected, trace); diff != "" {
+			t.Error(diff)
+		}
+		if diff := cmp.Diff(fixedFrames, stackerr.Frames(err)); diff != "" {
+			t.Error(diff)
+		}
+		if !strings.HasSuffix(fmt.Sprintf("%+v", err), "\n"+strings.Join(expected, "\n")) {
+			t.Errorf("unexpected %%+v output %q", fmt.Sprintf("%+v", err))
+		}
+	}
+
+	// Errorf and Wrap keep an existing stack trace, as they do at the package level.
+	inner := stackerr.New("inner")
+	if f.Wrap(inner) != inner {
+		t.Error("expected Wrap to return an error with a stack trace unchanged")
+	}
+	if diff := cmp.Diff(stackerr.Frames(inner), stackerr.Frames(f.Errorf("outer: %w", inner))); diff != "" {
+		t.Error(diff)
+	}
+	if f.Wrap(nil) != nil || f.Rewrap(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+}
+
+func TestFactoryRuntime(t *testing.T) {
+	for _, f := range []*stackerr.Factory{nil, {}, stackerr.NewFactory(nil)} {
+		for _, err := range []error{f.New("boom"), f.Errorf("boom"), f.Wrap(errors.New("boom")), f.Rewrap(errors.New("boom"))} {
+			if !strings.HasPrefix(topFrame(t, err), "github.com/jonbodner/stackerr_test.TestFactoryRuntime ") {
+				t.Errorf("unexpected top frame %s", topFrame(t, err))
+			}
+		}
+	}
+}
+
+// countingStacks is a StackProvider that counts its calls.
+type countingStacks struct {
+	calls int
+}
+
+func (c *countingStacks) CaptureStack(skip int) stackerr.Stack {
+	c.calls++
+	return stackerr.CaptureStack(skip + 1)
+}
+
+func TestFactoryProviderSkip(t *testing.T) {
+	p := &countingStacks{}
+	f := stackerr.NewFactory(p)
+	err := f.New("boom")
+	if p.calls != 1 {
+		t.Errorf("expected one call, got %d", p.calls)
+	}
+	if !strings.HasPrefix(topFrame(t, err), "github.com/jonbodner/stackerr_test.TestFactoryProviderSkip ") {
+		t.Errorf("unexpected top frame %s", topFrame(t, err))
+	}
+}
@@ -0,0 +1,171 @@
+package stackerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fieldError records the name of the input field that an error is about.
+type fieldError struct {
+	err   error
+	field string
+}
+
+// WithField records the name of the input field that an error is about, such as a form field that failed validation.
+// If there is no stack trace in the unwrap chain for err, one is captured. The returned error's message is the field
+// name, followed by the message for err. WithField returns nil when a nil error is passed in.
+func WithField(err error, field string) error {
+	if err == nil {
+		return nil
+	}
+	return fieldError{err: wrap(err, 1), field: field}
+}
+
+// Field returns the field name recorded by WithField for the first matching error in the unwrap chain. The second
+// return value is false if there is no field name.
+func Field(err error) (string, bool) {
+	var fe fieldError
+	if !errors.As(err, &fe) {
+		return "", false
+	}
+	return fe.field, true
+}
+
+// Error returns the field name, followed by the message for the wrapped error.
+func (fe fieldError) Error() string {
+	return fe.field + ": " + fe.err.Error()
+}
+
+// Unwrap exposes the error wrapped by fieldError.
+func (fe fieldError) Unwrap() error {
+	return fe.err
+}
+
+// Format prefixes the wrapped error's formatted output with the field name, so %+v still outputs the stack trace.
+func (fe fieldError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%s: %+v", fe.field, fe.err)
+			return
+		}
+		io.WriteString(s, fe.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, fe.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", fe.Error())
+	}
+}
+
+// FieldErrors groups errors by the field name recorded with WithField. Errors without a field name are grouped under
+// the empty string. The zero value is ready to use; use Err to get a value to return as an error.
+type FieldErrors struct {
+	fields []string
+	errs   map[string][]error
+}
+
+// Add adds err to the group for its field name. Nil errors are ignored.
+func (fe *FieldErrors) Add(err error) {
+	if err == nil {
+		return
+	}
+	if fe.errs == nil {
+		fe.errs = map[string][]error{}
+	}
+	field, _ := Field(err)
+	if _, ok := fe.errs[field]; !ok {
+		fe.fields = append(fe.fields, field)
+	}
+	fe.errs[field] = append(fe.errs[field], err)
+}
+
+// Err returns fe if any errors were added, and nil otherwise.
+func (fe *FieldErrors) Err() error {
+	if len(fe.fields) == 0 {
+		return nil
+	}
+	return fe
+}
+
+// Fields returns the field names that have errors, in the order they were first added.
+func (fe *FieldErrors) Fields() []string {
+	out := make([]string, len(fe.fields))
+	copy(out, fe.fields)
+	return out
+}
+
+// Errors returns the errors for a field name.
+func (fe *FieldErrors) Errors(field string) []error {
+	return fe.errs[field]
+}
+
+// Map returns the error messages for each field name, without the field name prefix. This is the structured form
+// to return in an API response.
+func (fe *FieldErrors) Map() map[string][]string {
+	out := make(map[string][]string, len(fe.fields))
+	for _, field := range fe.fields {
+		for _, err := range fe.errs[field] {
+			var f fieldError
+			if errors.As(err, &f) {
+				err = f.err
+			}
+			out[field] = append(out[field], err.Error())
+		}
+	}
+	return out
+}
+
+// Traces returns each error formatted with %+v, including its stack trace. This is the form to write to a log.
+func (fe *FieldErrors) Traces() []string {
+	var out []string
+	for _, field := range fe.fields {
+		for _, err := range fe.errs[field] {
+			out = append(out, fmt.Sprintf("%+v", err))
+		}
+	}
+	return out
+}
+
+// Error returns the messages for all of the errors, separated by semicolons.
+func (fe *FieldErrors) Error() string {
+	var msgs []string
+	for _, field := range fe.fields {
+		for _, err := range fe.errs[field] {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns all of the errors, so errors.Is and errors.As check each of them.
+func (fe *FieldErrors) Unwrap() []error {
+	var out []error
+	for _, field := range fe.fields {
+		out = append(out, fe.errs[field]...)
+	}
+	return out
+}
+
+// Format outputs the result of Traces, separated by blank lines, for %+v. Other verbs output the result of Error.
+func (fe *FieldErrors) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, strings.Join(fe.Traces(), "\n\n")) // nolint: errcheck
+			return
+		}
+		io.WriteString(s, fe.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, fe.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", fe.Error())
+	}
+}
+
+// MarshalJSON encodes the result of Map.
+func (fe *FieldErrors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fe.Map())
+}
@@ -0,0 +1,92 @@
+package stackerr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+var errEmpty = errors.New("must not be empty")
+
+func TestWithField(t *testing.T) {
+	if stackerr.WithField(nil, "name") != nil {
+		t.Error("Got non-nil for nil passed to WithField")
+	}
+	err := stackerr.WithField(errEmpty, "name")
+	if err.Error() != "name: must not be empty" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if field, ok := stackerr.Field(fmt.Errorf("wrapped: %w", err)); !ok || field != "name" {
+		t.Errorf("expected field `name`, got `%s`", field)
+	}
+	if !errors.Is(err, errEmpty) || !stackerr.HasStack(err) {
+		t.Error("expected error to wrap errEmpty with a stack trace")
+	}
+	if _, ok := stackerr.Field(errEmpty); ok {
+		t.Error("didn't expect a field name")
+	}
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	if lines[0] != "name: must not be empty" || !strings.HasPrefix(lines[1], "github.com/jonbodner/stackerr_test.TestWithField ") {
+		t.Errorf("unexpected %%+v output %q", lines)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	var fe stackerr.FieldErrors
+	if fe.Err() != nil {
+		t.Error("expected nil error when no errors were added")
+	}
+	fe.Add(stackerr.WithField(errEmpty, "name"))
+	fe.Add(nil)
+	fe.Add(stackerr.WithField(stackerr.New("must contain @"), "email"))
+	fe.Add(stackerr.WithField(stackerr.New("too long"), "name"))
+	fe.Add(errors.New("form expired"))
+
+	err := fe.Err()
+	if diff := cmp.Diff([]string{"name", "email", ""}, fe.Fields()); diff != "" {
+		t.Error(diff)
+	}
+	expectedMap := map[string][]string{
+		"name":  {"must not be empty", "too long"},
+		"email": {"must contain @"},
+		"":      {"form expired"},
+	}
+	if diff := cmp.Diff(expectedMap, fe.Map()); diff != "" {
+		t.Error(diff)
+	}
+	if err.Error() != "name: must not be empty; name: too long; email: must contain @; form expired" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if !errors.Is(err, errEmpty) {
+		t.Error("expected errors.Is to find errEmpty")
+	}
+	if len(fe.Errors("name")) != 2 {
+		t.Errorf("expected 2 errors for name, got %d", len(fe.Errors("name")))
+	}
+
+	traces := fe.Traces()
+	if len(traces) != 4 || !strings.HasPrefix(traces[0], "name: must not be empty\ngithub.com/jonbodner/stackerr_test.TestFieldErrors ") {
+		t.Errorf("unexpected traces %q", traces)
+	}
+	if fmt.Sprintf("%+v", err) != strings.Join(traces, "\n\n") {
+		t.Errorf("expected %%+v output to be the traces")
+	}
+
+	b, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var decoded map[string][]string
+	if jsonErr = json.Unmarshal(b, &decoded); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if diff := cmp.Diff(expectedMap, decoded); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,68 @@
+package stackerr
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// Fingerprint returns a short string that identifies where the error in the unwrap chain with a stack trace was
+// created. Errors created by the same code path have the same fingerprint, even if their messages are different. The
+// fingerprint is computed from the function and line of every frame in the stack trace. Fingerprint returns an empty
+// string if there is no stack trace.
+func Fingerprint(e error) string {
+	se, ok := asStack(e)
+	if !ok {
+		return ""
+	}
+	return fingerprint(se)
+}
+
+func fingerprint(se *errorStack) string {
+	root := se
+	for root.earlier != nil {
+		root = root.earlier
+	}
+	if root.trace != nil && root.trace.fingerprint != "" {
+		return root.trace.fingerprint
+	}
+	h := fnv.New64a()
+	var buf []byte
+	for _, v := range se.frames() {
+		buf = append(buf[:0], v.Function...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(v.Line), 10)
+		buf = append(buf, '\n')
+		h.Write(buf) // nolint: errcheck
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// Origin returns the innermost frame of the stack trace in the unwrap chain for the error; this is the place where the
+// stack trace was captured. The second return value is false if there is no stack trace.
+func Origin(e error) (Frame, bool) {
+	se, ok := asStack(e)
+	if !ok {
+		return Frame{}, false
+	}
+	frames := se.exportFrames()
+	if len(frames) == 0 {
+		return Frame{}, false
+	}
+	return frames[0], true
+}
+
+// SameOrigin reports whether the stack traces in the unwrap chains for a and b were captured at the same place: the
+// same function, file, and line. The code paths that led there may differ; compare fingerprints to require the whole
+// stack trace to match. SameOrigin returns false if either error has no stack trace.
+func SameOrigin(a, b error) bool {
+	oa, ok := Origin(a)
+	if !ok {
+		return false
+	}
+	ob, ok := Origin(b)
+	if !ok {
+		return false
+	}
+	return oa.Function == ob.Function && oa.File == ob.File && oa.Line == ob.Line
+}
@@ -0,0 +1,76 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestFingerprint(t *testing.T) {
+	var errs []error
+	for i := 0; i < 2; i++ {
+		errs = append(errs, stackerr.Errorf("attempt %d", i))
+	}
+	other := stackerr.New("attempt 0")
+
+	fp := stackerr.Fingerprint(errs[0])
+	if len(fp) != 16 {
+		t.Errorf("expected a 16 character fingerprint, got `%s`", fp)
+	}
+	if fp != stackerr.Fingerprint(errs[1]) {
+		t.Error("expected errors from the same call site to have the same fingerprint")
+	}
+	if fp != stackerr.Fingerprint(fmt.Errorf("wrapped: %w", errs[0])) {
+		t.Error("expected wrapping to keep the fingerprint")
+	}
+	if fp == stackerr.Fingerprint(other) {
+		t.Error("expected errors from different call sites to have different fingerprints")
+	}
+	if stackerr.Fingerprint(errors.New("no stack")) != "" {
+		t.Error("expected no fingerprint for an error without a stack trace")
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	err := stackerr.New("origin")
+	origin, ok := stackerr.Origin(fmt.Errorf("wrapped: %w", err))
+	if !ok {
+		t.Fatal("expected an origin")
+	}
+	if origin != stackerr.Frames(err)[0] {
+		t.Errorf("expected origin to be the first frame, got %v", origin)
+	}
+	if origin.Function != "github.com/jonbodner/stackerr_test.TestOrigin" {
+		t.Errorf("unexpected origin function `%s`", origin.Function)
+	}
+	if _, ok := stackerr.Origin(errors.New("no stack")); ok {
+		t.Error("expected no origin for an error without a stack trace")
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	create := func(msg string) error {
+		return stackerr.New(msg)
+	}
+	a := create("a")
+	b := func() error {
+		return fmt.Errorf("wrapped: %w", create("b"))
+	}()
+	other := stackerr.New("other")
+
+	if !stackerr.SameOrigin(a, b) {
+		t.Error("expected errors created at the same place to have the same origin")
+	}
+	if stackerr.Fingerprint(a) == stackerr.Fingerprint(b) {
+		t.Error("expected different call paths to have different fingerprints")
+	}
+	if stackerr.SameOrigin(a, other) {
+		t.Error("expected errors created at different places to have different origins")
+	}
+	plain := errors.New("plain")
+	if stackerr.SameOrigin(a, plain) || stackerr.SameOrigin(plain, plain) {
+		t.Error("expected false for errors without a stack trace")
+	}
+}
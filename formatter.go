@@ -0,0 +1,96 @@
+package stackerr
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// TraceFormatter renders stack traces. FormatFrame renders a single frame, and Join combines the rendered lines into
+// the text of the whole trace. The lines passed to Join also include the ones that Trace adds for elided frames,
+// collapsed standard library frames, and the boundaries between goroutines and processes. Pass a TraceFormatter to
+// Trace or FormatTrace with the WithFormatter option, or to SetFormatOptions to change the output of %+v.
+type TraceFormatter interface {
+	FormatFrame(f Frame) string
+	Join(lines []string) string
+}
+
+// WithFormatter renders each frame with the formatter instead of a template. When it is passed to SetFormatOptions,
+// the lines of the trace printed by %+v are combined with the formatter's Join method as well.
+func WithFormatter(f TraceFormatter) Option {
+	return func(o *renderOptions) {
+		o.formatter = f
+	}
+}
+
+// FormatTrace renders the stack trace in the unwrap chain for the error as a single string. The frames are rendered
+// with the formatter passed with WithFormatter, or with TemplateFormatter(StandardFormat) if there isn't one, and the
+// lines are combined with the formatter's Join method. FormatTrace returns an empty string if there is no stack trace.
+func FormatTrace(e error, opts ...Option) string {
+	se, ok := asStack(e)
+	if !ok {
+		return ""
+	}
+	o := newRenderOptions(opts)
+	if o.formatter == nil {
+		o.formatter = TemplateFormatter(StandardFormat)
+	}
+	lines, _ := se.renderTrace(nil, o)
+	return o.formatter.Join(lines)
+}
+
+type templateFormatter struct {
+	t *frameTemplate
+}
+
+func (tf templateFormatter) FormatFrame(f Frame) string {
+	var b bytes.Buffer
+	frame := runtime.Frame{Function: f.Function, File: f.File, Line: f.Line, PC: f.PC, Entry: f.Entry}
+	executeFrame(&b, tf.t, frame) // nolint: errcheck
+	return b.String()
+}
+
+func (tf templateFormatter) Join(lines []string) string {
+	return strings.Join(lines, "\n")
+}
+
+// CompactFormatter is a TraceFormatter that renders each frame as "FUNCTION:LINE" and joins the lines with
+// semicolons, like the trace field written by EncodeLogfmtTrace.
+var CompactFormatter TraceFormatter = compactFormatter{}
+
+type compactFormatter struct{}
+
+func (compactFormatter) FormatFrame(f Frame) string {
+	return f.Function + ":" + strconv.Itoa(f.Line)
+}
+
+func (compactFormatter) Join(lines []string) string {
+	return strings.Join(lines, ";")
+}
+
+// goroutineHeader starts the text joined by GoroutineFormatter.
+const goroutineHeader = "goroutine 0 [running]:"
+
+// GoroutineFormatter is a TraceFormatter that renders a stack trace the way the runtime prints a panic, so that tools
+// that read goroutine dumps, such as panicparse and the consoles of IDEs, recognize it and link its files. Each frame
+// is rendered as the function followed by "(...)" and, on a second line, a tab and "FILE:LINE". Join puts the header
+// "goroutine 0 [running]:" before the lines. The ID is always 0, since errors don't record the goroutine that created
+// them.
+var GoroutineFormatter TraceFormatter = goroutineFormatter{}
+
+type goroutineFormatter struct{}
+
+func (goroutineFormatter) FormatFrame(f Frame) string {
+	return f.Function + "(...)\n\t" + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+func (goroutineFormatter) Join(lines []string) string {
+	return goroutineHeader + "\n" + strings.Join(lines, "\n")
+}
+
+// GoroutineHeader makes %+v print the stack trace with GoroutineFormatter, after a goroutine header line. It is the
+// same as WithFormatter(GoroutineFormatter).
+func GoroutineHeader() Option {
+	return WithFormatter(GoroutineFormatter)
+}
@@ -0,0 +1,76 @@
+package stackerr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// arrowFormatter renders frames as "-> package.Func" and numbers the lines.
+type arrowFormatter struct{}
+
+func (arrowFormatter) FormatFrame(f stackerr.Frame) string {
+	return "-> " + f.ShortFunc()
+}
+
+func (arrowFormatter) Join(lines []string) string {
+	for i, v := range lines {
+		lines[i] = fmt.Sprintf("%d %s", i, v)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestWithFormatter(t *testing.T) {
+	err := stackerr.New("boom")
+	lines, traceErr := stackerr.Trace(err, nil, stackerr.WithFormatter(arrowFormatter{}), stackerr.OnlyModules("github.com/jonbodner/"))
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if diff := cmp.Diff([]string{"-> stackerr_test.TestWithFormatter", "[2 frames elided]"}, lines); diff != "" {
+		t.Error(diff)
+	}
+
+	expected := "0 -> stackerr_test.TestWithFormatter\n1 [2 frames elided]"
+	if out := stackerr.FormatTrace(err, stackerr.WithFormatter(arrowFormatter{}), stackerr.OnlyModules("github.com/jonbodner/")); out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+	standard, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	if out := stackerr.FormatTrace(err); out != strings.Join(standard, "\n") {
+		t.Errorf("expected the standard format by default, got %q", out)
+	}
+	if out := stackerr.FormatTrace(err, stackerr.WithFormatter(stackerr.CompactFormatter)); !strings.HasPrefix(out, "github.com/jonbodner/stackerr_test.TestWithFormatter:") || strings.Count(out, ";") != 2 {
+		t.Errorf("unexpected compact trace %q", out)
+	}
+	if out := stackerr.FormatTrace(fmt.Errorf("no stack")); out != "" {
+		t.Errorf("expected an empty string without a stack trace, got %q", out)
+	}
+
+	stackerr.SetFormatOptions(stackerr.WithFormatter(arrowFormatter{}), stackerr.OnlyModules("github.com/jonbodner/"))
+	defer stackerr.SetFormatOptions()
+	if out := fmt.Sprintf("%+v", err); out != "boom\n"+expected {
+		t.Errorf("expected %%+v to use the formatter, got %q", out)
+	}
+}
+
+func TestGoroutineHeader(t *testing.T) {
+	err := stackerr.New("boom")
+	frames := stackerr.Frames(err)
+	var expected []string
+	for _, v := range frames {
+		expected = append(expected, fmt.Sprintf("%s(...)\n\t%s:%d", v.Function, v.File, v.Line))
+	}
+	trace := "goroutine 0 [running]:\n" + strings.Join(expected, "\n")
+	if diff := cmp.Diff(trace, stackerr.FormatTrace(err, stackerr.WithFormatter(stackerr.GoroutineFormatter))); diff != "" {
+		t.Error(diff)
+	}
+
+	stackerr.SetFormatOptions(stackerr.GoroutineHeader())
+	defer stackerr.SetFormatOptions()
+	if diff := cmp.Diff("boom\n"+trace, fmt.Sprintf("%+v", err)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,231 @@
+package stackerr
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FrameKind classifies the code that a Frame belongs to.
+type FrameKind string
+
+const (
+	// FrameApp is a frame from the main module of the running program.
+	FrameApp FrameKind = "app"
+	// FrameDependency is a frame from a module that the main module depends on.
+	FrameDependency FrameKind = "dependency"
+	// FrameStdlib is a frame from the Go standard library or the runtime.
+	FrameStdlib FrameKind = "stdlib"
+	// FrameCgo is a frame from C code called through cgo: either a frame the runtime has no function or file for, or
+	// a frame returned by a CgoCollector.
+	FrameCgo FrameKind = "cgo"
+)
+
+// Frame is a single frame from a stack trace.
+type Frame struct {
+	Function string    `json:"function"`
+	File     string    `json:"file"`
+	Line     int       `json:"line"`
+	Kind     FrameKind `json:"kind"`
+	// PC and Entry are the frame's program counter and the entry address of its function. They are only set while
+	// SetFrameAddresses is on, and are not kept by the encodings other than JSON.
+	PC    uintptr `json:"pc,omitempty"`
+	Entry uintptr `json:"entry,omitempty"`
+}
+
+var frameAddresses int32
+
+// SetFrameAddresses turns on or off filling in the PC and Entry fields of the frames returned by Frames and Report,
+// which also adds them to the JSON encoding of errors. Use it for low-level debugging, such as matching frames with
+// the output of perf or objdump; see ShowAddresses for rendered stack traces. It is off by default.
+func SetFrameAddresses(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&frameAddresses, v)
+}
+
+// IsStdlib reports whether the frame is from the Go standard library or the runtime. Frames built by hand without a
+// Kind are classified from their function and file.
+func (f Frame) IsStdlib() bool {
+	if f.Kind == "" {
+		return classify(runtime.Frame{Function: f.Function, File: f.File}) == FrameStdlib
+	}
+	return f.Kind == FrameStdlib
+}
+
+// Package returns the import path of the package that contains the frame's function, such as
+// "github.com/jonbodner/stackerr".
+func (f Frame) Package() string {
+	return funcPackage(f.Function)
+}
+
+// ShortFunc returns the frame's function name without the directories of its import path, such as
+// "stackerr.(*FieldErrors).Add".
+func (f Frame) ShortFunc() string {
+	return f.Function[strings.LastIndex(f.Function, "/")+1:]
+}
+
+// BaseFile returns the last element of the frame's file path, such as "frame.go".
+func (f Frame) BaseFile() string {
+	if f.File == "" {
+		return ""
+	}
+	return path.Base(f.File)
+}
+
+// TemplateFrame is the value passed to the templates used by Trace and %+v for each frame. Along with the fields of
+// runtime.Frame, it has fields computed from them, so common layouts don't need a FuncMap.
+type TemplateFrame struct {
+	runtime.Frame
+	// Package is the import path of the function's package. See Frame.Package.
+	Package string
+	// ShortFunction is the function name without the directories of its import path. See Frame.ShortFunc.
+	ShortFunction string
+	// BaseFile is the last element of the file path. See Frame.BaseFile.
+	BaseFile string
+	// RelFile is the file path relative to the standard library source directory for standard library frames, or
+	// relative to the working directory the program started in for files under it. Otherwise it is the full path.
+	RelFile string
+}
+
+func newTemplateFrame(frame runtime.Frame) TemplateFrame {
+	f := Frame{Function: frame.Function, File: frame.File}
+	return TemplateFrame{
+		Frame:         frame,
+		Package:       f.Package(),
+		ShortFunction: f.ShortFunc(),
+		BaseFile:      f.BaseFile(),
+		RelFile:       relFile(frame.File),
+	}
+}
+
+// relFile implements TemplateFrame.RelFile.
+func relFile(file string) string {
+	classifier.once.Do(initClassifier)
+	for _, dir := range []string{classifier.gorootSrc, classifier.workDir} {
+		if dir != "" && strings.HasPrefix(file, dir) {
+			return file[len(dir):]
+		}
+	}
+	return file
+}
+
+// Frames returns the frames for the stack trace in the unwrap chain for the error, innermost frame first. It returns
+// nil if there is no stack trace.
+func Frames(e error) []Frame {
+	se, ok := asStack(e)
+	if !ok {
+		return nil
+	}
+	return se.exportFrames()
+}
+
+// exportFrames returns the frames for the errorStack's stack trace. Frames that were decoded from another process are
+// returned as they were decoded, rather than being classified again.
+func (e *errorStack) exportFrames() []Frame {
+	if e.earlier != nil {
+		return e.earlier.exportFrames()
+	}
+	if e.trace != nil && e.trace.decoded != nil {
+		out := make([]Frame, len(e.trace.decoded))
+		copy(out, e.trace.decoded)
+		return out
+	}
+	out := toFrames(e.frames())
+	if e.trace != nil && len(e.trace.cgo) > 0 {
+		// dropHelpers can remove frames from the front of the trace, so the C frames are found by what they are
+		// rather than by where they are.
+		for i := range out {
+			if e.trace.isCgo(out[i]) {
+				out[i].Kind = FrameCgo
+			}
+		}
+	}
+	return out
+}
+
+func toFrames(frames []runtime.Frame) []Frame {
+	addresses := atomic.LoadInt32(&frameAddresses) != 0
+	out := make([]Frame, len(frames))
+	for i, v := range frames {
+		out[i] = Frame{
+			Function: v.Function,
+			File:     v.File,
+			Line:     v.Line,
+			Kind:     classify(v),
+		}
+		if addresses {
+			out[i].PC = v.PC
+			out[i].Entry = v.Entry
+		}
+	}
+	return out
+}
+
+var classifier struct {
+	once       sync.Once
+	mainModule string
+	gorootSrc  string
+	workDir    string
+}
+
+// initClassifier finds the path of the main module from the build info, and the path to the standard library source
+// from the file that contains runtime.Callers. When the program was built with -trimpath, there is no path to the
+// standard library source and gorootSrc is left empty. It also records the working directory for relFile.
+func initClassifier() {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		classifier.mainModule = bi.Main.Path
+	}
+	if wd, err := os.Getwd(); err == nil {
+		classifier.workDir = filepath.ToSlash(wd) + "/"
+	}
+	pc := make([]uintptr, 1)
+	if runtime.Callers(0, pc) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pc).Next()
+	const suffix = "runtime/extern.go"
+	if strings.HasSuffix(frame.File, "/"+suffix) {
+		classifier.gorootSrc = strings.TrimSuffix(frame.File, suffix)
+	}
+}
+
+// classify determines the FrameKind for a frame.
+func classify(frame runtime.Frame) FrameKind {
+	if isUnknownFrame(frame) {
+		return FrameCgo
+	}
+	classifier.once.Do(initClassifier)
+	pkg := funcPackage(frame.Function)
+	if pkg == "main" {
+		return FrameApp
+	}
+	// external test packages have the import path of the package under test, plus _test.
+	if mod := classifier.mainModule; mod != "" {
+		trimmed := strings.TrimSuffix(pkg, "_test")
+		if trimmed == mod || strings.HasPrefix(trimmed, mod+"/") {
+			return FrameApp
+		}
+	}
+	if classifier.gorootSrc != "" && strings.HasPrefix(frame.File, classifier.gorootSrc) {
+		return FrameStdlib
+	}
+	// import paths outside the standard library start with a domain name.
+	if first := strings.SplitN(pkg, "/", 2)[0]; !strings.Contains(first, ".") {
+		return FrameStdlib
+	}
+	return FrameDependency
+}
+
+// isUnknownFrame reports whether the runtime has no symbol information for a frame. This happens for frames from C
+// code called through cgo.
+func isUnknownFrame(frame runtime.Frame) bool {
+	return frame.Function == "" && frame.File == ""
+}
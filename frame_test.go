@@ -0,0 +1,213 @@
+package stackerr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestFrames(t *testing.T) {
+	if stackerr.Frames(errors.New("no stack")) != nil {
+		t.Error("expected no frames for an error without a stack trace")
+	}
+
+	// the comparer is called from inside go-cmp, so the error's stack has frames from a dependency.
+	var err error
+	cmp.Equal(1, 2, cmp.Comparer(func(a, b int) bool {
+		err = stackerr.New("inside go-cmp")
+		return a == b
+	}))
+	kinds := map[stackerr.FrameKind]bool{}
+	for _, v := range stackerr.Frames(err) {
+		kinds[v.Kind] = true
+		switch v.Function {
+		case "github.com/jonbodner/stackerr_test.TestFrames", "github.com/jonbodner/stackerr_test.TestFrames.func1":
+			if v.Kind != stackerr.FrameApp {
+				t.Errorf("expected `%s` to be an app frame, got %s", v.Function, v.Kind)
+			}
+		case "testing.tRunner", "runtime.goexit":
+			if v.Kind != stackerr.FrameStdlib {
+				t.Errorf("expected `%s` to be a stdlib frame, got %s", v.Function, v.Kind)
+			}
+		}
+	}
+	for _, v := range []stackerr.FrameKind{stackerr.FrameApp, stackerr.FrameDependency, stackerr.FrameStdlib} {
+		if !kinds[v] {
+			t.Errorf("expected a frame of kind %s", v)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	err := stackerr.New("as json")
+	b, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var out struct {
+		Message string
+		Frames  []stackerr.Frame
+	}
+	if jsonErr = json.Unmarshal(b, &out); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if out.Message != "as json" {
+		t.Errorf("expected `as json`, got `%s`", out.Message)
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), out.Frames); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMarshalJSONMetadata(t *testing.T) {
+	err := metadataError(t)
+	b, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	for _, v := range []string{`"request_id":"req-1"`, `"trace_id":"trace-1"`, `"span_id":"span-1"`, `"context":{"tenant":"acme"}`} {
+		if !strings.Contains(string(b), v) {
+			t.Errorf("expected %s in %s", v, b)
+		}
+	}
+	decoded, decodeErr := stackerr.DecodeJSON(b)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	checkMetadata(t, err, decoded)
+
+	// the keys are left out when there's nothing to put in them.
+	if b, _ = json.Marshal(stackerr.New("plain")); strings.Contains(string(b), "_id") || strings.Contains(string(b), "context") {
+		t.Errorf("expected only the message and frames, got %s", b)
+	}
+}
+
+func TestFrameHelpers(t *testing.T) {
+	data := []struct {
+		frame     stackerr.Frame
+		isStdlib  bool
+		pkg       string
+		shortFunc string
+		baseFile  string
+	}{
+		{
+			frame:     stackerr.Frame{Function: "github.com/jonbodner/stackerr.(*FieldErrors).Add", File: "/src/stackerr/field.go", Line: 10, Kind: stackerr.FrameApp},
+			pkg:       "github.com/jonbodner/stackerr",
+			shortFunc: "stackerr.(*FieldErrors).Add",
+			baseFile:  "field.go",
+		},
+		{
+			frame:     stackerr.Frame{Function: "net/http.(*conn).serve", File: "/usr/local/go/src/net/http/server.go", Kind: stackerr.FrameStdlib},
+			isStdlib:  true,
+			pkg:       "net/http",
+			shortFunc: "http.(*conn).serve",
+			baseFile:  "server.go",
+		},
+		{
+			frame:     stackerr.Frame{Function: "main.main.func1", File: "main.go"},
+			pkg:       "main",
+			shortFunc: "main.main.func1",
+			baseFile:  "main.go",
+		},
+		{
+			frame:     stackerr.Frame{Function: "runtime.goexit"},
+			isStdlib:  true,
+			pkg:       "runtime",
+			shortFunc: "runtime.goexit",
+		},
+	}
+	for _, v := range data {
+		t.Run(v.frame.Function, func(t *testing.T) {
+			if v.frame.IsStdlib() != v.isStdlib {
+				t.Errorf("expected IsStdlib to be %t", v.isStdlib)
+			}
+			if diff := cmp.Diff(v.pkg, v.frame.Package()); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(v.shortFunc, v.frame.ShortFunc()); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(v.baseFile, v.frame.BaseFile()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTemplateFrame(t *testing.T) {
+	err := stackerr.New("computed fields")
+	tmpl := template.Must(template.New("computed").Parse("{{.ShortFunction}}|{{.Package}}|{{.BaseFile}}|{{.RelFile}}|{{.Line}}"))
+	lines, _ := stackerr.Trace(err, tmpl)
+	frames := stackerr.Frames(err)
+	if len(lines) != len(frames) {
+		t.Fatalf("expected %d lines, got %d", len(frames), len(lines))
+	}
+	for i, f := range frames {
+		parts := strings.Split(lines[i], "|")
+		expected := []string{f.ShortFunc(), f.Package(), f.BaseFile(), parts[3], strconv.Itoa(f.Line)}
+		if diff := cmp.Diff(expected, parts); diff != "" {
+			t.Error(diff)
+		}
+		switch f.Function {
+		case "github.com/jonbodner/stackerr_test.TestTemplateFrame":
+			// without -trimpath, test files are in the working directory.
+			if strings.HasPrefix(f.File, "/") && parts[3] != "frame_test.go" {
+				t.Errorf("expected frame_test.go, got %s", parts[3])
+			}
+		case "testing.tRunner":
+			if parts[3] != "testing/testing.go" {
+				t.Errorf("expected testing/testing.go, got %s", parts[3])
+			}
+		}
+	}
+}
+
+func TestSetFrameAddresses(t *testing.T) {
+	err := stackerr.New("addresses")
+	if f := stackerr.Frames(err)[0]; f.PC != 0 || f.Entry != 0 {
+		t.Errorf("expected no addresses by default, got %#x %#x", f.PC, f.Entry)
+	}
+	data, _ := json.Marshal(err)
+	if strings.Contains(string(data), `"pc"`) {
+		t.Errorf("expected no addresses in JSON by default, got %s", data)
+	}
+
+	stackerr.SetFrameAddresses(true)
+	defer stackerr.SetFrameAddresses(false)
+	f := stackerr.Frames(err)[0]
+	if f.Entry != reflect.ValueOf(TestSetFrameAddresses).Pointer() || f.PC <= f.Entry {
+		t.Errorf("unexpected addresses %#x %#x", f.PC, f.Entry)
+	}
+	data, _ = json.Marshal(err)
+	if !strings.Contains(string(data), `"pc":`+strconv.FormatUint(uint64(f.PC), 10)) {
+		t.Errorf("expected addresses in JSON, got %s", data)
+	}
+}
+
+func TestFramesCgo(t *testing.T) {
+	// a program counter outside of any Go function has no symbol information, like a frame from C code.
+	err := stackerr.AttachStack(errors.New("cgo"), stackerr.StackFromPCs([]uintptr{1}))
+	frames := stackerr.Frames(err)
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 frame, got %d", len(frames))
+	}
+	if frames[0].Kind != stackerr.FrameCgo || frames[0].IsStdlib() {
+		t.Errorf("expected a cgo frame, got %+v", frames[0])
+	}
+	data, _ := json.Marshal(err)
+	if !strings.Contains(string(data), `"kind":"cgo"`) {
+		t.Errorf("expected the frame to be marked in JSON, got %s", data)
+	}
+	lines, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	if diff := cmp.Diff([]string{"[cgo/unknown frame]"}, lines); diff != "" {
+		t.Error(diff)
+	}
+}
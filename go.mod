@@ -1,5 +1,5 @@
 module github.com/jonbodner/stackerr
 
-go 1.13
+go 1.21
 
 require github.com/google/go-cmp v0.4.0
@@ -0,0 +1,45 @@
+package stackerr
+
+// boundaryLine separates the two stack traces of an error returned by Resume.
+const boundaryLine = "--- crossed goroutine boundary ---"
+
+// Token carries the stack trace of an error from the goroutine that produced it to the goroutine that handles it.
+// See Handoff and Resume.
+type Token struct {
+	cs *callStack
+}
+
+// Handoff returns a Token with the stack trace of err, to send to another goroutine along with the error. If err has
+// no stack trace, the current call stack is captured. The zero Token is returned for a nil error.
+func Handoff(err error) Token {
+	if err == nil {
+		return Token{}
+	}
+	se, ok := asStack(err)
+	if ok {
+		if se.earlier != nil {
+			se = se.earlier
+		}
+		return Token{cs: se.trace}
+	}
+	return Token{cs: captureStack(1)}
+}
+
+// Resume wraps err in an errorStack that has both the stack trace from the Token and the current call stack. Trace
+// and %+v render the frames from the Token first, where the error was produced, then a line that says the error
+// crossed a goroutine boundary, then the frames from the goroutine that called Resume. Origin and Fingerprint use the
+// frames from the Token. If the Token is the zero value, Resume works like Wrap. Resume returns nil when a nil error
+// is passed in.
+func Resume(t Token, err error) error {
+	if err == nil {
+		return nil
+	}
+	if t.cs == nil {
+		return wrap(err, 1)
+	}
+	return &errorStack{
+		Err:     err,
+		trace:   t.cs,
+		resumed: captureStack(1),
+	}
+}
@@ -0,0 +1,70 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+type handoff struct {
+	err   error
+	token stackerr.Token
+}
+
+func produce(ch chan<- handoff) {
+	err := stackerr.New("in producer")
+	ch <- handoff{err: err, token: stackerr.Handoff(err)}
+}
+
+func TestHandoff(t *testing.T) {
+	ch := make(chan handoff)
+	go produce(ch)
+	h := <-ch
+	err := stackerr.Resume(h.token, h.err)
+
+	if err.Error() != "in producer" || !errors.Is(err, h.err) {
+		t.Errorf("expected the resumed error to match the original, got %v", err)
+	}
+	lines := traceLines(t, err)
+	producer := traceLines(t, h.err)
+	if diff := cmp.Diff(producer, lines[:len(producer)]); diff != "" {
+		t.Error(diff)
+	}
+	if lines[len(producer)] != "--- crossed goroutine boundary ---" {
+		t.Errorf("expected the boundary line, got %s", lines[len(producer)])
+	}
+	if !strings.HasPrefix(lines[len(producer)+1], "github.com/jonbodner/stackerr_test.TestHandoff ") {
+		t.Errorf("expected the consumer frames to start at Resume's caller, got %s", lines[len(producer)+1])
+	}
+	if diff := cmp.Diff("in producer\n"+strings.Join(lines, "\n"), fmt.Sprintf("%+v", err)); diff != "" {
+		t.Error(diff)
+	}
+	if stackerr.Fingerprint(err) != stackerr.Fingerprint(h.err) || !stackerr.SameOrigin(err, h.err) {
+		t.Error("expected the fingerprint and origin to come from the producer")
+	}
+}
+
+func TestHandoffWithoutStack(t *testing.T) {
+	if stackerr.Resume(stackerr.Handoff(nil), nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	plain := errors.New("plain")
+	token := stackerr.Handoff(plain)
+	lines := traceLines(t, stackerr.Resume(token, plain))
+	if !strings.HasPrefix(lines[0], "github.com/jonbodner/stackerr_test.TestHandoffWithoutStack ") {
+		t.Errorf("expected Handoff to capture the stack, got %s", lines[0])
+	}
+
+	err := stackerr.Resume(stackerr.Token{}, plain)
+	for _, v := range traceLines(t, err) {
+		if strings.Contains(v, "goroutine boundary") {
+			t.Error("expected no boundary line for the zero Token")
+		}
+	}
+}
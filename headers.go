@@ -0,0 +1,86 @@
+package stackerr
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// The names of the message headers written by EncodeHeaders.
+const (
+	HeaderFingerprint = "stackerr-fingerprint"
+	HeaderCode        = "stackerr-code"
+	HeaderCategory    = "stackerr-category"
+	HeaderTrace       = "stackerr-trace"
+)
+
+// EncodeHeaders encodes an error as message headers for a message bus such as Kafka or NATS, so a consumer of a
+// dead-letter queue can tell where the producer's error came from. The headers hold the error's fingerprint, its code
+// and category if it has them, and its compact trace from EncodeCompact, which has the message and the raw program
+// counters. The compact trace is base64 encoded, so every value is printable text, as NATS requires. EncodeHeaders
+// returns nil when a nil error is passed in.
+func EncodeHeaders(err error) map[string][]byte {
+	if err == nil {
+		return nil
+	}
+	h := map[string][]byte{}
+	if fp := Fingerprint(err); fp != "" {
+		h[HeaderFingerprint] = []byte(fp)
+	}
+	if c, ok := Code(err); ok {
+		h[HeaderCode] = strconv.AppendInt(nil, int64(c), 10)
+	}
+	if c, ok := CategoryOf(err); ok {
+		h[HeaderCategory], _ = c.MarshalText()
+	}
+	data, _ := EncodeCompact(err)
+	trace := make([]byte, base64.RawStdEncoding.EncodedLen(len(data)))
+	base64.RawStdEncoding.Encode(trace, data)
+	h[HeaderTrace] = trace
+	return h
+}
+
+// DecodeHeaders decodes an error from the message headers written by EncodeHeaders. Other headers are ignored. The
+// decoded error has the original message, code, and category, and Fingerprint returns the original fingerprint. If
+// the headers were written by another copy of the running binary, the program counters are symbolized as they are by
+// DecodeCompact. Otherwise the decoded error has no frames; pass the value of the HeaderTrace header to the
+// stackerr-symbolize command, with the producer's binary, to see them.
+func DecodeHeaders(h map[string][]byte) (error, error) {
+	trace, ok := h[HeaderTrace]
+	if !ok {
+		return nil, errors.New("stackerr: no " + HeaderTrace + " header")
+	}
+	data := make([]byte, base64.RawStdEncoding.DecodedLen(len(trace)))
+	n, decodeErr := base64.RawStdEncoding.Decode(data, trace)
+	if decodeErr != nil {
+		return nil, errors.New("stackerr: invalid " + HeaderTrace + " header")
+	}
+	c, parseErr := ParseCompact(data[:n])
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	err, compactErr := DecodeCompact(data[:n])
+	if compactErr != nil {
+		err = &errorStack{
+			Err:   &remoteError{msg: c.Message},
+			trace: &callStack{decoded: []Frame{}},
+		}
+	}
+	se := err.(*errorStack)
+	se.trace.fingerprint = string(h[HeaderFingerprint])
+	if v, ok := h[HeaderCode]; ok {
+		code, codeErr := strconv.Atoi(string(v))
+		if codeErr != nil {
+			return nil, errors.New("stackerr: invalid " + HeaderCode + " header")
+		}
+		err = annotated{err: err, key: codeKey, value: code}
+	}
+	if v, ok := h[HeaderCategory]; ok {
+		var c Category
+		if c.UnmarshalText(v) != nil {
+			return nil, errors.New("stackerr: invalid " + HeaderCategory + " header")
+		}
+		err = annotated{err: err, key: categoryKey, value: c}
+	}
+	return err, nil
+}
@@ -0,0 +1,96 @@
+package stackerr_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestEncodeHeaders(t *testing.T) {
+	if stackerr.EncodeHeaders(nil) != nil {
+		t.Error("expected nil headers for a nil error")
+	}
+	err := stackerr.WithCategory(stackerr.WithCode(stackerr.New("headers"), 503), stackerr.CategoryUnavailable)
+	h := stackerr.EncodeHeaders(err)
+	if string(h[stackerr.HeaderFingerprint]) != stackerr.Fingerprint(err) || string(h[stackerr.HeaderCode]) != "503" ||
+		string(h[stackerr.HeaderCategory]) != "unavailable" {
+		t.Errorf("unexpected headers %q", h)
+	}
+	data, decodeErr := base64.RawStdEncoding.DecodeString(string(h[stackerr.HeaderTrace]))
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	c, parseErr := stackerr.ParseCompact(data)
+	if parseErr != nil || c.Message != "headers" {
+		t.Errorf("unexpected compact trace %+v, %v", c, parseErr)
+	}
+
+	h = stackerr.EncodeHeaders(errors.New("plain"))
+	if _, ok := h[stackerr.HeaderFingerprint]; ok || len(h) != 1 {
+		t.Errorf("expected only the trace header, got %q", h)
+	}
+}
+
+func TestDecodeHeaders(t *testing.T) {
+	skipWithoutBuildID(t)
+	err := stackerr.WithCategory(stackerr.WithCode(stackerr.New("headers"), 503), stackerr.CategoryUnavailable)
+	h := stackerr.EncodeHeaders(err)
+	h["other"] = []byte("ignored")
+	decoded, decodeErr := stackerr.DecodeHeaders(h)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if decoded.Error() != "headers" || stackerr.Fingerprint(decoded) != stackerr.Fingerprint(err) {
+		t.Errorf("unexpected decoded error %q %q", decoded, stackerr.Fingerprint(decoded))
+	}
+	if code, ok := stackerr.Code(decoded); !ok || code != 503 {
+		t.Errorf("expected code 503, got %d", code)
+	}
+	if c, _ := stackerr.CategoryOf(decoded); c != stackerr.CategoryUnavailable {
+		t.Errorf("expected category unavailable, got %v", c)
+	}
+	if diff := cmp.Diff(traceLines(t, err), traceLines(t, decoded)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDecodeHeadersOtherBuild(t *testing.T) {
+	// a compact trace with the message "boom" and one frame, from a binary with the content ID "other".
+	data := []byte{'s', 'e', 1, 5, 'o', 't', 'h', 'e', 'r', 4, 'b', 'o', 'o', 'm', 1, 2}
+	h := map[string][]byte{
+		stackerr.HeaderFingerprint: []byte("0123456789abcdef"),
+		stackerr.HeaderTrace:       []byte(base64.RawStdEncoding.EncodeToString(data)),
+	}
+	decoded, decodeErr := stackerr.DecodeHeaders(h)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if decoded.Error() != "boom" || stackerr.Fingerprint(decoded) != "0123456789abcdef" {
+		t.Errorf("unexpected decoded error %q %q", decoded, stackerr.Fingerprint(decoded))
+	}
+	if frames := stackerr.Frames(decoded); len(frames) != 0 {
+		t.Errorf("expected no frames, got %v", frames)
+	}
+	if _, ok := stackerr.Code(decoded); ok {
+		t.Error("expected no code")
+	}
+	if _, ok := stackerr.CategoryOf(decoded); ok {
+		t.Error("expected no category")
+	}
+
+	for _, v := range []map[string][]byte{
+		{},
+		{stackerr.HeaderTrace: []byte("!!!")},
+		{stackerr.HeaderTrace: []byte(base64.RawStdEncoding.EncodeToString([]byte("xx")))},
+		{stackerr.HeaderTrace: h[stackerr.HeaderTrace], stackerr.HeaderCode: []byte("x")},
+		{stackerr.HeaderTrace: h[stackerr.HeaderTrace], stackerr.HeaderCategory: []byte("x")},
+	} {
+		if _, err := stackerr.DecodeHeaders(v); err == nil {
+			t.Errorf("expected an error for %q", v)
+		}
+	}
+}
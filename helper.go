@@ -0,0 +1,82 @@
+package stackerr
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var helpers = struct {
+	sync.RWMutex
+	funcs    map[string]struct{}
+	packages map[string]struct{}
+}{
+	funcs:    map[string]struct{}{},
+	packages: map[string]struct{}{},
+}
+
+// Helper marks the calling function as an error helper, like testing.T.Helper does for test helpers. When a stack
+// trace is rendered, frames for helper functions at the top of the stack are dropped, so the first frame shown is the
+// code that called the helper. Helper can be called from the helper every time it runs.
+func Helper() {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	helpers.RLock()
+	_, found := helpers.funcs[frame.Function]
+	helpers.RUnlock()
+	if found {
+		return
+	}
+	helpers.Lock()
+	helpers.funcs[frame.Function] = struct{}{}
+	helpers.Unlock()
+}
+
+// RegisterHelperPackage marks every function in the packages with the specified import paths as an error helper.
+// Use this for error-utility packages whose frames should never be the top frame of a stack trace. See Helper.
+func RegisterHelperPackage(paths ...string) {
+	helpers.Lock()
+	defer helpers.Unlock()
+	for _, v := range paths {
+		helpers.packages[v] = struct{}{}
+	}
+}
+
+// isHelper reports whether the fully-qualified function name belongs to a helper function or helper package.
+func isHelper(function string) bool {
+	helpers.RLock()
+	defer helpers.RUnlock()
+	if _, ok := helpers.funcs[function]; ok {
+		return true
+	}
+	if len(helpers.packages) == 0 {
+		return false
+	}
+	_, ok := helpers.packages[funcPackage(function)]
+	return ok
+}
+
+// dropHelpers removes the helper frames at the top of the stack. If every frame is a helper frame, frames is returned
+// unchanged.
+func dropHelpers(frames []runtime.Frame) []runtime.Frame {
+	for i, v := range frames {
+		if !isHelper(v.Function) {
+			return frames[i:]
+		}
+	}
+	return frames
+}
+
+// funcPackage returns the import path of the package for a fully-qualified function name, such as
+// "github.com/jonbodner/stackerr.Wrap" or "github.com/jonbodner/stackerr.errorStack.Error".
+func funcPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot == -1 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
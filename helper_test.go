@@ -0,0 +1,55 @@
+package stackerr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/internal/helpertest"
+)
+
+func markedHelper(msg string) error {
+	stackerr.Helper()
+	return stackerr.New(msg)
+}
+
+func unmarkedHelper(msg string) error {
+	return stackerr.New(msg)
+}
+
+func topFrame(t *testing.T, err error) string {
+	t.Helper()
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if len(lines) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	return lines[0]
+}
+
+func TestHelper(t *testing.T) {
+	data := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"marked helper", markedHelper("marked"), "github.com/jonbodner/stackerr_test.TestHelper "},
+		{"unmarked helper", unmarkedHelper("unmarked"), "github.com/jonbodner/stackerr_test.unmarkedHelper "},
+		{"helper package", helpertest.New("package"), "github.com/jonbodner/stackerr/internal/helpertest.New "},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			if top := topFrame(t, v.err); !strings.HasPrefix(top, v.expected) {
+				t.Errorf("expected top frame to start with `%s`, got `%s`", v.expected, top)
+			}
+		})
+	}
+
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/internal/helpertest")
+	err := helpertest.New("package")
+	if top := topFrame(t, err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestHelper ") {
+		t.Errorf("expected helper package frame to be dropped, got `%s`", top)
+	}
+}
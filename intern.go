@@ -0,0 +1,96 @@
+package stackerr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var interning int32
+
+var internTable = struct {
+	sync.Mutex
+	suffixes map[uint64][]uintptr
+}{
+	suffixes: map[uint64][]uintptr{},
+}
+
+// SetInterning turns stack interning on or off. While interning is on, captured stack traces that end with the same
+// frames as an earlier trace share the memory for those frames. This reduces memory use in programs that keep many
+// errors created from the same call paths. Turning interning off releases the intern table; errors that were already
+// created are not affected. Interning is off by default.
+//
+// The intern table grows with the number of distinct call paths that create errors, so interning is best suited to
+// programs with a bounded set of call paths.
+func SetInterning(on bool) {
+	if on {
+		atomic.StoreInt32(&interning, 1)
+		return
+	}
+	atomic.StoreInt32(&interning, 0)
+	internTable.Lock()
+	internTable.suffixes = map[uint64][]uintptr{}
+	internTable.Unlock()
+}
+
+// internStack builds a callStack for pc. If interning is off, pc is stored as-is. Otherwise, the longest suffix of pc
+// that is already in the intern table is shared. If no suffix is found, pc is stored and all of its suffixes are
+// added to the intern table.
+func internStack(pc []uintptr) *callStack {
+	if atomic.LoadInt32(&interning) == 0 {
+		return &callStack{head: pc}
+	}
+	internTable.Lock()
+	defer internTable.Unlock()
+	for i := range pc {
+		suffix := pc[i:]
+		shared, ok := internTable.suffixes[hashPCs(suffix)]
+		if !ok || !equalPCs(shared, suffix) {
+			continue
+		}
+		var head []uintptr
+		if i > 0 {
+			head = make([]uintptr, i)
+			copy(head, pc[:i])
+		}
+		return &callStack{head: head, tail: shared}
+	}
+	stored := make([]uintptr, len(pc))
+	copy(stored, pc)
+	for i := range stored {
+		h := hashPCs(stored[i:])
+		if _, ok := internTable.suffixes[h]; !ok {
+			internTable.suffixes[h] = stored[i:]
+		}
+	}
+	return &callStack{tail: stored}
+}
+
+// hashPCs computes the FNV-1a hash of a slice of program counters.
+func hashPCs(pcs []uintptr) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+	h := uint64(offset)
+	for _, pc := range pcs {
+		v := uint64(pc)
+		for i := 0; i < 8; i++ {
+			h ^= v & 0xff
+			h *= prime
+			v >>= 8
+		}
+	}
+	return h
+}
+
+func equalPCs(a, b []uintptr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
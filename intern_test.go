@@ -0,0 +1,42 @@
+package stackerr_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func newInHelper() error {
+	return stackerr.New("from helper")
+}
+
+func TestSetInterning(t *testing.T) {
+	defer stackerr.SetInterning(false)
+	var direct, helper [][]string
+	for _, on := range []bool{false, true, true} {
+		stackerr.SetInterning(on)
+		lines, err := stackerr.Trace(stackerr.New("direct"), stackerr.StandardFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		direct = append(direct, lines)
+		lines, err = stackerr.Trace(newInHelper(), stackerr.StandardFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		helper = append(helper, lines)
+	}
+	for i := 1; i < len(direct); i++ {
+		if diff := cmp.Diff(direct[0], direct[i]); diff != "" {
+			t.Error(diff)
+		}
+		if diff := cmp.Diff(helper[0], helper[i]); diff != "" {
+			t.Error(diff)
+		}
+	}
+	if len(helper[0]) != len(direct[0])+1 {
+		t.Errorf("expected helper trace to have one more frame than direct trace, got %d and %d", len(helper[0]), len(direct[0]))
+	}
+}
@@ -0,0 +1,85 @@
+// Package buildid reads the Go build ID from an executable, the same value reported by go tool buildid.
+package buildid
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// prefix and suffix surround the build ID in the text of non-ELF executables.
+	prefix = []byte("\xff Go build ID: \"")
+	suffix = []byte("\"\n \xff")
+	// elfNote is the name of the ELF note that holds the build ID.
+	elfNote = []byte("Go\x00\x00")
+)
+
+// elfNoteType is the type of the ELF note that holds the build ID.
+const elfNoteType = 4
+
+// headSize is the number of bytes at the start of a non-ELF executable that are searched for the build ID.
+const headSize = 64 * 1024
+
+// Read returns the Go build ID of the executable at path. ELF executables keep the build ID in a note; other formats
+// keep it near the start of the file.
+func Read(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if ef, err := elf.NewFile(f); err == nil {
+		return readELF(ef)
+	}
+	head := make([]byte, headSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return find(head[:n])
+}
+
+func readELF(f *elf.File) (string, error) {
+	s := f.Section(".note.go.buildid")
+	if s == nil {
+		return "", errors.New("buildid: no Go build ID note")
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	// a note is a 4-byte name size, a 4-byte description size, a 4-byte type, and then the name and description.
+	if len(data) < 16 {
+		return "", errors.New("buildid: malformed Go build ID note")
+	}
+	nameSize := f.ByteOrder.Uint32(data)
+	descSize := f.ByteOrder.Uint32(data[4:])
+	noteType := f.ByteOrder.Uint32(data[8:])
+	if nameSize != 4 || noteType != elfNoteType || !bytes.Equal(data[12:16], elfNote) || int(descSize) > len(data)-16 {
+		return "", errors.New("buildid: malformed Go build ID note")
+	}
+	return string(data[16 : 16+descSize]), nil
+}
+
+func find(data []byte) (string, error) {
+	start := bytes.Index(data, prefix)
+	if start == -1 {
+		return "", errors.New("buildid: no Go build ID found")
+	}
+	data = data[start+len(prefix):]
+	end := bytes.Index(data, suffix)
+	if end == -1 {
+		return "", errors.New("buildid: no Go build ID found")
+	}
+	return string(data[:end]), nil
+}
+
+// ContentID returns the last part of a build ID, which is a hash of the executable's contents. It identifies a build
+// as well as the full build ID does, in a quarter of the space.
+func ContentID(id string) string {
+	return id[strings.LastIndexByte(id, '/')+1:]
+}
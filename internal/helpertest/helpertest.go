@@ -0,0 +1,9 @@
+// Package helpertest provides an error helper package for the stackerr tests.
+package helpertest
+
+import "github.com/jonbodner/stackerr"
+
+// New creates an error with a stack trace from inside this package.
+func New(msg string) error {
+	return stackerr.New(msg)
+}
@@ -0,0 +1,19 @@
+// Package initstack finds stack traces that were captured while a package was being initialized. The packages with the
+// API of another errors package use it for sentinel errors, which code written for that API creates with New instead
+// of stackerr.NewBare.
+package initstack
+
+import "github.com/jonbodner/stackerr"
+
+// Captured reports whether the stack trace that stackerr reports for err was captured while a package was being
+// initialized, such as for a sentinel error in a package-level variable. That stack trace only points at the variable
+// declaration, so an error that wraps err should capture its own.
+func Captured(err error) bool {
+	for _, v := range stackerr.Frames(err) {
+		// runtime.doInit1 runs the initializers of a package since Go 1.21; earlier releases call runtime.doInit.
+		if v.Function == "runtime.doInit1" || v.Function == "runtime.doInit" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package stackerr
+
+import (
+	"fmt"
+	"io"
+)
+
+// IOError adds details about a failed Read, Write, or Close call to the error it returned. Use errors.As to get it
+// from an error returned by a reader from WrapReader or a writer from WrapWriter.
+type IOError struct {
+	// Err is the original error.
+	Err error
+	// Op is "read", "write", or "close".
+	Op string
+	// Offset is the number of bytes that were read or written before the error, including any bytes from the call
+	// that failed.
+	Offset int64
+}
+
+// Error returns the operation and offset, followed by the original error message.
+func (ie *IOError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %v", ie.Op, ie.Offset, ie.Err)
+}
+
+// Unwrap exposes the original error.
+func (ie *IOError) Unwrap() error {
+	return ie.Err
+}
+
+type reader struct {
+	r      io.Reader
+	offset int64
+}
+
+// WrapReader returns a reader that wraps every error returned by r, except io.EOF, in an *IOError with a stack trace
+// from the code that called Read or Close. If r doesn't implement io.Closer, Close does nothing.
+func WrapReader(r io.Reader) io.ReadCloser {
+	return &reader{r: r}
+}
+
+// Read calls Read on the wrapped reader.
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.offset += int64(n)
+	if err != nil && err != io.EOF {
+		err = wrap(&IOError{Err: err, Op: "read", Offset: r.offset}, 1)
+	}
+	return n, err
+}
+
+// Close calls Close on the wrapped reader, if it has one.
+func (r *reader) Close() error {
+	c, ok := r.r.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return wrapClose(c, r.offset)
+}
+
+type writer struct {
+	w      io.Writer
+	offset int64
+}
+
+// WrapWriter returns a writer that wraps every error returned by w in an *IOError with a stack trace from the code
+// that called Write or Close. If w doesn't implement io.Closer, Close does nothing.
+func WrapWriter(w io.Writer) io.WriteCloser {
+	return &writer{w: w}
+}
+
+// Write calls Write on the wrapped writer.
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	if err != nil {
+		err = wrap(&IOError{Err: err, Op: "write", Offset: w.offset}, 1)
+	}
+	return n, err
+}
+
+// Close calls Close on the wrapped writer, if it has one.
+func (w *writer) Close() error {
+	c, ok := w.w.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return wrapClose(c, w.offset)
+}
+
+// wrapClose skips its own frame and the Close method that called it.
+func wrapClose(c io.Closer, offset int64) error {
+	if err := c.Close(); err != nil {
+		return wrap(&IOError{Err: err, Op: "close", Offset: offset}, 2)
+	}
+	return nil
+}
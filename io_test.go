@@ -0,0 +1,82 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/jonbodner/stackerr"
+)
+
+var errBroken = errors.New("broken")
+
+type brokenCloser struct {
+	io.Reader
+}
+
+func (brokenCloser) Close() error { return errBroken }
+
+type limitedWriter struct {
+	remaining int
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > lw.remaining {
+		n := lw.remaining
+		lw.remaining = 0
+		return n, errBroken
+	}
+	lw.remaining -= len(p)
+	return len(p), nil
+}
+
+func TestWrapReader(t *testing.T) {
+	r := stackerr.WrapReader(io.MultiReader(strings.NewReader("hello"), iotest.ErrReader(io.ErrUnexpectedEOF)))
+	_, err := io.Copy(io.Discard, r)
+	checkIOError(t, err, io.ErrUnexpectedEOF, "read", 5, "read at offset 5: unexpected EOF")
+
+	// io.EOF is passed through so callers can compare it directly.
+	r = stackerr.WrapReader(strings.NewReader(""))
+	if _, err = r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF, got `%v`", err)
+	}
+	if err = r.Close(); err != nil {
+		t.Errorf("expected nil from Close, got `%v`", err)
+	}
+
+	r = stackerr.WrapReader(brokenCloser{strings.NewReader("")})
+	checkIOError(t, r.Close(), errBroken, "close", 0, "close at offset 0: broken")
+}
+
+func TestWrapWriter(t *testing.T) {
+	w := stackerr.WrapWriter(&limitedWriter{remaining: 3})
+	_, err := io.Copy(w, bytes.NewReader([]byte("hello")))
+	checkIOError(t, err, errBroken, "write", 3, "write at offset 3: broken")
+	if err = w.Close(); err != nil {
+		t.Errorf("expected nil from Close, got `%v`", err)
+	}
+}
+
+func checkIOError(t *testing.T, err error, target error, op string, offset int64, msg string) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("expected error to wrap `%v`, got `%v`", target, err)
+	}
+	var ie *stackerr.IOError
+	if !errors.As(err, &ie) {
+		t.Fatal("expected an *IOError")
+	}
+	if ie.Op != op || ie.Offset != offset {
+		t.Errorf("expected %s at %d, got %s at %d", op, offset, ie.Op, ie.Offset)
+	}
+	if err.Error() != msg {
+		t.Errorf("expected `%s`, got `%s`", msg, err.Error())
+	}
+	frames := stackerr.Frames(err)
+	if len(frames) == 0 || strings.HasPrefix(frames[0].Function, "github.com/jonbodner/stackerr.") {
+		t.Errorf("expected stack trace to start outside of stackerr, got %v", frames)
+	}
+}
@@ -0,0 +1,45 @@
+package stackerr
+
+import "encoding/json"
+
+// MarshalJSON encodes the errorStack as a JSON object with the error message and the frames of its stack trace, along
+// with the request ID, the trace and span IDs, and the context values attached to it, when there are any.
+func (e *errorStack) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e)
+}
+
+// MarshalJSON encodes the annotated error like the MarshalJSON method of the errorStack it wraps, so the values
+// attached outside of the errorStack, such as a request ID, are encoded too.
+func (a annotated) MarshalJSON() ([]byte, error) {
+	return marshalJSON(a)
+}
+
+// marshalJSON implements MarshalJSON for the error.
+func marshalJSON(err error) ([]byte, error) {
+	out := struct {
+		Message   string            `json:"message"`
+		Frames    []Frame           `json:"frames"`
+		RequestID string            `json:"request_id,omitempty"`
+		TraceID   string            `json:"trace_id,omitempty"`
+		SpanID    string            `json:"span_id,omitempty"`
+		Context   map[string]string `json:"context,omitempty"`
+	}{
+		Message: err.Error(),
+		Frames:  Frames(err),
+		Context: ContextValues(err),
+	}
+	out.RequestID, _ = RequestID(err)
+	out.TraceID, out.SpanID, _ = SpanIDs(err)
+	return json.Marshal(out)
+}
+
+// DecodeJSON decodes an error from JSON written by MarshalJSON, or from a LogReport encoded as JSON. The decoded error
+// has the original message and frames, and the chain, code, severity, request ID, trace and span IDs, and context
+// values if they were encoded. Trace, Frames, and %+v use the decoded frames.
+func DecodeJSON(data []byte) (error, error) {
+	var r LogReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, Wrap(err)
+	}
+	return FromReport(r), nil
+}
@@ -0,0 +1,86 @@
+package stackerr
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var captureLimit int64
+
+// siteBucket is the token bucket for one call site.
+type siteBucket struct {
+	tokens float64
+	last   time.Time
+	trace  *callStack
+}
+
+var limiter = struct {
+	sync.Mutex
+	sites   map[uintptr]*siteBucket
+	limited uint64
+}{
+	sites: map[uintptr]*siteBucket{},
+}
+
+// SetCaptureLimit limits how many stack traces are captured each second by each call site that creates errors. Once
+// a call site has used up its limit, the errors it creates share the stack trace of the last error that it created
+// with a full capture, instead of capturing their own; the limit refills continuously, up to perSecond captures.
+// This keeps an error storm from a hot call site from making an outage worse. Because the shared trace was captured
+// by an earlier call, its outer frames may differ from the current call's. Pass 0 to turn the limit off, which also
+// resets the per-site state. The limit is off by default.
+func SetCaptureLimit(perSecond int) {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	limiter.Lock()
+	limiter.sites = map[uintptr]*siteBucket{}
+	limiter.limited = 0
+	limiter.Unlock()
+	atomic.StoreInt64(&captureLimit, int64(perSecond))
+}
+
+// LimitedCaptures returns the number of errors that shared an earlier stack trace because their call site was over the
+// limit set by SetCaptureLimit.
+func LimitedCaptures() uint64 {
+	limiter.Lock()
+	defer limiter.Unlock()
+	return limiter.limited
+}
+
+// limitedStack captures the call stack, skipping skip frames above the caller of limitedStack, if the call site has a
+// token left. Otherwise it returns the call site's last captured stack.
+func limitedStack(skip int) *callStack {
+	var site [1]uintptr
+	if runtime.Callers(skip+2, site[:]) == 0 {
+		return newStack(skip + 1)
+	}
+	limit := float64(atomic.LoadInt64(&captureLimit))
+	now := time.Now()
+	limiter.Lock()
+	b, ok := limiter.sites[site[0]]
+	if !ok {
+		b = &siteBucket{tokens: limit, last: now}
+		limiter.sites[site[0]] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * limit
+	if b.tokens > limit {
+		b.tokens = limit
+	}
+	b.last = now
+	if b.tokens < 1 && b.trace != nil {
+		limiter.limited++
+		cs := b.trace
+		limiter.Unlock()
+		return cs
+	}
+	b.tokens--
+	limiter.Unlock()
+
+	cs := newStack(skip + 1)
+	limiter.Lock()
+	b.trace = cs
+	limiter.Unlock()
+	return cs
+}
@@ -0,0 +1,43 @@
+package stackerr_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSetCaptureLimit(t *testing.T) {
+	stackerr.SetCaptureLimit(2)
+	defer stackerr.SetCaptureLimit(0)
+
+	var errs []error
+	for i := 0; i < 5; i++ {
+		errs = append(errs, stackerr.New("storm"))
+	}
+	other := stackerr.New("other site")
+	if limited := stackerr.LimitedCaptures(); limited != 3 {
+		t.Errorf("expected 3 limited captures, got %d", limited)
+	}
+	expected := traceLines(t, errs[0])
+	for _, err := range errs[1:] {
+		if diff := cmp.Diff(expected, traceLines(t, err)); diff != "" {
+			t.Error(diff)
+		}
+	}
+	if !stackerr.HasStack(other) {
+		t.Error("expected the other site to capture a stack")
+	}
+
+	stackerr.SetCaptureLimit(0)
+	if limited := stackerr.LimitedCaptures(); limited != 0 {
+		t.Errorf("expected the count to be reset, got %d", limited)
+	}
+	for i := 0; i < 5; i++ {
+		_ = stackerr.New("no limit")
+	}
+	if limited := stackerr.LimitedCaptures(); limited != 0 {
+		t.Errorf("expected no limited captures, got %d", limited)
+	}
+}
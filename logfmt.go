@@ -0,0 +1,88 @@
+package stackerr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EncodeLogfmt renders an error as a single logfmt line with msg, origin, file, line, fingerprint, category, op,
+// request_id, trace_id, and span_id fields, followed by a context.name field for each context value, such as
+//
+//	msg="open config: not found" origin=main.loadConfig file=config.go line=42 fingerprint=5f1c0e2a9b7d4c31
+//
+// The origin, file, line, and fingerprint fields are left out when the error has no stack trace, category is left out
+// when CategoryOf finds none, op is left out when no operation names were attached with WithOp, request_id is left out
+// when no request ID was attached with WithRequestID, and trace_id and span_id are left out when no span IDs were
+// attached. The op field holds the operation names joined with ": ". The context values come from ContextValues. An
+// empty string is returned for a nil error.
+func EncodeLogfmt(err error) string {
+	if err == nil {
+		return ""
+	}
+	var b strings.Builder
+	writeLogfmt(&b, err)
+	return b.String()
+}
+
+// EncodeLogfmtTrace works like EncodeLogfmt, but adds a trace field with every frame of the stack trace as
+// function:line, separated by semicolons. Any options are applied to the trace field; see Trace.
+func EncodeLogfmtTrace(err error, opts ...Option) string {
+	if err == nil {
+		return ""
+	}
+	var b strings.Builder
+	se, ok := writeLogfmt(&b, err)
+	if !ok {
+		return b.String()
+	}
+	trace, terr := se.renderTrace(compactFormat, newRenderOptions(opts))
+	if terr == nil && len(trace) > 0 {
+		b.WriteString(" trace=" + logfmtValue(strings.Join(trace, ";")))
+	}
+	return b.String()
+}
+
+func writeLogfmt(b *strings.Builder, err error) (*errorStack, bool) {
+	b.WriteString("msg=" + logfmtValue(err.Error()))
+	se, ok := asStack(err)
+	if !ok {
+		return se, false
+	}
+	if frames := se.exportFrames(); len(frames) > 0 {
+		origin := frames[0]
+		b.WriteString(" origin=" + logfmtValue(origin.Function))
+		b.WriteString(" file=" + logfmtValue(origin.BaseFile()))
+		b.WriteString(" line=" + strconv.Itoa(origin.Line))
+	}
+	b.WriteString(" fingerprint=" + fingerprint(se))
+	if c, ok := CategoryOf(err); ok {
+		b.WriteString(" category=" + logfmtValue(c.String()))
+	}
+	if ops := Ops(err); len(ops) > 0 {
+		b.WriteString(" op=" + logfmtValue(strings.Join(ops, ": ")))
+	}
+	if id, ok := RequestID(err); ok {
+		b.WriteString(" request_id=" + logfmtValue(id))
+	}
+	if traceID, spanID, ok := SpanIDs(err); ok {
+		b.WriteString(" trace_id=" + logfmtValue(traceID) + " span_id=" + logfmtValue(spanID))
+	}
+	values := ContextValues(err)
+	for _, k := range sortedContextValues(values) {
+		b.WriteString(" context." + logfmtValue(k) + "=" + logfmtValue(values[k]))
+	}
+	return se, true
+}
+
+// logfmtValue quotes s when it is empty or contains a space, an equals sign, a quote, or a control character.
+func logfmtValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
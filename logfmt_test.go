@@ -0,0 +1,60 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestEncodeLogfmt(t *testing.T) {
+	if out := stackerr.EncodeLogfmt(nil); out != "" {
+		t.Errorf("expected empty string, got %q", out)
+	}
+	if diff := cmp.Diff(`msg="a \"quoted\" = message"`, stackerr.EncodeLogfmt(errors.New(`a "quoted" = message`))); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(`msg=""`, stackerr.EncodeLogfmt(errors.New(""))); diff != "" {
+		t.Error(diff)
+	}
+
+	err := fmt.Errorf("outer: %w", stackerr.New("inner"))
+	origin, _ := stackerr.Origin(err)
+	expected := fmt.Sprintf(`msg="outer: inner" origin=%s file=%s line=%d fingerprint=%s`,
+		origin.Function, path.Base(origin.File), origin.Line, stackerr.Fingerprint(err))
+	out := stackerr.EncodeLogfmt(err)
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Error(diff)
+	}
+	if strings.Contains(out, "\n") {
+		t.Error("expected a single line")
+	}
+}
+
+func TestEncodeLogfmtTrace(t *testing.T) {
+	err := stackerr.New("boom")
+	var frames []string
+	for _, f := range stackerr.Frames(err) {
+		frames = append(frames, fmt.Sprintf("%s:%d", f.Function, f.Line))
+	}
+	expected := stackerr.EncodeLogfmt(err) + " trace=" + strings.Join(frames, ";")
+	if diff := cmp.Diff(expected, stackerr.EncodeLogfmtTrace(err)); diff != "" {
+		t.Error(diff)
+	}
+
+	out := stackerr.EncodeLogfmtTrace(err, stackerr.OnlyModules("github.com/jonbodner/"))
+	suffix := fmt.Sprintf(` trace="%s;[%d frames elided]"`, frames[0], len(frames)-1)
+	if !strings.HasSuffix(out, suffix) {
+		t.Errorf("expected suffix %q, got %q", suffix, out)
+	}
+
+	plain := errors.New("plain")
+	if diff := cmp.Diff(stackerr.EncodeLogfmt(plain), stackerr.EncodeLogfmtTrace(plain)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,109 @@
+package stackerr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MainOption configures Main.
+type MainOption func(*mainOptions)
+
+type mainOptions struct {
+	json bool
+}
+
+// MainJSON makes Main print the error's LogReport as a line of JSON instead of printing it with %+v.
+func MainJSON() MainOption {
+	return func(o *mainOptions) {
+		o.json = true
+	}
+}
+
+// signalError is the cause of the cancellation of the context passed to the function run by Main, when the program
+// receives a signal.
+type signalError struct {
+	sig os.Signal
+}
+
+func (se signalError) Error() string {
+	return "received signal: " + se.sig.String()
+}
+
+// Main runs a program's top-level function and exits the program when the function returns. The function is passed
+// a context that is canceled when the program receives an interrupt or termination signal. If the function panics,
+// the panic is recovered and turned into an error with FromRecovered.
+//
+// When the function returns nil, Main exits with code 0. Otherwise, it prints the error to standard error with %+v,
+// or as JSON with the MainJSON option, and exits with the code attached to the error with WithCode if it is between
+// 1 and 255. Without one, Main exits with 2 if the function panicked, with 128 plus the signal number if the function
+// returned after the context was canceled by a signal, and with 1 otherwise. A second signal isn't caught, so it
+// stops the program even if the function doesn't return. Under WebAssembly and TinyGo, where there are no signals,
+// the context is only canceled when Main returns.
+//
+//	func main() {
+//		stackerr.Main(run)
+//	}
+func Main(run func(ctx context.Context) error, opts ...MainOption) {
+	o := &mainOptions{}
+	for _, v := range opts {
+		v(o)
+	}
+	os.Exit(runMain(run, os.Stderr, o))
+}
+
+// runMain implements Main, writing the error to w and returning the exit code.
+func runMain(run func(ctx context.Context) error, w io.Writer, o *mainOptions) int {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	sigs := make(chan os.Signal, 1)
+	notifySignals(sigs)
+	defer stopSignals(sigs)
+	go func() {
+		select {
+		case s := <-sigs:
+			// only the first signal cancels the context; a second one stops the program, even if run ignores ctx.
+			stopSignals(sigs)
+			cancel(signalError{sig: s})
+		case <-ctx.Done():
+		}
+	}()
+
+	err, panicked := callMain(ctx, run)
+	if err == nil {
+		return 0
+	}
+	if o.json {
+		data, _ := json.Marshal(Report(err))
+		fmt.Fprintf(w, "%s\n", data)
+	} else {
+		fmt.Fprintf(w, "%+v\n", err)
+	}
+	if code, ok := Code(err); ok && code > 0 && code < 256 {
+		return code
+	}
+	if panicked {
+		return 2
+	}
+	var se signalError
+	if errors.As(context.Cause(ctx), &se) {
+		if n, ok := signalNumber(se.sig); ok {
+			return 128 + n
+		}
+	}
+	return 1
+}
+
+// callMain calls run, converting a panic into an error.
+func callMain(ctx context.Context, run func(ctx context.Context) error) (err error, panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = FromRecovered(v)
+			panicked = true
+		}
+	}()
+	return run(ctx), false
+}
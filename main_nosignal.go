@@ -0,0 +1,15 @@
+//go:build js || wasip1 || tinygo
+
+package stackerr
+
+import "os"
+
+// notifySignals does nothing, since a program running under WebAssembly or TinyGo can't be sent signals. The context
+// passed to the function run by Main is only canceled when Main returns.
+func notifySignals(chan<- os.Signal) {}
+
+func stopSignals(chan<- os.Signal) {}
+
+func signalNumber(os.Signal) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,24 @@
+//go:build !js && !wasip1 && !tinygo
+
+package stackerr
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifySignals relays the signals that cancel the context passed to the function run by Main to sigs.
+func notifySignals(sigs chan<- os.Signal) {
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+}
+
+func stopSignals(sigs chan<- os.Signal) {
+	signal.Stop(sigs)
+}
+
+// signalNumber returns the number of a signal, for the exit code.
+func signalNumber(sig os.Signal) (int, bool) {
+	s, ok := sig.(syscall.Signal)
+	return int(s), ok
+}
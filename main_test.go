@@ -0,0 +1,130 @@
+package stackerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// mainRuns are the functions run by TestMainProcess, by name.
+var mainRuns = map[string]func(ctx context.Context) error{
+	"ok": func(ctx context.Context) error {
+		return nil
+	},
+	"error": func(ctx context.Context) error {
+		return stackerr.New("failed")
+	},
+	"code": func(ctx context.Context) error {
+		return stackerr.WithCode(stackerr.New("bad config"), 78)
+	},
+	"panic": func(ctx context.Context) error {
+		panic("oops")
+	},
+	"signal": func(ctx context.Context) error {
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return stackerr.Wrap(err)
+		}
+		p.Signal(syscall.SIGTERM) // nolint: errcheck
+		<-ctx.Done()
+		return stackerr.Wrap(ctx.Err())
+	},
+	"second signal": func(ctx context.Context) error {
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return stackerr.Wrap(err)
+		}
+		p.Signal(syscall.SIGTERM) // nolint: errcheck
+		<-ctx.Done()
+		// ignore the cancellation, like a program that doesn't check ctx; the second signal should stop it.
+		p.Signal(syscall.SIGTERM) // nolint: errcheck
+		time.Sleep(10 * time.Second)
+		return stackerr.Wrap(ctx.Err())
+	},
+}
+
+// TestMainProcess runs stackerr.Main in a child process started by runMainProcess.
+func TestMainProcess(t *testing.T) {
+	name := os.Getenv("STACKERR_MAIN")
+	if name == "" {
+		t.Skip("only run as a child process")
+	}
+	var opts []stackerr.MainOption
+	if os.Getenv("STACKERR_MAIN_JSON") != "" {
+		opts = append(opts, stackerr.MainJSON())
+	}
+	stackerr.Main(mainRuns[name], opts...)
+}
+
+func runMainProcess(t *testing.T, name string, json bool) (int, string) {
+	t.Helper()
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skip("subprocesses are not supported on " + runtime.GOOS)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainProcess$")
+	cmd.Env = append(os.Environ(), "STACKERR_MAIN="+name)
+	if json {
+		cmd.Env = append(cmd.Env, "STACKERR_MAIN_JSON=1")
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return cmd.ProcessState.ExitCode(), stderr.String()
+}
+
+func TestMainExitCodes(t *testing.T) {
+	data := []struct {
+		name   string
+		code   int
+		output string
+	}{
+		{"ok", 0, ""},
+		{"error", 1, "failed\ngithub.com/jonbodner/stackerr_test.init.func"},
+		{"code", 78, "bad config\n"},
+		{"panic", 2, "panic: oops\n"},
+		{"signal", 128 + int(syscall.SIGTERM), "context canceled\n"},
+		// the process is killed by the second signal, so it has no exit code.
+		{"second signal", -1, ""},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			if strings.HasSuffix(v.name, "signal") && runtime.GOOS == "windows" {
+				t.Skip("signals are not supported on windows")
+			}
+			code, output := runMainProcess(t, v.name, false)
+			if code != v.code {
+				t.Errorf("expected exit code %d, got %d", v.code, code)
+			}
+			if !strings.HasPrefix(output, v.output) {
+				t.Errorf("expected output starting with %q, got %q", v.output, output)
+			}
+		})
+	}
+}
+
+func TestMainJSON(t *testing.T) {
+	code, output := runMainProcess(t, "code", true)
+	if code != 78 {
+		t.Errorf("expected exit code 78, got %d", code)
+	}
+	var r stackerr.LogReport
+	if err := json.Unmarshal([]byte(output), &r); err != nil {
+		t.Fatalf("expected a line of JSON, got %q", output)
+	}
+	if r.Message != "bad config" || r.Code != 78 || len(r.Frames) == 0 {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
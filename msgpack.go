@@ -0,0 +1,393 @@
+package stackerr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// EncodeMsgpack encodes an error as a MessagePack map with the message, chain, code, severity, and frames from its
+// LogReport, along with its category, operation names, request ID, trace and span IDs, and context values, which are
+// left out when they are empty. Use DecodeMsgpack to turn the result back into an error.
+func EncodeMsgpack(err error) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	r := Report(err)
+	var category string
+	if r.Category != 0 {
+		category = r.Category.String()
+	}
+	optional := []struct{ key, value string }{
+		{"category", category},
+		{"request_id", r.RequestID},
+		{"trace_id", r.TraceID},
+		{"span_id", r.SpanID},
+	}
+	keys := 5
+	for _, v := range optional {
+		if v.value != "" {
+			keys++
+		}
+	}
+	if len(r.Context) > 0 {
+		keys++
+	}
+	if len(r.Ops) > 0 {
+		keys++
+	}
+	var e msgpackEncoder
+	e.mapHeader(keys)
+	e.str("message")
+	e.str(r.Message)
+	e.str("chain")
+	e.arrayHeader(len(r.Chain))
+	for _, v := range r.Chain {
+		e.str(v)
+	}
+	e.str("code")
+	e.int(int64(r.Code))
+	e.str("severity")
+	e.str(r.Severity.String())
+	e.str("frames")
+	e.arrayHeader(len(r.Frames))
+	for _, v := range r.Frames {
+		e.mapHeader(4)
+		e.str("function")
+		e.str(v.Function)
+		e.str("file")
+		e.str(v.File)
+		e.str("line")
+		e.int(int64(v.Line))
+		e.str("kind")
+		e.str(string(v.Kind))
+	}
+	for _, v := range optional {
+		if v.value != "" {
+			e.str(v.key)
+			e.str(v.value)
+		}
+	}
+	if len(r.Context) > 0 {
+		e.str("context")
+		e.mapHeader(len(r.Context))
+		for _, k := range sortedContextValues(r.Context) {
+			e.str(k)
+			e.str(r.Context[k])
+		}
+	}
+	if len(r.Ops) > 0 {
+		e.str("ops")
+		e.arrayHeader(len(r.Ops))
+		for _, v := range r.Ops {
+			e.str(v)
+		}
+	}
+	return e.buf, nil
+}
+
+// MarshalMsgpack encodes the errorStack with EncodeMsgpack. This method lets MessagePack libraries that look for a
+// MarshalMsgpack method encode errors with stack traces.
+func (e *errorStack) MarshalMsgpack() ([]byte, error) {
+	return EncodeMsgpack(e)
+}
+
+// DecodeMsgpack decodes an error encoded by EncodeMsgpack. The decoded error has the original message, chain, code,
+// severity, category, operation names, frames, request ID, trace and span IDs, and context values. Trace, Frames, and
+// %+v use the decoded frames. Unknown keys are ignored, and data with arrays and maps nested more than 64 deep is
+// rejected.
+func DecodeMsgpack(data []byte) (error, error) {
+	d := msgpackDecoder{buf: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("stackerr: msgpack data is not a map")
+	}
+	var r LogReport
+	r.Message, _ = m["message"].(string)
+	chain, _ := m["chain"].([]interface{})
+	for _, c := range chain {
+		if s, ok := c.(string); ok {
+			r.Chain = append(r.Chain, s)
+		}
+	}
+	if code, ok := m["code"].(int64); ok {
+		r.Code = int(code)
+	}
+	if s, ok := m["severity"].(string); ok {
+		if err := r.Severity.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+	}
+	if s, ok := m["category"].(string); ok {
+		if err := r.Category.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+	}
+	ops, _ := m["ops"].([]interface{})
+	for _, v := range ops {
+		if s, ok := v.(string); ok {
+			r.Ops = append(r.Ops, s)
+		}
+	}
+	r.RequestID, _ = m["request_id"].(string)
+	r.TraceID, _ = m["trace_id"].(string)
+	r.SpanID, _ = m["span_id"].(string)
+	if values, ok := m["context"].(map[string]interface{}); ok {
+		r.Context = make(map[string]string, len(values))
+		for k, v := range values {
+			r.Context[k], _ = v.(string)
+		}
+	}
+	frames, _ := m["frames"].([]interface{})
+	r.Frames = make([]Frame, 0, len(frames))
+	for _, f := range frames {
+		fm, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("stackerr: msgpack frame is not a map")
+		}
+		var frame Frame
+		frame.Function, _ = fm["function"].(string)
+		frame.File, _ = fm["file"].(string)
+		if line, ok := fm["line"].(int64); ok {
+			frame.Line = int(line)
+		}
+		kind, _ := fm["kind"].(string)
+		frame.Kind = FrameKind(kind)
+		r.Frames = append(r.Frames, frame)
+	}
+	return FromReport(r), nil
+}
+
+// msgpackEncoder writes the subset of MessagePack needed to encode errors.
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) mapHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xde)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdf)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) arrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xdc)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdd)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) str(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) int(v int64) {
+	switch {
+	case v >= 0 && v < 128:
+		e.buf = append(e.buf, byte(v))
+	case v < 0 && v >= -32:
+		e.buf = append(e.buf, byte(v))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+	}
+}
+
+// msgpackDecoder reads MessagePack values into generic Go values: maps with string keys become
+// map[string]interface{}, arrays become []interface{}, integers become int64, and strings become string. Other types
+// are decoded so they can be skipped.
+type msgpackDecoder struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+// msgpackMaxDepth is the deepest that arrays and maps can be nested in data passed to DecodeMsgpack, so crafted data
+// can't use up the stack of the decoding goroutine. The data written by EncodeMsgpack is nested three deep.
+const msgpackMaxDepth = 64
+
+var (
+	errMsgpackShort = errors.New("stackerr: msgpack data is truncated")
+	errMsgpackDeep  = errors.New("stackerr: msgpack data is nested too deeply")
+)
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errMsgpackShort
+	}
+	out := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return out, nil
+}
+
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) value() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapValue(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.arrayValue(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.strValue(int(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		return int64(v), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		v, err := d.uint(size)
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, err
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.strValue(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.next(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayValue(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// fixext: a type byte followed by 1, 2, 4, 8, or 16 bytes of data.
+		return d.next(1 + 1<<(c-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.next(1 + int(n))
+	}
+	return nil, fmt.Errorf("stackerr: unsupported msgpack type 0x%02x", c)
+}
+
+func (d *msgpackDecoder) strValue(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) arrayValue(n int) (interface{}, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errMsgpackShort
+	}
+	if d.depth == msgpackMaxDepth {
+		return nil, errMsgpackDeep
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	out := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (d *msgpackDecoder) mapValue(n int) (interface{}, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errMsgpackShort
+	}
+	if d.depth == msgpackMaxDepth {
+		return nil, errMsgpackDeep
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	out := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if ks, ok := k.(string); ok {
+			out[ks] = v
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	inner := stackerr.New("inner")
+	err := stackerr.WithSeverity(stackerr.WithCode(fmt.Errorf("outer: %w", inner), 42), stackerr.SeverityCritical)
+	data, encErr := stackerr.EncodeMsgpack(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	decoded, decErr := stackerr.DecodeMsgpack(data)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if decoded.Error() != err.Error() {
+		t.Errorf("expected message %q, got %q", err.Error(), decoded.Error())
+	}
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	if !stackerr.HasStack(decoded) {
+		t.Error("expected decoded error to have a stack")
+	}
+	expected, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	got, _ := stackerr.Trace(decoded, stackerr.StandardFormat)
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff("outer: inner\n"+strings.Join(expected, "\n"), fmt.Sprintf("%+v", decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	plain, _ := stackerr.EncodeMsgpack(errors.New("plain"))
+	decoded, decErr = stackerr.DecodeMsgpack(plain)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if decoded.Error() != "plain" {
+		t.Errorf("expected plain, got %q", decoded.Error())
+	}
+	if len(stackerr.Frames(decoded)) != 0 {
+		t.Error("expected no frames")
+	}
+}
+
+func TestMsgpackMetadata(t *testing.T) {
+	err := metadataError(t)
+	data, encErr := stackerr.EncodeMsgpack(err)
+	if encErr != nil {
+		t.Fatal(encErr)
+	}
+	decoded, decErr := stackerr.DecodeMsgpack(data)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	checkMetadata(t, err, decoded)
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	err := stackerr.New("boom")
+	m, ok := err.(interface{ MarshalMsgpack() ([]byte, error) })
+	if !ok {
+		t.Fatal("expected MarshalMsgpack method")
+	}
+	data, mErr := m.MarshalMsgpack()
+	if mErr != nil {
+		t.Fatal(mErr)
+	}
+	expected, _ := stackerr.EncodeMsgpack(err)
+	if diff := cmp.Diff(expected, data); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDecodeMsgpackErrors(t *testing.T) {
+	if _, err := stackerr.EncodeMsgpack(nil); err == nil {
+		t.Error("expected error encoding nil")
+	}
+	data, _ := stackerr.EncodeMsgpack(stackerr.New("boom"))
+	inputs := map[string][]byte{
+		"empty":     nil,
+		"truncated": data[:len(data)/2],
+		"not a map": {0x93, 0x01, 0x02, 0x03},
+		"bad type":  {0xc1},
+		// a map with an unknown key whose value is 100 nested arrays.
+		"too deep": append(append([]byte{0x81, 0xa1, 'x'}, bytes.Repeat([]byte{0x91}, 100)...), 0xc0),
+	}
+	for name, in := range inputs {
+		if _, err := stackerr.DecodeMsgpack(in); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// unknown keys are skipped
+	withExtra := append([]byte{0x82, 0xa5, 'e', 'x', 't', 'r', 'a', 0xc3, 0xa7}, "message"...)
+	withExtra = append(withExtra, 0xa2, 'h', 'i')
+	decoded, err := stackerr.DecodeMsgpack(withExtra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Error() != "hi" {
+		t.Errorf("expected hi, got %q", decoded.Error())
+	}
+}
@@ -0,0 +1,132 @@
+package stackerr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// multiUnwrap returns the errors wrapped by an error that wraps more than one: from its WrappedErrors() []error
+// method, like *multierror.Error from github.com/hashicorp/go-multierror, its Unwrap() []error method, or, like the
+// errors combined by go.uber.org/multierr, its Errors() []error method. It returns nil for other errors.
+func multiUnwrap(err error) []error {
+	switch u := err.(type) {
+	case interface{ WrappedErrors() []error }:
+		return u.WrappedErrors()
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	case interface{ Errors() []error }:
+		return u.Errors()
+	}
+	return nil
+}
+
+// groupErrors returns the errors in a group of errors with an Errors() []error method, like the ones combined by
+// go.uber.org/multierr and by AppendWithStack. It returns nil for other errors.
+func groupErrors(err error) []error {
+	if g, ok := err.(interface{ Errors() []error }); ok {
+		return g.Errors()
+	}
+	return nil
+}
+
+// multiError is the error returned by AppendWithStack.
+type multiError struct {
+	errs []error
+}
+
+// Error returns the messages for all of the errors, separated by semicolons, like go.uber.org/multierr.
+func (me *multiError) Error() string {
+	msgs := make([]string, len(me.errs))
+	for i, v := range me.errs {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Errors returns a copy of the errors, so multierr.Errors returns them.
+func (me *multiError) Errors() []error {
+	return append([]error(nil), me.errs...)
+}
+
+// Unwrap returns the errors, so errors.Is and errors.As check each of them.
+func (me *multiError) Unwrap() []error {
+	return me.errs
+}
+
+// Format outputs each error formatted with %+v, separated by blank lines, for %+v. Other verbs output the result of
+// Error.
+func (me *multiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			traces := make([]string, len(me.errs))
+			for i, v := range me.errs {
+				traces[i] = fmt.Sprintf("%+v", v)
+			}
+			io.WriteString(s, strings.Join(traces, "\n\n")) // nolint: errcheck
+			return
+		}
+		io.WriteString(s, me.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, me.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", me.Error())
+	}
+}
+
+// AppendWithStack combines two errors, like multierr.Append from go.uber.org/multierr, and makes sure that every error
+// it adds has a stack trace: an error from right without one is wrapped with Wrap, so the stack trace points at the
+// call to AppendWithStack. If either error is a group of errors with an Errors() []error method, such as one returned
+// by multierr.Combine or by an earlier call to AppendWithStack, its errors are added one by one, like multierr.Append
+// does. Other errors that wrap several, such as ones from errors.Join or from fmt.Errorf with more than one %w, are
+// added as one error, so their message is kept. If right is nil, left is returned as is, and if left is nil, the result
+// is right with its stack trace.
+//
+// The error returned for two errors has an Errors() []error method, so multierr.Errors splits it, and an
+// Unwrap() []error method, so errors.Is, errors.As, and Flatten check each error. Trace, HasStack, and Flatten also
+// look inside errors that only have an Errors() []error method, like the ones from older releases of multierr.
+func AppendWithStack(left, right error) error {
+	if right == nil {
+		return left
+	}
+	added := []error{right}
+	if errs := groupErrors(right); len(errs) > 0 {
+		added = append([]error(nil), errs...)
+	}
+	for i, v := range added {
+		added[i] = wrap(v, 1)
+	}
+	if left == nil && len(added) == 1 {
+		return added[0]
+	}
+	var errs []error
+	if left != nil {
+		errs = append(errs, left)
+		if members := groupErrors(left); len(members) > 0 {
+			errs = append([]error(nil), members...)
+		}
+	}
+	return &multiError{errs: append(errs, added...)}
+}
+
+// StackListFormat formats a list of errors like multierror.ListFormatFunc from github.com/hashicorp/go-multierror,
+// with the stack trace of each error under its message. Assign it to the ErrorFormat field of a *multierror.Error, so
+// its message has the stack trace of every error it holds, instead of only the stack trace of the error that wraps it:
+//
+//	merr.ErrorFormat = stackerr.StackListFormat
+//
+// The stack traces use the options passed to SetFormatOptions, like %+v. Because they are part of the message, Chain
+// and the Message and Chain of a LogReport hold them too, for the *multierror.Error and for every error that wraps it
+// with %w, and tools that group errors by message see a different, much longer message for each code path. Fingerprint
+// and the deduplication in this package only look at frames, so they aren't affected.
+func StackListFormat(es []error) string {
+	points := make([]string, len(es))
+	for i, v := range es {
+		points[i] = "* " + strings.ReplaceAll(fmt.Sprintf("%+v", v), "\n", "\n\t\t")
+	}
+	if len(es) == 1 {
+		return fmt.Sprintf("1 error occurred:\n\t%s\n\n", points[0])
+	}
+	return fmt.Sprintf("%d errors occurred:\n\t%s\n\n", len(es), strings.Join(points, "\n\t"))
+}
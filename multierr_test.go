@@ -0,0 +1,217 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// errorGroup is like the error returned by older releases of go.uber.org/multierr, which only have an Errors method.
+type errorGroup []error
+
+func (eg errorGroup) Error() string {
+	msgs := make([]string, len(eg))
+	for i, v := range eg {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (eg errorGroup) Errors() []error {
+	return eg
+}
+
+func TestErrorsMethod(t *testing.T) {
+	stacked := stackerr.New("stacked")
+	plain := errors.New("plain")
+	group := errorGroup{plain, stacked}
+
+	if !stackerr.HasStack(group) {
+		t.Error("expected HasStack to look inside the group")
+	}
+	lines, err := stackerr.Trace(group, stackerr.StandardFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(traceLines(t, stacked), lines); diff != "" {
+		t.Error(diff)
+	}
+	leaves := stackerr.Flatten(group)
+	if len(leaves) != 2 || leaves[0].Err != plain || leaves[1].Stack != stacked {
+		t.Errorf("unexpected leaves %+v", leaves)
+	}
+	if diff := cmp.Diff([]string{"plain; stacked", "plain", "stacked"}, stackerr.Chain(group)); diff != "" {
+		t.Error(diff)
+	}
+
+	// the accessors find the same stack trace as Trace.
+	if diff := cmp.Diff(stackerr.Frames(stacked), stackerr.Frames(group)); diff != "" {
+		t.Error(diff)
+	}
+	if fp := stackerr.Fingerprint(group); fp == "" || fp != stackerr.Fingerprint(stacked) {
+		t.Errorf("expected the fingerprint of the stacked error, got %q", fp)
+	}
+	if origin, ok := stackerr.Origin(group); !ok || origin != stackerr.Frames(stacked)[0] {
+		t.Errorf("unexpected origin %+v", origin)
+	}
+	if diff := cmp.Diff(stackerr.Frames(stacked), stackerr.Report(group).Frames); diff != "" {
+		t.Error(diff)
+	}
+	if out := stackerr.FormatTrace(group); out != stackerr.FormatTrace(stacked) {
+		t.Errorf("unexpected trace %q", out)
+	}
+	if line := stackerr.EncodeLogfmt(group); !strings.Contains(line, " origin=") {
+		t.Errorf("expected the origin in `%s`", line)
+	}
+	var logs bytes.Buffer
+	if _, ok := stackerr.WrapAndLog(slog.New(slog.NewJSONHandler(&logs, nil)), group, "failed").(errorGroup); !ok {
+		t.Error("expected WrapAndLog to return the group as is")
+	}
+	if !strings.Contains(logs.String(), `"fingerprint":"`+stackerr.Fingerprint(stacked)+`"`) {
+		t.Errorf("expected the fingerprint in the logs, got %s", logs.String())
+	}
+}
+
+func TestAppendWithStack(t *testing.T) {
+	first := errors.New("first")
+	second := errors.New("second")
+	stacked := stackerr.New("stacked")
+
+	if stackerr.AppendWithStack(nil, nil) != nil {
+		t.Error("expected nil for two nil errors")
+	}
+	if stackerr.AppendWithStack(first, nil) != first {
+		t.Error("expected left for a nil right")
+	}
+	single := stackerr.AppendWithStack(nil, first)
+	if top := topFrame(t, single); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestAppendWithStack ") {
+		t.Errorf("expected the caller on top, got %s", top)
+	}
+
+	var err error
+	for _, v := range []error{first, stacked, second} {
+		err = stackerr.AppendWithStack(err, v)
+	}
+	if err.Error() != "first; stacked; second" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	errs := err.(interface{ Errors() []error }).Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d", len(errs))
+	}
+	if errs[1] != stacked {
+		t.Error("expected an error with a stack trace to be added as is")
+	}
+	for _, v := range errs {
+		if top := topFrame(t, v); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestAppendWithStack ") {
+			t.Errorf("expected the caller on top, got %s", top)
+		}
+	}
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Error("expected errors.Is to find every error")
+	}
+
+	// the errors of a group passed as right are added one by one, each with a stack trace.
+	combined := stackerr.AppendWithStack(errorGroup{first}, errorGroup{second, stacked})
+	errs = combined.(interface{ Errors() []error }).Errors()
+	if len(errs) != 3 || errs[0] != first || !stackerr.HasStack(errs[1]) || errs[2] != stacked {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if out := fmt.Sprintf("%+v", combined); !strings.Contains(out, "first\n\nsecond\n") || !strings.Contains(out, "\n\nstacked\n") {
+		t.Errorf("expected each error with its stack trace, got\n%s", out)
+	}
+
+	// other errors that wrap several are added as one, keeping their message.
+	wrapsTwo := fmt.Errorf("a: %w, b: %w", first, second)
+	single = stackerr.AppendWithStack(nil, wrapsTwo)
+	if single.Error() != "a: first, b: second" || !errors.Is(single, wrapsTwo) || !stackerr.HasStack(single) {
+		t.Errorf("expected the error with a stack trace, got %v", single)
+	}
+	joined := stackerr.AppendWithStack(wrapsTwo, errors.Join(first, second))
+	if errs = joined.(interface{ Errors() []error }).Errors(); len(errs) != 2 || errs[0] != wrapsTwo {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+// multierrorError is like *multierror.Error from github.com/hashicorp/go-multierror, whose Unwrap method returns a
+// chain that steps from one error to the next.
+type multierrorError struct {
+	Errors []error
+}
+
+func (me *multierrorError) Error() string {
+	return stackerr.StackListFormat(me.Errors)
+}
+
+func (me *multierrorError) WrappedErrors() []error {
+	return me.Errors
+}
+
+func (me *multierrorError) Unwrap() error {
+	if len(me.Errors) == 0 {
+		return nil
+	}
+	return multierrorChain(me.Errors)
+}
+
+type multierrorChain []error
+
+func (c multierrorChain) Error() string {
+	return c[0].Error()
+}
+
+func (c multierrorChain) Unwrap() error {
+	if len(c) == 1 {
+		return nil
+	}
+	return c[1:]
+}
+
+func (c multierrorChain) Is(target error) bool {
+	return errors.Is(c[0], target)
+}
+
+func TestWrappedErrors(t *testing.T) {
+	plain := errors.New("plain")
+	stacked := stackerr.Errorf("load: %w", stackerr.New("missing"))
+	merr := &multierrorError{Errors: []error{plain, stacked}}
+
+	leaves := stackerr.Flatten(merr)
+	if len(leaves) != 2 || leaves[0].Err != plain || leaves[0].Stack != nil || leaves[1].Stack == nil {
+		t.Fatalf("unexpected leaves %+v", leaves)
+	}
+	if diff := cmp.Diff(traceLines(t, stacked), traceLines(t, leaves[1].Stack)); diff != "" {
+		t.Error(diff)
+	}
+	if !stackerr.HasStack(merr) {
+		t.Error("expected HasStack to look inside the error")
+	}
+	if diff := cmp.Diff(traceLines(t, stacked), traceLines(t, merr)); diff != "" {
+		t.Error(diff)
+	}
+	if len(stackerr.StackedErrors(merr)) != 1 {
+		t.Errorf("expected one stacked error, got %v", stackerr.StackedErrors(merr))
+	}
+}
+
+func TestStackListFormat(t *testing.T) {
+	plain := errors.New("plain")
+	stacked := stackerr.New("stacked")
+	lines := traceLines(t, stacked)
+
+	expected := "1 error occurred:\n\t* plain\n\n"
+	if diff := cmp.Diff(expected, stackerr.StackListFormat([]error{plain})); diff != "" {
+		t.Error(diff)
+	}
+	expected = "2 errors occurred:\n\t* plain\n\t* stacked\n\t\t" + strings.Join(lines, "\n\t\t") + "\n\n"
+	if diff := cmp.Diff(expected, stackerr.StackListFormat([]error{plain, stacked})); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,39 @@
+package stackerr
+
+import "strings"
+
+// WithOp attaches the name of the logical operation that failed, such as "users.Get", to an error. Unlike function
+// names in a stack trace, operation names are chosen by the program, so they stay the same when code is moved or
+// renamed. Attach one at each layer that adds meaning, and use Ops or Summary to read the path. The error's message
+// is unchanged. If there is no stack trace in the unwrap chain for err, one is captured. WithOp returns nil when a nil
+// error is passed in.
+func WithOp(err error, op string) error {
+	return annotate(err, opKey, op, 1)
+}
+
+// Ops returns the operation names attached with WithOp in the unwrap tree for the error, outermost first, in the same
+// order that errors.Is checks them. It returns nil if there are none.
+func Ops(err error) []string {
+	var out []string
+	walk(err, func(e error) bool {
+		if a, ok := e.(annotated); ok && a.key == opKey {
+			out = append(out, a.value.(string))
+		}
+		return true
+	})
+	return out
+}
+
+// Summary returns the error's message preceded by its operation path, with each name followed by ": ", such as
+// "users.Get: db.query: connection refused". It returns the message by itself if there are no operation names, and an
+// empty string for a nil error.
+func Summary(err error) string {
+	if err == nil {
+		return ""
+	}
+	ops := Ops(err)
+	if len(ops) == 0 {
+		return err.Error()
+	}
+	return strings.Join(ops, ": ") + ": " + err.Error()
+}
@@ -0,0 +1,52 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestWithOp(t *testing.T) {
+	if stackerr.WithOp(nil, "users.Get") != nil {
+		t.Error("expected nil for a nil error")
+	}
+	if stackerr.Ops(errors.New("plain")) != nil {
+		t.Error("expected no operation names")
+	}
+
+	inner := stackerr.WithOp(errors.New("connection refused"), "db.query")
+	err := stackerr.WithOp(fmt.Errorf("load: %w", inner), "users.Get")
+	if diff := cmp.Diff([]string{"users.Get", "db.query"}, stackerr.Ops(err)); diff != "" {
+		t.Error(diff)
+	}
+	if msg := err.Error(); msg != "load: connection refused" {
+		t.Errorf("expected the message to be unchanged, got `%s`", msg)
+	}
+	if s := stackerr.Summary(err); s != "users.Get: db.query: load: connection refused" {
+		t.Errorf("unexpected summary `%s`", s)
+	}
+	if s := stackerr.Summary(errors.New("plain")); s != "plain" {
+		t.Errorf("unexpected summary `%s`", s)
+	}
+	if stackerr.Summary(nil) != "" {
+		t.Error("expected an empty summary for a nil error")
+	}
+
+	if diff := cmp.Diff([]string{"users.Get", "db.query"}, stackerr.Report(err).Ops); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"users.Get", "db.query"}, stackerr.Ops(stackerr.FromReport(stackerr.Report(err)))); diff != "" {
+		t.Error(diff)
+	}
+	if out := stackerr.EncodeLogfmt(err); !strings.Contains(out, ` op="users.Get: db.query"`) {
+		t.Errorf("expected the operation names in %s", out)
+	}
+	if out := stackerr.EncodeYAML(err); !strings.Contains(out, "ops:\n  - users.Get\n  - db.query\n") {
+		t.Errorf("expected the operation names in %s", out)
+	}
+}
@@ -0,0 +1,236 @@
+package stackerr
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Option configures how a stack trace is rendered. Options are passed to Trace, or to SetFormatOptions to control
+// the output of %+v.
+type Option func(*renderOptions)
+
+type renderOptions struct {
+	modules        []string
+	collapseStdlib bool
+	outermostFirst bool
+	addresses      bool
+	formatter      TraceFormatter
+	layout         layout
+	// maxFrames limits the number of rendered frames when limitFrames is set, such as by the precision of %+.5v.
+	maxFrames   int
+	limitFrames bool
+	// framePrefix, separator, and trailingNewline only change the output of %+v.
+	framePrefix     string
+	separator       string
+	trailingNewline bool
+}
+
+func newRenderOptions(opts []Option) *renderOptions {
+	o := &renderOptions{separator: "\n"}
+	for _, v := range opts {
+		v(o)
+	}
+	return o
+}
+
+// OnlyModules restricts the rendered frames to functions whose fully-qualified names start with one of the
+// prefixes, such as "github.com/ourco/". A final line reports how many frames were left out.
+func OnlyModules(prefixes ...string) Option {
+	return func(o *renderOptions) {
+		o.modules = append(o.modules, prefixes...)
+	}
+}
+
+// CollapseStdlib replaces each run of two or more consecutive standard library or runtime frames with a single line
+// that reports how many frames were in the run, such as "[3 stdlib frames]".
+func CollapseStdlib() Option {
+	return func(o *renderOptions) {
+		o.collapseStdlib = true
+	}
+}
+
+// OutermostFirst renders the frames in the opposite order, with the outermost frame (the goroutine's entry point)
+// first and the frame where the stack trace was captured last.
+func OutermostFirst() Option {
+	return func(o *renderOptions) {
+		o.outermostFirst = true
+	}
+}
+
+// ShowAddresses adds the program counter and the entry address of the function to each rendered frame, such as
+// " pc=0x4a5b3c entry=0x4a5b00". Frames decoded from another process have no addresses and are rendered as usual.
+func ShowAddresses() Option {
+	return func(o *renderOptions) {
+		o.addresses = true
+	}
+}
+
+// FramePrefix sets a prefix for each line of the stack trace printed by %+v, such as "\t" or "    at ", so that log
+// processors that group indented continuation lines keep the stack trace with its message. Pass it to
+// SetFormatOptions; Trace ignores it.
+func FramePrefix(prefix string) Option {
+	return func(o *renderOptions) {
+		o.framePrefix = prefix
+	}
+}
+
+// MessageSeparator sets what %+v prints between an error's message and its stack trace, instead of a newline. Pass
+// it to SetFormatOptions; Trace ignores it.
+func MessageSeparator(sep string) Option {
+	return func(o *renderOptions) {
+		o.separator = sep
+	}
+}
+
+// TrailingNewline makes %+v end with a newline after the last line of the stack trace. Pass it to SetFormatOptions;
+// Trace ignores it.
+func TrailingNewline() Option {
+	return func(o *renderOptions) {
+		o.trailingNewline = true
+	}
+}
+
+// joinFrames joins the lines of a stack trace for %+v, adding the frame prefix to each one.
+func (o *renderOptions) joinFrames(lines []string) string {
+	if o.framePrefix != "" {
+		prefixed := make([]string, len(lines))
+		for i, v := range lines {
+			prefixed[i] = o.framePrefix + v
+		}
+		lines = prefixed
+	}
+	if o.formatter != nil {
+		return o.formatter.Join(lines)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var formatOptions atomic.Value
+
+// SetFormatOptions sets the options used when an error's stack trace is rendered with %+v. Each call replaces the
+// options from the previous call; call SetFormatOptions with no options to go back to the default output.
+func SetFormatOptions(opts ...Option) {
+	formatOptions.Store(newRenderOptions(opts))
+}
+
+func currentFormatOptions() *renderOptions {
+	if o, ok := formatOptions.Load().(*renderOptions); ok {
+		return o
+	}
+	return &renderOptions{separator: "\n"}
+}
+
+// keep reports whether a frame passes the module filter.
+func (o *renderOptions) keep(frame runtime.Frame) bool {
+	if len(o.modules) == 0 {
+		return true
+	}
+	for _, v := range o.modules {
+		if strings.HasPrefix(frame.Function, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// render formats the frames with the template, or with the formatter from the options if there is one, applying the
+// options.
+func render(frames []runtime.Frame, t *frameTemplate, o *renderOptions) ([]string, error) {
+	kept := make([]runtime.Frame, 0, len(frames))
+	for _, frame := range frames {
+		if o.keep(frame) {
+			kept = append(kept, frame)
+		}
+	}
+	if o.limitFrames && len(kept) > o.maxFrames {
+		kept = kept[:o.maxFrames]
+	}
+	elided := len(frames) - len(kept)
+	if o.outermostFirst {
+		for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+			kept[i], kept[j] = kept[j], kept[i]
+		}
+	}
+	s := make([]string, 0, len(kept)+1)
+	b := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(b)
+	for i := 0; i < len(kept); i++ {
+		if o.collapseStdlib {
+			if run := stdlibRun(kept[i:]); run > 1 {
+				s = append(s, fmt.Sprintf("[%d stdlib frames]", run))
+				i += run - 1
+				continue
+			}
+		}
+		if isUnknownFrame(kept[i]) {
+			s = append(s, unknownFrameLine)
+			continue
+		}
+		b.Reset()
+		if o.formatter != nil {
+			b.WriteString(o.formatter.FormatFrame(toFrames(kept[i : i+1])[0]))
+		} else if err := executeFrame(b, t, kept[i]); err != nil {
+			return nil, Wrap(err)
+		}
+		if o.addresses && kept[i].PC != 0 {
+			fmt.Fprintf(b, " pc=%#x entry=%#x", kept[i].PC, kept[i].Entry)
+		}
+		s = append(s, b.String())
+	}
+	if elided > 0 {
+		s = append(s, elidedLine(elided))
+	}
+	return s, nil
+}
+
+// bufferPool holds the buffers that render builds each line in, so rendering a trace doesn't allocate a new one.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// unknownFrameLine is rendered in place of a frame without a function or file, since the template would only produce
+// " (:0)".
+const unknownFrameLine = "[cgo/unknown frame]"
+
+// stdlibRun returns the number of standard library frames at the start of frames.
+func stdlibRun(frames []runtime.Frame) int {
+	for i, v := range frames {
+		if classify(v) != FrameStdlib {
+			return i
+		}
+	}
+	return len(frames)
+}
+
+// renderStandard renders a frame in the layout of StandardFormat, "FUNCTION (FILE:LINE)", without executing a
+// template.
+func renderStandard(b *bytes.Buffer, frame runtime.Frame) {
+	b.WriteString(frame.Function)
+	b.WriteString(" (")
+	b.WriteString(frame.File)
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(frame.Line))
+	b.WriteByte(')')
+}
+
+// renderCompact renders a frame in the layout of the logfmt trace field, "FUNCTION:LINE", without executing a
+// template.
+func renderCompact(b *bytes.Buffer, frame runtime.Frame) {
+	b.WriteString(frame.Function)
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(frame.Line))
+}
+
+func elidedLine(n int) string {
+	if n == 1 {
+		return "[1 frame elided]"
+	}
+	return fmt.Sprintf("[%d frames elided]", n)
+}
@@ -0,0 +1,209 @@
+package stackerr_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestOnlyModules(t *testing.T) {
+	err := stackerr.New("filtered")
+	all := traceLines(t, err)
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat, stackerr.OnlyModules("github.com/jonbodner/"))
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected := []string{all[0], fmt.Sprintf("[%d frames elided]", len(all)-1)}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+
+	lines, traceErr = stackerr.Trace(err, stackerr.StandardFormat, stackerr.OnlyModules("github.com/jonbodner/", "testing."))
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected = []string{all[0], all[1], "[1 frame elided]"}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSetFormatOptions(t *testing.T) {
+	err := stackerr.New("formatted")
+	stackerr.SetFormatOptions(stackerr.OnlyModules("github.com/jonbodner/"))
+	result := fmt.Sprintf("%+v", err)
+	stackerr.SetFormatOptions()
+	lines := strings.Split(result, "\n")
+	if len(lines) != 3 || lines[0] != "formatted" || !strings.HasPrefix(lines[1], "github.com/jonbodner/stackerr_test.TestSetFormatOptions ") {
+		t.Errorf("unexpected output `%s`", result)
+	}
+	if !strings.HasSuffix(lines[2], "frames elided]") {
+		t.Errorf("expected elided frame count, got `%s`", lines[2])
+	}
+	if result = fmt.Sprintf("%+v", err); strings.Contains(result, "elided") {
+		t.Errorf("expected default output after resetting options, got `%s`", result)
+	}
+}
+
+func TestFramePrefix(t *testing.T) {
+	err := stackerr.New("indented")
+	all := traceLines(t, err)
+	data := []struct {
+		name     string
+		opts     []stackerr.Option
+		expected string
+	}{
+		{"default", nil, "indented\n" + strings.Join(all, "\n")},
+		{"prefix", []stackerr.Option{stackerr.FramePrefix("    at ")}, "indented\n    at " + strings.Join(all, "\n    at ")},
+		{"separator", []stackerr.Option{stackerr.MessageSeparator(":\n"), stackerr.FramePrefix("\t")}, "indented:\n\t" + strings.Join(all, "\n\t")},
+		{"trailing newline", []stackerr.Option{stackerr.TrailingNewline()}, "indented\n" + strings.Join(all, "\n") + "\n"},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			stackerr.SetFormatOptions(v.opts...)
+			defer stackerr.SetFormatOptions()
+			if diff := cmp.Diff(v.expected, fmt.Sprintf("%+v", err)); diff != "" {
+				t.Error(diff)
+			}
+			// Trace ignores the options for %+v.
+			lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat, v.opts...)
+			if traceErr != nil {
+				t.Fatal(traceErr)
+			}
+			if diff := cmp.Diff(all, lines); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCollapseStdlib(t *testing.T) {
+	// the test function is followed by testing.tRunner and runtime.goexit.
+	err := stackerr.New("collapsed")
+	all := traceLines(t, err)
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat, stackerr.CollapseStdlib())
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected := []string{all[0], fmt.Sprintf("[%d stdlib frames]", len(all)-1)}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+
+	// a single stdlib frame is left as-is.
+	lines, traceErr = stackerr.Trace(err, stackerr.StandardFormat, stackerr.CollapseStdlib(), stackerr.OnlyModules("github.com/jonbodner/", "runtime."))
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected = []string{all[0], all[len(all)-1], "[1 frame elided]"}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestOutermostFirst(t *testing.T) {
+	err := stackerr.New("reversed")
+	all := traceLines(t, err)
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat, stackerr.OutermostFirst())
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected := make([]string, len(all))
+	for i, v := range all {
+		expected[len(all)-1-i] = v
+	}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+
+	lines, traceErr = stackerr.Trace(err, stackerr.StandardFormat, stackerr.OutermostFirst(), stackerr.OnlyModules("github.com/jonbodner/", "testing."))
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	expected = []string{all[1], all[0], "[1 frame elided]"}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestShowAddresses(t *testing.T) {
+	err := stackerr.New("addresses")
+	all := traceLines(t, err)
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat, stackerr.ShowAddresses())
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if len(lines) != len(all) {
+		t.Fatalf("expected %d lines, got %d", len(all), len(lines))
+	}
+	pc := reflect.ValueOf(TestShowAddresses).Pointer()
+	expected := fmt.Sprintf(" entry=%#x", pc)
+	if !strings.HasPrefix(lines[0], all[0]+" pc=0x") || !strings.HasSuffix(lines[0], expected) {
+		t.Errorf("expected %q with addresses ending in %q, got %q", all[0], expected, lines[0])
+	}
+
+	decoded, _ := stackerr.DecodeJSON([]byte(`{"message":"remote","frames":[{"function":"main.main","file":"/main.go","line":1}]}`))
+	lines, _ = stackerr.Trace(decoded, stackerr.StandardFormat, stackerr.ShowAddresses())
+	if diff := cmp.Diff([]string{"main.main (/main.go:1)"}, lines); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUnknownFrames(t *testing.T) {
+	err := stackerr.NewFactory(stackerr.FixedStack(
+		stackerr.Frame{},
+		stackerr.Frame{Function: "main.main", File: "/main.go", Line: 1},
+	)).New("cgo")
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	if diff := cmp.Diff([]string{"[cgo/unknown frame]", "main.main (/main.go:1)"}, lines); diff != "" {
+		t.Error(diff)
+	}
+	lines, _ = stackerr.Trace(err, stackerr.StandardFormat, stackerr.CollapseStdlib())
+	if diff := cmp.Diff([]string{"[cgo/unknown frame]", "main.main (/main.go:1)"}, lines); diff != "" {
+		t.Error(diff)
+	}
+	if out := fmt.Sprintf("%+v", err); !strings.Contains(out, "[cgo/unknown frame]") || strings.Contains(out, " (:0)") {
+		t.Errorf("expected %%+v to render the unknown frame, got %q", out)
+	}
+}
+
+func TestFormatPrecision(t *testing.T) {
+	err := stackerr.New("brief")
+	all := traceLines(t, err)
+	if len(all) < 2 {
+		t.Fatalf("expected at least 2 frames, got %d", len(all))
+	}
+	elided := "[1 frame elided]"
+	if len(all) > 2 {
+		elided = fmt.Sprintf("[%d frames elided]", len(all)-1)
+	}
+	data := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{"all", "%+v", "brief\n" + strings.Join(all, "\n")},
+		{"top one", "%+.1v", "brief\n" + all[0] + "\n" + elided},
+		{"more than there are", fmt.Sprintf("%%+.%dv", len(all)+1), "brief\n" + strings.Join(all, "\n")},
+		{"annotated", "%+.1v", "outer: brief\n" + all[0] + "\n" + elided},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			target := err
+			if v.name == "annotated" {
+				target = stackerr.WithCode(stackerr.Errorf("outer: %w", err), 7)
+			}
+			if diff := cmp.Diff(v.expected, fmt.Sprintf(v.format, target)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package stackerr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// panicError holds a value that was recovered from a panic.
+type panicError struct {
+	value interface{}
+}
+
+// Error returns "panic: " followed by the recovered value.
+func (pe panicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.value)
+}
+
+// Unwrap exposes the recovered value if it is an error.
+func (pe panicError) Unwrap() error {
+	if err, ok := pe.value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// FromRecovered converts a value returned by recover into an error. Panic values are often strings or other
+// non-error values, so the value is kept and can be retrieved with PanicValue. If the value is an error with a stack
+// trace, that stack trace is kept. Otherwise a stack trace is captured; when FromRecovered is called from a deferred
+// function while panicking, the stack trace includes the code that panicked. FromRecovered returns nil when nil is
+// passed in.
+//
+//	defer func() {
+//		if err := stackerr.FromRecovered(recover()); err != nil {
+//			log.Printf("%+v", err)
+//		}
+//	}()
+func FromRecovered(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	return wrap(panicError{value: v}, 1)
+}
+
+// PanicValue returns the recovered value for an error created by FromRecovered. The second return value is false if
+// there is no recovered value in the unwrap chain for the error.
+func PanicValue(err error) (interface{}, bool) {
+	var pe panicError
+	if !errors.As(err, &pe) {
+		return nil, false
+	}
+	return pe.value, true
+}
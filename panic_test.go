@@ -0,0 +1,57 @@
+package stackerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func panicWith(v interface{}) (err error) {
+	defer func() {
+		err = stackerr.FromRecovered(recover())
+	}()
+	panic(v)
+}
+
+func TestFromRecovered(t *testing.T) {
+	if stackerr.FromRecovered(nil) != nil {
+		t.Error("Got non-nil for nil passed to FromRecovered")
+	}
+
+	err := panicWith("boom")
+	if err.Error() != "panic: boom" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if v, ok := stackerr.PanicValue(err); !ok || v != "boom" {
+		t.Errorf("expected panic value `boom`, got `%v`", v)
+	}
+	foundPanicker := false
+	for _, v := range stackerr.Frames(err) {
+		if v.Function == "github.com/jonbodner/stackerr_test.panicWith" {
+			foundPanicker = true
+		}
+	}
+	if !foundPanicker {
+		t.Error("expected the stack trace to include the function that panicked")
+	}
+
+	plain := errors.New("plain")
+	err = panicWith(plain)
+	if !errors.Is(err, plain) || !stackerr.HasStack(err) {
+		t.Error("expected a recovered error to be wrapped with a stack trace")
+	}
+
+	stacked := stackerr.New("stacked")
+	err = panicWith(stacked)
+	if stackerr.Fingerprint(err) != stackerr.Fingerprint(stacked) {
+		t.Error("expected a recovered error's stack trace to be kept")
+	}
+	if v, _ := stackerr.PanicValue(err); v != stacked {
+		t.Errorf("expected panic value to be the recovered error, got `%v`", v)
+	}
+
+	if _, ok := stackerr.PanicValue(plain); ok {
+		t.Error("didn't expect a panic value")
+	}
+}
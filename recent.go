@@ -0,0 +1,85 @@
+package stackerr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RecentError is an error recorded by the buffer turned on with SetRecentErrors.
+type RecentError struct {
+	// Err is the error.
+	Err error
+	// Time is when the error was created.
+	Time time.Time
+	// Fingerprint is the error's fingerprint. See Fingerprint.
+	Fingerprint string
+}
+
+// recentEntry is a slot of the ring buffer. The fingerprint is left out so that recording an error doesn't symbolize
+// its stack trace.
+type recentEntry struct {
+	err  error
+	time time.Time
+}
+
+var recentSize int32
+
+var recent = struct {
+	sync.Mutex
+	entries []recentEntry
+	next    int
+}{}
+
+// SetRecentErrors turns on a buffer of the last n errors created with a new stack trace, the same errors that are
+// reported by Subscribe, and RecentErrors returns its contents. Crash reports written by WriteCrash and
+// WriteCrashFile include them. Pass 0 to turn the buffer off, which also empties it. The buffer is off by default.
+func SetRecentErrors(n int) {
+	if n < 0 {
+		n = 0
+	}
+	recent.Lock()
+	recent.entries = make([]recentEntry, 0, n)
+	recent.next = 0
+	atomic.StoreInt32(&recentSize, int32(n))
+	recent.Unlock()
+}
+
+// RecentErrors returns the errors in the buffer turned on with SetRecentErrors, newest first. It returns nil when the
+// buffer is off or empty.
+func RecentErrors() []RecentError {
+	recent.Lock()
+	entries := make([]recentEntry, 0, len(recent.entries))
+	for i := len(recent.entries) - 1; i >= 0; i-- {
+		entries = append(entries, recent.entries[(recent.next+i)%len(recent.entries)])
+	}
+	recent.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make([]RecentError, len(entries))
+	for i, v := range entries {
+		out[i] = RecentError{Err: v.err, Time: v.time, Fingerprint: Fingerprint(v.err)}
+	}
+	return out
+}
+
+// addRecent records err in the buffer, if it is on.
+func addRecent(err error, now time.Time) {
+	n := int(atomic.LoadInt32(&recentSize))
+	if n == 0 {
+		return
+	}
+	recent.Lock()
+	defer recent.Unlock()
+	if cap(recent.entries) != n {
+		// SetRecentErrors changed the size after the load.
+		return
+	}
+	if len(recent.entries) < n {
+		recent.entries = append(recent.entries, recentEntry{err: err, time: now})
+		return
+	}
+	recent.entries[recent.next] = recentEntry{err: err, time: now}
+	recent.next = (recent.next + 1) % n
+}
@@ -0,0 +1,44 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestSetRecentErrors(t *testing.T) {
+	stackerr.New("before") // nolint: errcheck
+	if recent := stackerr.RecentErrors(); recent != nil {
+		t.Errorf("expected no recent errors while the buffer is off, got %v", recent)
+	}
+
+	stackerr.SetRecentErrors(2)
+	defer stackerr.SetRecentErrors(0)
+	first := stackerr.New("first")
+	stackerr.Wrap(first)                // nolint: errcheck
+	stackerr.Wrap(errors.New("second")) // nolint: errcheck
+	third := stackerr.Errorf("third: %d", 3)
+	recent := stackerr.RecentErrors()
+	if len(recent) != 2 || recent[0].Err != third || recent[1].Err.Error() != "second" {
+		t.Fatalf("expected the last two errors, newest first, got %v", recent)
+	}
+	if recent[0].Fingerprint != stackerr.Fingerprint(third) || recent[0].Time.IsZero() || recent[0].Time.Before(recent[1].Time) {
+		t.Errorf("unexpected entry %v", recent[0])
+	}
+
+	var b bytes.Buffer
+	if wErr := stackerr.WriteCrash(&b, first); wErr != nil {
+		t.Fatal(wErr)
+	}
+	if !strings.Contains(b.String(), "\nrecent errors:\n\t") || !strings.Contains(b.String(), " "+recent[0].Fingerprint+" third: 3\n") {
+		t.Errorf("expected the recent errors in the crash report, got\n%s", b.String())
+	}
+
+	stackerr.SetRecentErrors(0)
+	if recent := stackerr.RecentErrors(); recent != nil {
+		t.Errorf("expected the buffer to be emptied, got %v", recent)
+	}
+}
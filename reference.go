@@ -0,0 +1,21 @@
+package stackerr
+
+import (
+	"strconv"
+	"time"
+)
+
+// ReferenceID returns a short ID for an occurrence of an error, to show to a user in place of the error's details.
+// It is the first eight characters of the error's fingerprint, followed by the current time in milliseconds in base
+// 36, such as "3f2a9c01-lx2k4q1b". Support staff can find the error's stack trace by searching the logs for the ID, and
+// group reports of the same problem by its first part. Each call returns a new ID, so call ReferenceID once and both
+// log the result and send it. Errors without a stack trace only have the time part, and a fingerprint decoded from
+// another process that is shorter than eight characters is used as is.
+func ReferenceID(err error) string {
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 36)
+	fp := Fingerprint(err)
+	if fp == "" {
+		return ts
+	}
+	return fp[:min(len(fp), 8)] + "-" + ts
+}
@@ -0,0 +1,49 @@
+package stackerr_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestReferenceID(t *testing.T) {
+	err := stackerr.New("reference")
+	before := time.Now().UnixMilli()
+	id := stackerr.ReferenceID(err)
+	after := time.Now().UnixMilli()
+
+	prefix, ts, ok := strings.Cut(id, "-")
+	if !ok || prefix != stackerr.Fingerprint(err)[:8] {
+		t.Errorf("expected the ID to start with the fingerprint, got %q", id)
+	}
+	ms, parseErr := strconv.ParseInt(ts, 36, 64)
+	if parseErr != nil || ms < before || ms > after {
+		t.Errorf("expected the current time in the ID, got %q", id)
+	}
+
+	id = stackerr.ReferenceID(errors.New("no stack"))
+	if strings.Contains(id, "-") {
+		t.Errorf("expected only the time part, got %q", id)
+	}
+
+	// fingerprints decoded from other processes can be shorter than eight characters.
+	decoded, decodeErr := stackerr.DecodeJSON([]byte(`{"message":"short","fingerprint":"x"}`))
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if id = stackerr.ReferenceID(decoded); !strings.HasPrefix(id, "x-") {
+		t.Errorf("expected the short fingerprint in the ID, got %q", id)
+	}
+	h := stackerr.EncodeHeaders(stackerr.New("short"))
+	h[stackerr.HeaderFingerprint] = []byte("abc")
+	if decoded, decodeErr = stackerr.DecodeHeaders(h); decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if id = stackerr.ReferenceID(decoded); !strings.HasPrefix(id, "abc-") {
+		t.Errorf("expected the short fingerprint in the ID, got %q", id)
+	}
+}
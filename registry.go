@@ -0,0 +1,84 @@
+package stackerr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RegistryStats reports how the shared trace registry has been used since it was last turned on.
+type RegistryStats struct {
+	// Lookups is the number of captured stack traces that were checked against the registry.
+	Lookups uint64
+	// Hits is the number of lookups that found an identical stack trace.
+	Hits uint64
+	// Traces is the number of distinct stack traces stored in the registry.
+	Traces int
+}
+
+// HitRate returns the fraction of lookups that found an identical stack trace. It returns 0 if there were no lookups.
+func (rs RegistryStats) HitRate() float64 {
+	if rs.Lookups == 0 {
+		return 0
+	}
+	return float64(rs.Hits) / float64(rs.Lookups)
+}
+
+var registryOn int32
+
+var registry = struct {
+	sync.Mutex
+	traces  map[uint64]*callStack
+	lookups uint64
+	hits    uint64
+}{
+	traces: map[uint64]*callStack{},
+}
+
+// SetTraceRegistry turns the shared trace registry on or off. While the registry is on, errors whose stack traces
+// are identical (for example, errors created by the same call site in a loop) share a single stored trace instead of
+// each keeping their own copy. Turning the registry off releases the stored traces and resets its statistics; errors
+// that were already created are not affected. The registry is off by default.
+func SetTraceRegistry(on bool) {
+	if on {
+		atomic.StoreInt32(&registryOn, 1)
+		return
+	}
+	atomic.StoreInt32(&registryOn, 0)
+	registry.Lock()
+	registry.traces = map[uint64]*callStack{}
+	registry.lookups = 0
+	registry.hits = 0
+	registry.Unlock()
+}
+
+// TraceRegistryStats returns the current statistics for the shared trace registry.
+func TraceRegistryStats() RegistryStats {
+	registry.Lock()
+	defer registry.Unlock()
+	return RegistryStats{
+		Lookups: registry.lookups,
+		Hits:    registry.hits,
+		Traces:  len(registry.traces),
+	}
+}
+
+// storeStack returns the callStack to use for pc. If the registry is on and already has an identical callStack, it
+// is returned. Otherwise a new callStack is built, and added to the registry if it is on.
+func storeStack(pc []uintptr) *callStack {
+	if atomic.LoadInt32(&registryOn) == 0 {
+		return internStack(pc)
+	}
+	h := hashPCs(pc)
+	registry.Lock()
+	defer registry.Unlock()
+	registry.lookups++
+	if cs, ok := registry.traces[h]; ok && equalPCs(cs.pcs(), pc) {
+		registry.hits++
+		return cs
+	}
+	cs := internStack(pc)
+	if _, ok := registry.traces[h]; !ok {
+		registry.traces[h] = cs
+	}
+	return cs
+}
@@ -0,0 +1,42 @@
+package stackerr_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestTraceRegistry(t *testing.T) {
+	stackerr.SetTraceRegistry(true)
+	defer stackerr.SetTraceRegistry(false)
+
+	var traces [][]string
+	for i := 0; i < 4; i++ {
+		lines, err := stackerr.Trace(stackerr.New("cache miss"), stackerr.StandardFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		traces = append(traces, lines)
+	}
+	for _, v := range traces[1:] {
+		if diff := cmp.Diff(traces[0], v); diff != "" {
+			t.Error(diff)
+		}
+	}
+
+	stats := stackerr.TraceRegistryStats()
+	expected := stackerr.RegistryStats{Lookups: 4, Hits: 3, Traces: 1}
+	if diff := cmp.Diff(expected, stats); diff != "" {
+		t.Error(diff)
+	}
+	if stats.HitRate() != 0.75 {
+		t.Errorf("expected hit rate 0.75, got %f", stats.HitRate())
+	}
+
+	stackerr.SetTraceRegistry(false)
+	if diff := cmp.Diff(stackerr.RegistryStats{}, stackerr.TraceRegistryStats()); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,112 @@
+package stackerr
+
+// remoteError holds the message chain of an error that was decoded from another process. Each remoteError wraps the
+// next message in the chain, so Chain returns the same messages as it did in the other process.
+type remoteError struct {
+	msg  string
+	next *remoteError
+}
+
+// Error returns the decoded message.
+func (re *remoteError) Error() string {
+	return re.msg
+}
+
+// Unwrap returns the next error in the decoded chain.
+func (re *remoteError) Unwrap() error {
+	if re.next == nil {
+		return nil
+	}
+	return re.next
+}
+
+// FromReport rebuilds an error from a LogReport, such as one decoded from another process. The error has the report's
+// message, chain, code, severity, category, operation names, request ID, trace and span IDs, context values, and
+// frames; Trace, Frames, and %+v use the report's frames.
+func FromReport(r LogReport) error {
+	chain := r.Chain
+	if len(chain) == 0 || chain[0] != r.Message {
+		chain = append([]string{r.Message}, chain...)
+	}
+	var inner *remoteError
+	for i := len(chain) - 1; i >= 0; i-- {
+		inner = &remoteError{msg: chain[i], next: inner}
+	}
+	frames := r.Frames
+	if frames == nil {
+		frames = []Frame{}
+	}
+	var err error = &errorStack{
+		Err:   inner,
+		trace: &callStack{decoded: frames, fingerprint: r.Fingerprint},
+	}
+	if r.Code != 0 {
+		err = annotated{err: err, key: codeKey, value: r.Code}
+	}
+	if r.Severity != 0 {
+		err = annotated{err: err, key: severityKey, value: r.Severity}
+	}
+	for i := len(r.Ops) - 1; i >= 0; i-- {
+		err = annotated{err: err, key: opKey, value: r.Ops[i]}
+	}
+	if r.Category != 0 {
+		err = annotated{err: err, key: categoryKey, value: r.Category}
+	}
+	if r.RequestID != "" {
+		err = annotated{err: err, key: requestIDKey, value: r.RequestID}
+	}
+	if r.TraceID != "" || r.SpanID != "" {
+		err = annotated{err: err, key: spanKey, value: spanIDs{traceID: r.TraceID, spanID: r.SpanID}}
+	}
+	if len(r.Context) > 0 {
+		err = annotated{err: err, key: contextValuesKey, value: &attachedValues{values: r.Context}}
+	}
+	return err
+}
+
+// Remote describes the process that a decoded error came from.
+type Remote struct {
+	// Service is the name of the service that sent the error.
+	Service string
+	// Revision is the version of the service, such as a commit hash.
+	Revision string
+}
+
+// header returns the line rendered before the frames from the remote process.
+func (r Remote) header() string {
+	out := "remote:"
+	if r.Service != "" {
+		out += " " + r.Service
+	}
+	if r.Revision != "" {
+		out += " @ rev " + r.Revision
+	}
+	return out
+}
+
+// localLine is rendered before the local frames of an error returned by AttachLocal.
+const localLine = "local:"
+
+// AttachLocal wraps an error decoded from another process, such as one returned by DecodeJSON or DecodeMsgpack, in
+// an errorStack that also has the current call stack. Trace and %+v render a "remote:" line that describes where the
+// error came from, followed by the decoded frames, then a "local:" line and the frames from the current call stack.
+// Origin and Fingerprint use the decoded frames. If err has no stack trace, AttachLocal works like Wrap. AttachLocal
+// returns nil when a nil error is passed in.
+func AttachLocal(err error, from Remote) error {
+	if err == nil {
+		return nil
+	}
+	se, ok := asStack(err)
+	if !ok {
+		return wrap(err, 1)
+	}
+	if se.earlier != nil {
+		se = se.earlier
+	}
+	return &errorStack{
+		Err:     err,
+		trace:   se.trace,
+		resumed: captureStack(1),
+		remote:  &from,
+	}
+}
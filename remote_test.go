@@ -0,0 +1,92 @@
+package stackerr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	original := stackerr.New("from json")
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := stackerr.DecodeJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Error() != "from json" {
+		t.Errorf("expected `from json`, got `%s`", decoded.Error())
+	}
+	if diff := cmp.Diff(stackerr.Frames(original), stackerr.Frames(decoded)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(traceLines(t, original), traceLines(t, decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	report := stackerr.Report(stackerr.WithCode(fmt.Errorf("outer: %w", original), 9))
+	data, _ = json.Marshal(report)
+	decoded, err = stackerr.DecodeJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(report, stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := stackerr.DecodeJSON([]byte("{")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestAttachLocal(t *testing.T) {
+	if stackerr.AttachLocal(nil, stackerr.Remote{}) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	remoteErr := stackerr.New("from service-b")
+	data, _ := json.Marshal(remoteErr)
+	decoded, _ := stackerr.DecodeJSON(data)
+	err := stackerr.AttachLocal(decoded, stackerr.Remote{Service: "service-b", Revision: "abc"})
+	if !errors.Is(err, decoded) {
+		t.Error("expected the decoded error to be in the chain")
+	}
+
+	lines := traceLines(t, err)
+	remote := traceLines(t, remoteErr)
+	if lines[0] != "remote: service-b @ rev abc" {
+		t.Errorf("unexpected remote header `%s`", lines[0])
+	}
+	if diff := cmp.Diff(remote, lines[1:len(remote)+1]); diff != "" {
+		t.Error(diff)
+	}
+	if lines[len(remote)+1] != "local:" {
+		t.Errorf("expected the local header, got `%s`", lines[len(remote)+1])
+	}
+	if !strings.HasPrefix(lines[len(remote)+2], "github.com/jonbodner/stackerr_test.TestAttachLocal ") {
+		t.Errorf("expected the local frames to start at the caller, got `%s`", lines[len(remote)+2])
+	}
+	if diff := cmp.Diff("from service-b\n"+strings.Join(lines, "\n"), fmt.Sprintf("%+v", err)); diff != "" {
+		t.Error(diff)
+	}
+	if stackerr.Fingerprint(err) != stackerr.Fingerprint(remoteErr) {
+		t.Error("expected the fingerprint to come from the remote frames")
+	}
+
+	lines = traceLines(t, stackerr.AttachLocal(decoded, stackerr.Remote{}))
+	if lines[0] != "remote:" {
+		t.Errorf("unexpected remote header `%s`", lines[0])
+	}
+	lines = traceLines(t, stackerr.AttachLocal(errors.New("plain"), stackerr.Remote{Service: "service-b"}))
+	if !strings.HasPrefix(lines[0], "github.com/jonbodner/stackerr_test.TestAttachLocal ") {
+		t.Errorf("expected AttachLocal to work like Wrap for an error without a stack trace, got `%s`", lines[0])
+	}
+}
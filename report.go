@@ -0,0 +1,58 @@
+package stackerr
+
+// LogReport collects everything a logging adapter needs to know about an error.
+type LogReport struct {
+	// Message is the error's message.
+	Message string `json:"message"`
+	// Chain holds the messages in the error's unwrap tree. See Chain.
+	Chain []string `json:"chain"`
+	// Code is the code attached with WithCode, or 0 if there isn't one.
+	Code int `json:"code,omitempty"`
+	// Severity is the severity attached with WithSeverity, or SeverityError if there isn't one.
+	Severity Severity `json:"severity"`
+	// Category is the category from CategoryOf, or 0 if there isn't one.
+	Category Category `json:"category,omitempty"`
+	// Ops holds the operation names attached with WithOp, outermost first. See Ops.
+	Ops []string `json:"ops,omitempty"`
+	// OriginFrame is the frame where the stack trace was captured. It is the zero value if there is no stack trace.
+	OriginFrame Frame `json:"origin"`
+	// Fingerprint identifies where the error was created. See Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// RequestID is the request ID attached with WithRequestID, or an empty string if there isn't one.
+	RequestID string `json:"request_id,omitempty"`
+	// TraceID and SpanID are the IDs attached with WithSpanIDs or one of the context-aware constructors, such as
+	// WrapCtx, or empty strings if there aren't any.
+	TraceID string `json:"trace_id,omitempty"`
+	SpanID  string `json:"span_id,omitempty"`
+	// Context holds the values copied from contexts by the context-aware constructors. See ContextValues.
+	Context map[string]string `json:"context,omitempty"`
+	// Frames holds the frames of the stack trace. See Frames.
+	Frames []Frame `json:"frames,omitempty"`
+}
+
+// Report builds a LogReport for the error. It returns the zero value when a nil error is passed in.
+func Report(err error) LogReport {
+	if err == nil {
+		return LogReport{}
+	}
+	r := LogReport{
+		Message:  err.Error(),
+		Chain:    Chain(err),
+		Severity: SeverityOf(err),
+	}
+	r.Code, _ = Code(err)
+	r.Category, _ = CategoryOf(err)
+	r.Ops = Ops(err)
+	r.RequestID, _ = RequestID(err)
+	r.TraceID, r.SpanID, _ = SpanIDs(err)
+	r.Context = ContextValues(err)
+	se, ok := asStack(err)
+	if ok {
+		r.Frames = se.exportFrames()
+		if len(r.Frames) > 0 {
+			r.OriginFrame = r.Frames[0]
+		}
+		r.Fingerprint = fingerprint(se)
+	}
+	return r
+}
@@ -0,0 +1,57 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestChain(t *testing.T) {
+	inner := stackerr.New("inner")
+	err := fmt.Errorf("outer: %w", stackerr.WithCode(fmt.Errorf("middle: %w", inner), 7))
+	expected := []string{"outer: middle: inner", "middle: inner", "inner"}
+	if diff := cmp.Diff(expected, stackerr.Chain(err)); diff != "" {
+		t.Error(diff)
+	}
+
+	joined := errors.Join(errors.New("a"), errors.New("b"))
+	expected = []string{"a\nb", "a", "b"}
+	if diff := cmp.Diff(expected, stackerr.Chain(joined)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReport(t *testing.T) {
+	if diff := cmp.Diff(stackerr.LogReport{}, stackerr.Report(nil)); diff != "" {
+		t.Error(diff)
+	}
+
+	inner := stackerr.New("inner")
+	err := stackerr.WithSeverity(stackerr.WithCode(fmt.Errorf("outer: %w", inner), 42), stackerr.SeverityWarning)
+	frames := stackerr.Frames(inner)
+	expected := stackerr.LogReport{
+		Message:     "outer: inner",
+		Chain:       []string{"outer: inner", "inner"},
+		Code:        42,
+		Severity:    stackerr.SeverityWarning,
+		OriginFrame: frames[0],
+		Fingerprint: stackerr.Fingerprint(inner),
+		Frames:      frames,
+	}
+	if diff := cmp.Diff(expected, stackerr.Report(err)); diff != "" {
+		t.Error(diff)
+	}
+
+	expected = stackerr.LogReport{
+		Message:  "plain",
+		Chain:    []string{"plain"},
+		Severity: stackerr.SeverityError,
+	}
+	if diff := cmp.Diff(expected, stackerr.Report(errors.New("plain"))); diff != "" {
+		t.Error(diff)
+	}
+}
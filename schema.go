@@ -0,0 +1,158 @@
+package stackerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SchemaV1 is the value of the schema field of documents in version 1 of the versioned JSON schema. The schema is
+// described by schema/error-v1.json in the stackerr repository.
+//
+// Within a version, fields are never removed or renamed and never change type or meaning. New optional fields may be
+// added, so parsers should ignore fields they don't know. A change that would break a parser gets a new version, and
+// UnmarshalSchema keeps accepting the documents of every earlier version.
+const SchemaV1 = "stackerr/error/v1"
+
+// SchemaDocument is an error in the versioned JSON schema. Unlike the JSON written by MarshalJSON and the fields of
+// LogReport, which can change between releases, its layout is fixed for each version; see SchemaV1. Log pipelines that
+// parse errors written by other programs should use it.
+type SchemaDocument struct {
+	// Schema is the version of the schema, such as SchemaV1.
+	Schema string `json:"schema"`
+	// Message is the error's message.
+	Message string `json:"message"`
+	// Chain holds the messages in the error's unwrap tree. See Chain.
+	Chain []string `json:"chain"`
+	// Frames holds the frames of the stack trace, innermost first. It is empty if there is no stack trace.
+	Frames []SchemaFrame `json:"frames"`
+	// Metadata holds the values attached to the error.
+	Metadata SchemaMetadata `json:"metadata"`
+}
+
+// SchemaFrame is a frame in a SchemaDocument.
+type SchemaFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Kind is one of the FrameKind values, such as "app".
+	Kind string `json:"kind"`
+}
+
+// SchemaMetadata holds the values attached to the error in a SchemaDocument.
+type SchemaMetadata struct {
+	// Code is the code attached with WithCode, or 0 if there isn't one.
+	Code int `json:"code,omitempty"`
+	// Severity is the name of the error's severity, such as "error".
+	Severity string `json:"severity"`
+	// Category is the name of the error's category, such as "not_found". See CategoryOf.
+	Category string `json:"category,omitempty"`
+	// Ops holds the operation names attached with WithOp, outermost first.
+	Ops []string `json:"ops,omitempty"`
+	// Fingerprint identifies where the error was created. See Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// RequestID is the request ID attached with WithRequestID.
+	RequestID string `json:"request_id,omitempty"`
+	// TraceID and SpanID are the trace and span IDs attached with WithSpanIDs or a context-aware constructor.
+	TraceID string `json:"trace_id,omitempty"`
+	SpanID  string `json:"span_id,omitempty"`
+	// Context holds the values copied from contexts. See ContextValues.
+	Context map[string]string `json:"context,omitempty"`
+}
+
+// Document builds a SchemaDocument for the error in the current version of the schema. It returns a document with only
+// the schema field set when a nil error is passed in.
+func Document(err error) SchemaDocument {
+	d := SchemaDocument{Schema: SchemaV1, Chain: []string{}, Frames: []SchemaFrame{}}
+	if err == nil {
+		return d
+	}
+	r := Report(err)
+	d.Message = r.Message
+	if r.Chain != nil {
+		d.Chain = r.Chain
+	}
+	for _, v := range r.Frames {
+		d.Frames = append(d.Frames, SchemaFrame{Function: v.Function, File: v.File, Line: v.Line, Kind: string(v.Kind)})
+	}
+	d.Metadata = SchemaMetadata{
+		Code:        r.Code,
+		Severity:    r.Severity.String(),
+		Fingerprint: r.Fingerprint,
+		RequestID:   r.RequestID,
+		TraceID:     r.TraceID,
+		SpanID:      r.SpanID,
+		Context:     r.Context,
+		Ops:         r.Ops,
+	}
+	if r.Category != 0 {
+		d.Metadata.Category = r.Category.String()
+	}
+	return d
+}
+
+// MarshalSchema encodes the error as JSON in the current version of the versioned schema. See SchemaV1.
+func MarshalSchema(err error) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	return json.Marshal(Document(err))
+}
+
+// MarshalSchemaIndent works like MarshalSchema, but indents the output like json.MarshalIndent.
+func MarshalSchemaIndent(err error, prefix, indent string) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("stackerr: cannot encode a nil error")
+	}
+	return json.MarshalIndent(Document(err), prefix, indent)
+}
+
+// UnmarshalSchema decodes an error encoded by MarshalSchema, in this or an earlier version of the schema. The decoded
+// error has the original message, chain, frames, and metadata; Trace, Frames, and %+v use the decoded frames. It
+// returns an error if the document is for an unknown version, such as one written by a newer release of stackerr.
+func UnmarshalSchema(data []byte) (error, error) {
+	var d SchemaDocument
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, Wrap(err)
+	}
+	if d.Schema != SchemaV1 {
+		if !strings.HasPrefix(d.Schema, "stackerr/error/") {
+			return nil, errors.New("stackerr: not an error document")
+		}
+		return nil, fmt.Errorf("stackerr: unknown schema version %q", d.Schema)
+	}
+	return FromReport(d.Report()), nil
+}
+
+// Report converts the document to a LogReport. Unknown severities, categories, and frame kinds are left as their zero
+// values.
+func (d SchemaDocument) Report() LogReport {
+	r := LogReport{
+		Message:     d.Message,
+		Chain:       d.Chain,
+		Code:        d.Metadata.Code,
+		Fingerprint: d.Metadata.Fingerprint,
+		RequestID:   d.Metadata.RequestID,
+		TraceID:     d.Metadata.TraceID,
+		SpanID:      d.Metadata.SpanID,
+		Context:     d.Metadata.Context,
+		Ops:         d.Metadata.Ops,
+	}
+	r.Severity.UnmarshalText([]byte(d.Metadata.Severity)) // nolint: errcheck
+	if d.Metadata.Category != "" {
+		r.Category.UnmarshalText([]byte(d.Metadata.Category)) // nolint: errcheck
+	}
+	r.Frames = make([]Frame, len(d.Frames))
+	for i, v := range d.Frames {
+		r.Frames[i] = Frame{Function: v.Function, File: v.File, Line: v.Line}
+		switch k := FrameKind(v.Kind); k {
+		case FrameApp, FrameDependency, FrameStdlib, FrameCgo:
+			r.Frames[i].Kind = k
+		}
+	}
+	if len(r.Frames) > 0 {
+		r.OriginFrame = r.Frames[0]
+	}
+	return r
+}
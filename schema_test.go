@@ -0,0 +1,122 @@
+package stackerr_test
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func schemaError() error {
+	err := stackerr.New("lookup failed")
+	err = stackerr.WithCode(err, 404)
+	err = stackerr.WithSeverity(err, stackerr.SeverityWarning)
+	err = stackerr.WithCategory(err, stackerr.CategoryNotFound)
+	err = stackerr.WithOp(err, "users.Get")
+	err = stackerr.WithRequestID(err, "req-1")
+	return stackerr.WithSpanIDs(err, "trace-1", "span-1")
+}
+
+func TestMarshalSchema(t *testing.T) {
+	err := schemaError()
+	data, marshalErr := stackerr.MarshalSchema(err)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	decoded, decodeErr := stackerr.UnmarshalSchema(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	indented, marshalErr := stackerr.MarshalSchemaIndent(err, "", "  ")
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	if !strings.HasPrefix(string(indented), "{\n  \"schema\": \"stackerr/error/v1\",\n") {
+		t.Errorf("unexpected indented output %s", indented)
+	}
+
+	if _, marshalErr := stackerr.MarshalSchema(nil); marshalErr == nil {
+		t.Error("expected an error for a nil error")
+	}
+	for _, v := range []string{`{"schema":"stackerr/error/v9"}`, `{"message":"no schema"}`, `not json`} {
+		if _, decodeErr := stackerr.UnmarshalSchema([]byte(v)); decodeErr == nil {
+			t.Errorf("expected an error decoding %s", v)
+		}
+	}
+}
+
+// TestSchemaFile checks that the fields written by MarshalSchema are the ones described by the published schema.
+func TestSchemaFile(t *testing.T) {
+	schemaData, err := os.ReadFile("schema/error-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type object struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Items      *object                    `json:"items"`
+	}
+	var schema object
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		t.Fatal(err)
+	}
+	property := func(o object, name string) object {
+		var out object
+		if err := json.Unmarshal(o.Properties[name], &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	doc := stackerr.Document(schemaError())
+	doc.Metadata.Context = map[string]string{"tenant": "a"}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &written); err != nil {
+		t.Fatal(err)
+	}
+	var frames []map[string]json.RawMessage
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(written["frames"], &frames); err != nil || len(frames) == 0 {
+		t.Fatal("expected frames", err)
+	}
+	if err := json.Unmarshal(written["metadata"], &metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		name    string
+		schema  map[string]json.RawMessage
+		written map[string]json.RawMessage
+	}{
+		{"document", schema.Properties, written},
+		{"frame", property(schema, "frames").Items.Properties, frames[0]},
+		{"metadata", property(schema, "metadata").Properties, metadata},
+	}
+	keys := func(m map[string]json.RawMessage) []string {
+		var out []string
+		for k := range m {
+			out = append(out, k)
+		}
+		sort.Strings(out)
+		return out
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			if diff := cmp.Diff(keys(v.schema), keys(v.written)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
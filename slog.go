@@ -0,0 +1,160 @@
+package stackerr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slogHandler is a slog.Handler that expands errors with stack traces before passing records to the next handler.
+type slogHandler struct {
+	next slog.Handler
+}
+
+// NewSlogHandler returns a slog.Handler that looks for attributes whose values are errors with a stack trace, and
+// replaces each one with a group that contains the error's message, origin, frames, and fingerprint. Records are then
+// passed to next. Wrapping the handler used by a program's loggers adds stack traces to every logged error, without
+// changing any logging calls.
+func NewSlogHandler(next slog.Handler) slog.Handler {
+	return slogHandler{next: next}
+}
+
+// Enabled reports whether the next handler handles records at the given level.
+func (h slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle expands the error attributes in the record and passes it to the next handler.
+func (h slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(expandAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+// WithAttrs expands the error attributes and passes them to the next handler.
+func (h slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, v := range attrs {
+		expanded[i] = expandAttr(v)
+	}
+	return slogHandler{next: h.next.WithAttrs(expanded)}
+}
+
+// WithGroup passes the group to the next handler.
+func (h slogHandler) WithGroup(name string) slog.Handler {
+	return slogHandler{next: h.next.WithGroup(name)}
+}
+
+// expandAttr replaces errors with stack traces with a group. Attributes in groups are expanded as well.
+func expandAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		expanded := make([]any, len(group))
+		for i, g := range group {
+			expanded[i] = expandAttr(g)
+		}
+		return slog.Group(a.Key, expanded...)
+	case slog.KindAny:
+		err, ok := v.Any().(error)
+		if !ok {
+			return a
+		}
+		se, ok := asStack(err)
+		if !ok {
+			return a
+		}
+		return slog.Group(a.Key, stackAttrs(err, se)...)
+	}
+	return a
+}
+
+// stackAttrs returns the attributes for an error with a stack trace.
+func stackAttrs(err error, se *errorStack) []any {
+	frames := se.exportFrames()
+	lines := make([]string, len(frames))
+	for i, v := range frames {
+		lines[i] = fmt.Sprintf("%s (%s:%d)", v.Function, v.File, v.Line)
+	}
+	attrs := []any{slog.String("msg", err.Error())}
+	if len(lines) > 0 {
+		attrs = append(attrs, slog.String("origin", lines[0]))
+	}
+	attrs = append(attrs,
+		slog.Any("frames", lines),
+		slog.String("fingerprint", fingerprint(se)),
+	)
+	if c, ok := CategoryOf(err); ok {
+		attrs = append(attrs, slog.String("category", c.String()))
+	}
+	if ops := Ops(err); len(ops) > 0 {
+		attrs = append(attrs, slog.Any("ops", ops))
+	}
+	if id, ok := RequestID(err); ok {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if traceID, spanID, ok := SpanIDs(err); ok {
+		attrs = append(attrs, slog.String("trace_id", traceID), slog.String("span_id", spanID))
+	}
+	if values := ContextValues(err); len(values) > 0 {
+		group := make([]any, 0, len(values))
+		for _, k := range sortedContextValues(values) {
+			group = append(group, slog.String(k, values[k]))
+		}
+		attrs = append(attrs, slog.Group("context", group...))
+	}
+	return attrs
+}
+
+var slogLevels = map[Severity]slog.Level{
+	SeverityDebug:    slog.LevelDebug,
+	SeverityInfo:     slog.LevelInfo,
+	SeverityWarning:  slog.LevelWarn,
+	SeverityError:    slog.LevelError,
+	SeverityCritical: slog.LevelError + 4,
+}
+
+// WrapAndLog wraps err the same way as Wrap, logs it to logger with msg, and returns the wrapped error. The record is
+// logged at the level that matches the error's severity, with an "err" group that holds the error's message, chain,
+// code, severity, origin, frames, fingerprint, category, operation names, request ID, trace and span IDs, and context
+// values. The default logger is used if logger is nil. WrapAndLog returns nil and logs nothing when a nil error is
+// passed in.
+func WrapAndLog(logger *slog.Logger, err error, msg string) error {
+	err = wrap(err, 1)
+	if err == nil {
+		return nil
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx := context.Background()
+	r := Report(err)
+	level := slogLevels[r.Severity]
+	if !logger.Enabled(ctx, level) {
+		return err
+	}
+	attrs := []any{
+		slog.String("msg", r.Message),
+		slog.Any("chain", r.Chain),
+		slog.String("severity", r.Severity.String()),
+	}
+	if r.Code != 0 {
+		attrs = append(attrs, slog.Int("code", r.Code))
+	}
+	se, _ := asStack(err)
+	// stackAttrs starts with msg, which is already in attrs.
+	attrs = append(attrs, stackAttrs(err, se)[1:]...)
+	// skip runtime.Callers and WrapAndLog, so the record's source is the caller.
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.AddAttrs(slog.Group("err", attrs...))
+	logger.Handler().Handle(ctx, record) // nolint: errcheck
+	return err
+}
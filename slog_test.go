@@ -0,0 +1,112 @@
+package stackerr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestNewSlogHandler(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(stackerr.NewSlogHandler(slog.NewJSONHandler(&b, nil)))
+	err := stackerr.New("failed")
+	logger.With("plain", errors.New("plain")).Error("request failed", "err", err, slog.Group("nested", "err", err))
+
+	var out struct {
+		Plain  string `json:"plain"`
+		Err    logged `json:"err"`
+		Nested struct {
+			Err logged `json:"err"`
+		} `json:"nested"`
+	}
+	if jsonErr := json.Unmarshal(b.Bytes(), &out); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if out.Plain != "plain" {
+		t.Errorf("expected plain error to be left alone, got `%s`", out.Plain)
+	}
+	lines := traceLines(t, err)
+	expected := logged{
+		Msg:         "failed",
+		Origin:      lines[0],
+		Frames:      lines,
+		Fingerprint: stackerr.Fingerprint(err),
+	}
+	if diff := cmp.Diff(expected, out.Err); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(expected, out.Nested.Err); diff != "" {
+		t.Error(diff)
+	}
+}
+
+type logged struct {
+	Msg         string   `json:"msg"`
+	Origin      string   `json:"origin"`
+	Frames      []string `json:"frames"`
+	Fingerprint string   `json:"fingerprint"`
+}
+
+func TestWrapAndLog(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{AddSource: true}))
+	if stackerr.WrapAndLog(logger, nil, "nothing") != nil || b.Len() != 0 {
+		t.Error("expected nil and no output for a nil error")
+	}
+
+	err := stackerr.WrapAndLog(logger, stackerr.WithCode(errors.New("failed"), 7), "request failed")
+	if !stackerr.HasStack(err) || err.Error() != "failed" {
+		t.Fatalf("expected a stacked `failed`, got %v", err)
+	}
+	var out struct {
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		Source struct {
+			Function string `json:"function"`
+		} `json:"source"`
+		Err struct {
+			logged
+			Chain    []string `json:"chain"`
+			Severity string   `json:"severity"`
+			Code     int      `json:"code"`
+		} `json:"err"`
+	}
+	if jsonErr := json.Unmarshal(b.Bytes(), &out); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if out.Level != "ERROR" || out.Msg != "request failed" {
+		t.Errorf("unexpected level and message: %s %s", out.Level, out.Msg)
+	}
+	if out.Source.Function != "github.com/jonbodner/stackerr_test.TestWrapAndLog" {
+		t.Errorf("expected the source to be the caller, got %s", out.Source.Function)
+	}
+	lines := traceLines(t, err)
+	expected := logged{
+		Msg:         "failed",
+		Origin:      lines[0],
+		Frames:      lines,
+		Fingerprint: stackerr.Fingerprint(err),
+	}
+	if diff := cmp.Diff(expected, out.Err.logged); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"failed"}, out.Err.Chain); diff != "" {
+		t.Error(diff)
+	}
+	if out.Err.Severity != "error" || out.Err.Code != 7 {
+		t.Errorf("unexpected severity and code: %s %d", out.Err.Severity, out.Err.Code)
+	}
+
+	b.Reset()
+	quiet := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	err = stackerr.WrapAndLog(quiet, stackerr.WithSeverity(errors.New("debug only"), stackerr.SeverityDebug), "ignored")
+	if err == nil || b.Len() != 0 {
+		t.Error("expected the error to be returned without logging")
+	}
+}
@@ -0,0 +1,90 @@
+package stackerr
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// SpanContextFunc returns the IDs of the trace and span that are active in ctx, as lowercase hex strings. The last
+// return value is false if there is no active span. The stackerroteltrace package installs one for OpenTelemetry
+// when it is imported.
+type SpanContextFunc func(ctx context.Context) (traceID, spanID string, ok bool)
+
+var spanContextFunc atomic.Value
+
+// SetSpanContextFunc installs the function used by NewCtx, ErrorfCtx, and WrapCtx to find the active trace and span.
+// Passing nil removes the function.
+func SetSpanContextFunc(f SpanContextFunc) {
+	spanContextFunc.Store(f)
+}
+
+// spanIDs is the value of the span annotation.
+type spanIDs struct {
+	traceID string
+	spanID  string
+}
+
+// WithSpanIDs attaches the IDs of a trace and span to err. The IDs are included in the error's LogReport and in the
+// output of the structured encoders, so an error log can be matched with the trace in a tracing backend. If there is
+// no stack trace in the unwrap chain for err, one is captured. WithSpanIDs returns nil when a nil error is passed in.
+//
+// Most code doesn't need to call WithSpanIDs; NewCtx, ErrorfCtx, and WrapCtx attach the IDs of the span that is
+// active in their context.
+func WithSpanIDs(err error, traceID, spanID string) error {
+	return annotate(err, spanKey, spanIDs{traceID: traceID, spanID: spanID}, 1)
+}
+
+// SpanIDs returns the trace and span IDs attached by the outermost call to WithSpanIDs, NewCtx, ErrorfCtx, or WrapCtx
+// in the unwrap chain for the error. The last return value is false if there are no IDs.
+func SpanIDs(err error) (traceID, spanID string, ok bool) {
+	v, ok := lookup(err, spanKey)
+	if !ok {
+		return "", "", false
+	}
+	ids := v.(spanIDs)
+	return ids.traceID, ids.spanID, true
+}
+
+// NewCtx works like New, and also attaches the IDs of the trace and span that are active in ctx, as found by the
+// function installed with SetSpanContextFunc, and the values from ctx allowlisted with SetContextValues.
+func NewCtx(ctx context.Context, msg string) error {
+	return withContext(ctx, defaultFactory.rewrap(errors.New(msg), 1))
+}
+
+// ErrorfCtx works like Errorf, and also attaches the IDs of the trace and span that are active in ctx, as found by
+// the function installed with SetSpanContextFunc, and the values from ctx allowlisted with SetContextValues.
+func ErrorfCtx(ctx context.Context, format string, vals ...interface{}) error {
+	return withContext(ctx, defaultFactory.errorf(1, format, vals...))
+}
+
+// WrapCtx works like Wrap, and also attaches the IDs of the trace and span that are active in ctx, as found by the
+// function installed with SetSpanContextFunc, and the values from ctx allowlisted with SetContextValues. IDs and
+// values that are already attached to err are kept. WrapCtx returns nil when a nil error is passed in.
+func WrapCtx(ctx context.Context, err error) error {
+	return withContext(ctx, wrap(err, 1))
+}
+
+// withContext attaches the span IDs and context values from ctx to err, which already has a stack trace.
+func withContext(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	return withContextValues(ctx, withSpanContext(ctx, err))
+}
+
+// withSpanContext attaches the IDs of the span that is active in ctx to err.
+func withSpanContext(ctx context.Context, err error) error {
+	if _, _, ok := SpanIDs(err); ok {
+		return err
+	}
+	f, _ := spanContextFunc.Load().(SpanContextFunc)
+	if f == nil {
+		return err
+	}
+	traceID, spanID, ok := f(ctx)
+	if !ok {
+		return err
+	}
+	return annotated{err: err, key: spanKey, value: spanIDs{traceID: traceID, spanID: spanID}}
+}
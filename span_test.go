@@ -0,0 +1,87 @@
+package stackerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+type spanKey struct{}
+
+func TestWrapCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), spanKey{}, "4bf92f3577b34da6a3ce929d0e0e4736/00f067aa0ba902b7")
+	stackerr.SetSpanContextFunc(func(ctx context.Context) (string, string, bool) {
+		v, ok := ctx.Value(spanKey{}).(string)
+		if !ok {
+			return "", "", false
+		}
+		traceID, spanID, _ := strings.Cut(v, "/")
+		return traceID, spanID, true
+	})
+	defer stackerr.SetSpanContextFunc(nil)
+
+	if stackerr.WrapCtx(ctx, nil) != nil {
+		t.Error("Got non-nil for nil passed to WrapCtx")
+	}
+	data := []struct {
+		name string
+		err  error
+	}{
+		{"NewCtx", stackerr.NewCtx(ctx, "payment declined")},
+		{"ErrorfCtx", stackerr.ErrorfCtx(ctx, "payment %d declined", 7)},
+		{"WrapCtx", stackerr.WrapCtx(ctx, errors.New("payment declined"))},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			traceID, spanID, ok := stackerr.SpanIDs(fmt.Errorf("wrapped: %w", v.err))
+			if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+				t.Errorf("unexpected IDs %q %q", traceID, spanID)
+			}
+			if top := topFrame(t, v.err); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestWrapCtx ") {
+				t.Errorf("expected the trace to start in the test, got `%s`", top)
+			}
+		})
+	}
+
+	err := stackerr.WrapCtx(context.Background(), errors.New("no span"))
+	if _, _, ok := stackerr.SpanIDs(err); ok || !stackerr.HasStack(err) {
+		t.Error("expected a stack trace without span IDs")
+	}
+	err = stackerr.WrapCtx(ctx, stackerr.WithSpanIDs(errors.New("earlier span"), "a", "b"))
+	if traceID, spanID, _ := stackerr.SpanIDs(err); traceID != "a" || spanID != "b" {
+		t.Errorf("expected the earlier IDs to be kept, got %q %q", traceID, spanID)
+	}
+}
+
+func TestWithSpanIDs(t *testing.T) {
+	if stackerr.WithSpanIDs(nil, "a", "b") != nil {
+		t.Error("Got non-nil for nil passed to WithSpanIDs")
+	}
+	err := stackerr.WithSpanIDs(errors.New("timeout"), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	r := stackerr.Report(err)
+	if r.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || r.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("expected the IDs in the report, got %q %q", r.TraceID, r.SpanID)
+	}
+	if line := stackerr.EncodeLogfmt(err); !strings.HasSuffix(line, " trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7") {
+		t.Errorf("expected the IDs in `%s`", line)
+	}
+	if doc := stackerr.EncodeYAML(err); !strings.Contains(doc, "\nspan_id: \"00f067aa0ba902b7\"\n") {
+		t.Errorf("expected the span ID in\n%s", doc)
+	}
+	data, jsonErr := json.Marshal(r)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	decoded, decodeErr := stackerr.DecodeJSON(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if traceID, spanID, _ := stackerr.SpanIDs(decoded); traceID != r.TraceID || spanID != r.SpanID {
+		t.Errorf("expected the IDs to round trip, got %q %q", traceID, spanID)
+	}
+}
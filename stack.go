@@ -0,0 +1,247 @@
+package stackerr
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// callStack holds the program counters captured for an errorStack, innermost frame first. When interning is on,
+// tail holds the outer frames and shares its memory with every other callStack that has the same outer frames;
+// head holds the frames that are unique to this callStack.
+//
+// A callStack decoded from another process has no program counters; its frames are held in decoded instead. A
+// callStack created by WrapCgo holds the frames of the C code that failed in cgo, ahead of its program counters. A
+// callStack decoded by DecodeHeaders keeps the fingerprint computed by the process that encoded it. A callStack
+// captured while SetMaxFrames was set records how many outer frames it discarded.
+//
+// The frames for a callStack are symbolized the first time they are needed and cached, so a callStack must not be
+// copied once it is created. The cache is filled under a sync.Once and never changed afterward, so an error can be
+// formatted from several goroutines at once. Identical callStacks share their frames through the symbol cache.
+type callStack struct {
+	head    []uintptr
+	tail    []uintptr
+	decoded []Frame
+	cgo     []Frame
+
+	fingerprint string
+	// dropped is the number of outer frames that were discarded because of SetMaxFrames.
+	dropped int
+
+	once   sync.Once
+	cached []runtime.Frame
+}
+
+// pcs returns all of the program counters in the callStack. It returns nil for a nil callStack.
+func (c *callStack) pcs() []uintptr {
+	if c == nil {
+		return nil
+	}
+	if len(c.tail) == 0 {
+		return c.head
+	}
+	if len(c.head) == 0 {
+		return c.tail
+	}
+	out := make([]uintptr, 0, len(c.head)+len(c.tail))
+	out = append(out, c.head...)
+	return append(out, c.tail...)
+}
+
+// frames returns the symbolized frames for the callStack. The frames are computed on the first call and cached; the
+// returned slice must not be modified.
+func (c *callStack) frames() []runtime.Frame {
+	if c == nil {
+		return symbolize(nil)
+	}
+	c.once.Do(func() {
+		if c.decoded != nil {
+			c.cached = make([]runtime.Frame, len(c.decoded))
+			for i, v := range c.decoded {
+				c.cached[i] = runtime.Frame{Function: v.Function, File: v.File, Line: v.Line}
+			}
+			return
+		}
+		if c.cgo != nil {
+			c.cached = c.cgoFrames()
+			return
+		}
+		c.cached = cachedSymbolize(c.pcs())
+	})
+	return c.cached
+}
+
+// symbolize converts program counters into frames.
+func symbolize(pcs []uintptr) []runtime.Frame {
+	out := make([]runtime.Frame, 0, len(pcs))
+	// runtime.CallersFrames returns an empty frame when there are no program counters, which happens on runtimes
+	// that can't capture stack traces.
+	if len(pcs) == 0 {
+		return out
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		out = append(out, frame)
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// Stack is a call stack captured by CaptureStack. A Stack can be captured in one place, such as at the start of a
+// request or in a deferred function that calls recover, and used later.
+type Stack struct {
+	cs *callStack
+}
+
+// CaptureStack captures the current call stack. The argument skip is the number of stack frames to skip before
+// recording, with 0 identifying the caller of CaptureStack.
+func CaptureStack(skip int) Stack {
+	return Stack{cs: captureStack(skip + 1)}
+}
+
+// StackFromPCs builds a Stack from program counters returned by runtime.Callers, innermost frame first. Use this to
+// attach a stack captured by other code to an error.
+func StackFromPCs(pcs []uintptr) Stack {
+	pc := make([]uintptr, len(pcs))
+	copy(pc, pcs)
+	return Stack{cs: storeStack(pc)}
+}
+
+// AttachStack wraps an error in an errorStack that uses the provided Stack instead of capturing a new one. Unlike
+// Wrap, AttachStack wraps the error even if there is already an errorStack in the unwrap chain; the attached Stack is
+// the one reported by Trace and %+v. AttachStack returns nil when a nil error is passed in.
+func AttachStack(err error, s Stack) error {
+	if err == nil {
+		return nil
+	}
+	return publish(&errorStack{
+		Err:   err,
+		trace: s.cs,
+	})
+}
+
+// StackTrace returns the call stack frames for the Stack. Since *runtime.Frames tracks its own offset and cannot be
+// reused, StackTrace creates a new instance of *runtime.Frames every time this method runs.
+func (s Stack) StackTrace() *runtime.Frames {
+	return runtime.CallersFrames(s.cs.pcs())
+}
+
+// PCs returns a copy of the program counters in the Stack, innermost frame first.
+func (s Stack) PCs() []uintptr {
+	pcs := s.cs.pcs()
+	out := make([]uintptr, len(pcs))
+	copy(out, pcs)
+	return out
+}
+
+// maxDepth is the maximum number of frames captured for a stack trace.
+const maxDepth = 20
+
+var deferredCapture int32
+
+// SetDeferredCapture turns deferred capture mode on or off. In deferred capture mode, creating an error does nothing
+// but copy the raw program counters into the error, using a single allocation; interning and the shared trace
+// registry are skipped. Use this when errors are created for expected conditions, such as cache misses, and creating
+// them needs to be as cheap as possible. Deferred capture mode is off by default.
+//
+// Stack traces are always symbolized lazily, the first time they are rendered, and the result is cached with the
+// stack trace.
+func SetDeferredCapture(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&deferredCapture, v)
+}
+
+// deferredStack lets a callStack and its program counters be allocated together.
+type deferredStack struct {
+	cs  callStack
+	buf [maxDepth]uintptr
+}
+
+var maxFrames int32
+
+// SetMaxFrames sets the number of frames kept when a stack trace is captured. The frames nearest to where the error
+// was created are kept and the outer ones are discarded, so services that hold many errors in memory, such as in a
+// dedup cache or a batch of results, use less of it. Trace and %+v end the trace with a line that reports how many
+// frames were dropped, such as "[6 frames dropped]". Pass 0 to keep every captured frame, which is the default.
+func SetMaxFrames(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&maxFrames, int32(n))
+}
+
+// capFrames returns the number of the n captured program counters to keep, and the number to drop.
+func capFrames(n int) (int, int) {
+	limit := int(atomic.LoadInt32(&maxFrames))
+	if limit == 0 || n <= limit {
+		return n, 0
+	}
+	return limit, n - limit
+}
+
+// droppedLine reports the number of frames discarded because of SetMaxFrames.
+func droppedLine(n int) string {
+	if n == 1 {
+		return "[1 frame dropped]"
+	}
+	return fmt.Sprintf("[%d frames dropped]", n)
+}
+
+// DroppedFrames returns the number of outer frames that were discarded from the stack trace in the unwrap chain for
+// the error because of SetMaxFrames. It returns 0 if no frames were dropped or there is no stack trace.
+func DroppedFrames(e error) int {
+	se, ok := asStack(e)
+	if !ok {
+		return 0
+	}
+	return se.dropped()
+}
+
+// dropped returns the number of frames discarded from the errorStack's stack trace.
+func (e *errorStack) dropped() int {
+	if e.earlier != nil {
+		return e.earlier.dropped()
+	}
+	if e.trace == nil {
+		return 0
+	}
+	return e.trace.dropped
+}
+
+// captureStack captures the current call stack, skipping skip frames above the caller of captureStack.
+func captureStack(skip int) *callStack {
+	if atomic.LoadInt64(&captureLimit) != 0 {
+		return limitedStack(skip + 1)
+	}
+	return newStack(skip + 1)
+}
+
+// newStack always captures the call stack, skipping skip frames above the caller of newStack.
+func newStack(skip int) *callStack {
+	if atomic.LoadInt32(&deferredCapture) != 0 {
+		d := new(deferredStack)
+		n, dropped := capFrames(runtime.Callers(skip+2, d.buf[:]))
+		d.cs.head = d.buf[:n:n]
+		d.cs.dropped = dropped
+		return &d.cs
+	}
+	// capture into a buffer on the stack and keep an exact-size copy, so a short trace doesn't hold on to maxDepth
+	// program counters for as long as the error lives.
+	var buf [maxDepth]uintptr
+	n, dropped := capFrames(runtime.Callers(skip+2, buf[:]))
+	pc := make([]uintptr, n)
+	copy(pc, buf[:n])
+	cs := storeStack(pc)
+	if dropped > 0 {
+		// the stored callStack can be shared with other errors, so the count goes in a callStack of its own.
+		cs = &callStack{head: cs.head, tail: cs.tail, dropped: dropped}
+	}
+	return cs
+}
@@ -0,0 +1,172 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func captureInHelper() stackerr.Stack {
+	return stackerr.CaptureStack(1)
+}
+
+func TestCaptureStack(t *testing.T) {
+	data := []struct {
+		name     string
+		stack    stackerr.Stack
+		expected string
+	}{
+		{"no skip", stackerr.CaptureStack(0), "github.com/jonbodner/stackerr_test.TestCaptureStack"},
+		{"skip helper", captureInHelper(), "github.com/jonbodner/stackerr_test.TestCaptureStack"},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			frame, _ := v.stack.StackTrace().Next()
+			if frame.Function != v.expected {
+				t.Errorf("expected `%s`, got `%s`", v.expected, frame.Function)
+			}
+			if len(v.stack.PCs()) == 0 {
+				t.Error("expected program counters")
+			}
+		})
+	}
+
+	var empty stackerr.Stack
+	if len(empty.PCs()) != 0 {
+		t.Error("expected no program counters for an empty Stack")
+	}
+}
+
+func TestAttachStack(t *testing.T) {
+	if stackerr.AttachStack(nil, stackerr.CaptureStack(0)) != nil {
+		t.Error("Got non-nil for nil passed to AttachStack")
+	}
+
+	s := captureInHelper()
+	inner := stackerr.New("inner")
+	data := []struct {
+		name string
+		err  error
+	}{
+		{"plain error", errors.New("plain")},
+		{"stacked error", inner},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			err := stackerr.AttachStack(v.err, s)
+			if err.Error() != v.err.Error() {
+				t.Errorf("expected `%s`, got `%s`", v.err.Error(), err.Error())
+			}
+			if !errors.Is(err, v.err) {
+				t.Error("expected attached error to wrap the original error")
+			}
+			if diff := cmp.Diff(stackTraceLines(t, s), traceLines(t, err)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	pcs := make([]uintptr, 20)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	err := stackerr.AttachStack(errors.New("from callers"), stackerr.StackFromPCs(pcs))
+	lines := traceLines(t, err)
+	if !strings.HasPrefix(lines[0], "github.com/jonbodner/stackerr_test.TestAttachStack ") {
+		t.Errorf("unexpected top frame `%s`", lines[0])
+	}
+}
+
+func traceLines(t *testing.T, err error) []string {
+	t.Helper()
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil {
+		t.Fatal(traceErr)
+	}
+	return lines
+}
+
+func stackTraceLines(t *testing.T, s stackerr.Stack) []string {
+	t.Helper()
+	var out []string
+	frames := s.StackTrace()
+	for {
+		frame, more := frames.Next()
+		var b strings.Builder
+		if err := stackerr.StandardFormat.Execute(&b, frame); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b.String())
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+func TestSetDeferredCapture(t *testing.T) {
+	var traces [][]string
+	for _, on := range []bool{false, true} {
+		stackerr.SetDeferredCapture(on)
+		traces = append(traces, traceLines(t, stackerr.New("cache miss")))
+	}
+	stackerr.SetDeferredCapture(false)
+	if diff := cmp.Diff(traces[0], traces[1]); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestEmptyStack(t *testing.T) {
+	// runtimes that can't capture stack traces, such as TinyGo's, produce stacks without program counters.
+	err := stackerr.AttachStack(errors.New("no frames"), stackerr.StackFromPCs(nil))
+	if frames := stackerr.Frames(err); len(frames) != 0 {
+		t.Errorf("expected no frames, got %v", frames)
+	}
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil || len(lines) != 0 {
+		t.Errorf("expected no lines, got %v, %v", lines, traceErr)
+	}
+	if out := fmt.Sprintf("%+v", err); out != "no frames\n" {
+		t.Errorf("expected only the message, got %q", out)
+	}
+}
+
+func TestSetMaxFrames(t *testing.T) {
+	stackerr.SetMaxFrames(1)
+	defer stackerr.SetMaxFrames(0)
+	for _, deferred := range []bool{false, true} {
+		t.Run(fmt.Sprintf("deferred %v", deferred), func(t *testing.T) {
+			stackerr.SetDeferredCapture(deferred)
+			defer stackerr.SetDeferredCapture(false)
+			err := stackerr.New("capped")
+			frames := stackerr.Frames(err)
+			if len(frames) != 1 || frames[0].Function != "github.com/jonbodner/stackerr_test.TestSetMaxFrames.func1" {
+				t.Fatalf("expected only the innermost frame, got %v", frames)
+			}
+			dropped := stackerr.DroppedFrames(err)
+			if dropped == 0 {
+				t.Fatal("expected dropped frames")
+			}
+			lines := traceLines(t, err)
+			expected := fmt.Sprintf("[%d frames dropped]", dropped)
+			if dropped == 1 {
+				expected = "[1 frame dropped]"
+			}
+			if last := lines[len(lines)-1]; last != expected {
+				t.Errorf("expected `%s`, got `%s`", expected, last)
+			}
+			if out := fmt.Sprintf("%+v", err); !strings.HasSuffix(out, "\n"+expected) {
+				t.Errorf("expected %q to end with the dropped line", out)
+			}
+		})
+	}
+
+	stackerr.SetMaxFrames(0)
+	if n := stackerr.DroppedFrames(stackerr.New("full")); n != 0 {
+		t.Errorf("expected no dropped frames, got %d", n)
+	}
+}
@@ -1,20 +1,25 @@
 package stackerr
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"runtime"
-	"strings"
-	"text/template"
 )
 
-// errorStack wraps an error with the stack location where the error occurred.
+// errorStack wraps an error with the stack location where the error occurred. It is always used as a pointer, so
+// wrapping an error doesn't copy it and an error keeps its identity as it moves through the unwrap chain.
 type errorStack struct {
-	Err     error
-	trace   []uintptr
-	earlier *errorStack
+	Err      error
+	trace    *callStack
+	earlier  *errorStack
+	snapshot *RuntimeSnapshot
+	// site is the return address of the call to Errorf when earlier is set.
+	site uintptr
+	// resumed is the stack trace captured by Resume, on the goroutine that received the error, or by AttachLocal.
+	resumed *callStack
+	// remote describes where the error came from when it was returned by AttachLocal.
+	remote *Remote
 }
 
 // StackTrace returns the call stack frames for the errorStack. If this was the first errorStack on
@@ -23,18 +28,18 @@ type errorStack struct {
 //
 //  Since *runtime.Frames tracks its own offset and cannot be reused, StackTrace creates a new instance of
 // *runtime.Frames every time this method runs.
-func (e errorStack) StackTrace() *runtime.Frames {
+func (e *errorStack) StackTrace() *runtime.Frames {
 	if e.earlier != nil {
 		return e.earlier.StackTrace()
 	}
-	return runtime.CallersFrames(e.trace)
+	return runtime.CallersFrames(e.trace.pcs())
 }
 
 // Is provides an implementation of the Is method to support the errors.Is() function. This allows two errorStack
 // instances to be compared to each other using errors.Is. Both errorStack instances need to be unwrapped because the
 // trace field and the earlier field are not relevant for the comparison.
-func (e errorStack) Is(err error) bool {
-	if err, ok := err.(errorStack); ok {
+func (e *errorStack) Is(err error) bool {
+	if err, ok := err.(*errorStack); ok {
 		return errors.Is(e.Err, err.Err)
 	}
 	return false
@@ -44,77 +49,147 @@ func (e errorStack) Is(err error) bool {
 // the error was first created or returned from third-party code. If there is already an errorStack
 // in the error chain, Wrap returns the passed-in error. Wrap returns nil when a nil error is passed in.
 func Wrap(err error) error {
+	return wrap(err, 1)
+}
+
+// wrap implements Wrap, skipping skip frames above the caller of wrap when capturing the stack trace.
+func wrap(err error, skip int) error {
+	return defaultFactory.wrap(err, skip+1)
+}
+
+// wrap implements Wrap for the Factory, skipping skip frames above the caller of wrap when capturing the stack trace.
+func (f *Factory) wrap(err error, skip int) error {
 	if err == nil {
 		return nil
 	}
-	var se errorStack
-	if errors.As(err, &se) {
+	if _, ok := asStack(err); ok {
 		return err
 	}
-	return errorStack{
-		Err:   err,
-		trace: buildStackTrace(),
-	}
+	return publish(&errorStack{
+		Err:      err,
+		trace:    f.stack(skip + 1),
+		snapshot: takeSnapshot(),
+	})
+}
+
+// Rewrap wraps err in an errorStack with a new stack trace, even if there is already an errorStack in the unwrap
+// chain. Use it when an error is reused after the code that created it has returned, such as an error read from a
+// cache or a channel, and the current call site is the one that matters. The new stack trace is the one reported by
+// Trace and %+v; the earlier one can still be found with TraceOf or StackedErrors. Rewrap returns nil when a nil
+// error is passed in.
+func Rewrap(err error) error {
+	return defaultFactory.rewrap(err, 1)
 }
 
-func buildStackTrace() []uintptr {
-	pc := make([]uintptr, 20)
-	n := runtime.Callers(3, pc)
-	pc = pc[:n]
-	return pc
+// rewrap implements Rewrap for the Factory, skipping skip frames above the caller of rewrap when capturing the stack
+// trace.
+func (f *Factory) rewrap(err error, skip int) error {
+	if err == nil {
+		return nil
+	}
+	return publish(&errorStack{
+		Err:      err,
+		trace:    f.stack(skip + 1),
+		snapshot: takeSnapshot(),
+	})
 }
 
 // New builds a errorStack out of a string
 func New(msg string) error {
-	return errorStack{
-		Err:   errors.New(msg),
-		trace: buildStackTrace(),
-	}
+	return defaultFactory.rewrap(errors.New(msg), 1)
+}
+
+// NewBare returns an error with the message and no stack trace, for defining package-level sentinel errors:
+//
+//	var ErrNotFound = stackerr.NewBare("not found")
+//
+// A stack trace captured by New when the package is initialized only points at the variable declaration, and it
+// would be shared by every error that wraps the sentinel. Instead, call Wrap on the sentinel where it is returned, so
+// the stack trace shows the call path that led to it. Each call to NewBare returns a distinct error that is only equal
+// to itself, so errors.Is finds the sentinel in the unwrap chain of a wrapped error, and a comparison with == works
+// when the sentinel is returned as is.
+func NewBare(msg string) error {
+	return errors.New(msg)
 }
 
 // Errorf wraps the error returned by fmt.Errorf in an errorStack. If there is an existing errorStack
-// in the unwrap chain, its stack trace is used.
+// in the unwrap chain, its stack trace is used. The existing errorStack stays in the unwrap chain of the new error,
+// since fmt.Errorf only wraps errors passed with %w, so errors.Is and errors.As still find it and everything it wraps.
 func Errorf(format string, vals ...interface{}) error {
+	return defaultFactory.errorf(1, format, vals...)
+}
+
+// errorf implements Errorf for the Factory, skipping skip frames above the caller of errorf when capturing the stack
+// trace or the call site.
+func (f *Factory) errorf(skip int, format string, vals ...interface{}) error {
 	err := fmt.Errorf(format, vals...)
-	out := errorStack{
+	out := &errorStack{
 		Err: err,
 	}
 	// it's possible that there was already an errorStack in the unwrap chain of the error returned
 	// by fmt.Errorf. If so, set the earlier field in the new errorStack to refer to it. Otherwise,
 	// create a new stack trace.
-	var st errorStack
-	if errors.As(err, &st) {
+	if st, ok := asStack(err); ok {
 		if st.earlier != nil {
 			out.earlier = st.earlier
 		} else {
-			out.earlier = &st
+			out.earlier = st
 		}
+		var pc [1]uintptr
+		runtime.Callers(skip+2, pc[:])
+		out.site = pc[0]
 	} else {
-		out.trace = buildStackTrace()
+		out.trace = f.stack(skip + 1)
+		out.snapshot = takeSnapshot()
+		return publish(out)
 	}
 	return out
 }
 
 // Unwrap exposes the error wrapped by errorStack
-func (e errorStack) Unwrap() error {
+func (e *errorStack) Unwrap() error {
 	return e.Err
 }
 
 // Error returns the error string for the wrapped error.
-func (e errorStack) Error() string {
+func (e *errorStack) Error() string {
 	return e.Err.Error()
 }
 
-// Format controls the optional display of the stack trace. Use %+v to output the stack trace, use %v or %s to output
-// the wrapped error only, use %q to get a single-quoted character literal safely escaped with Go syntax for the wrapped
-// error.
-func (e errorStack) Format(s fmt.State, verb rune) {
+// Format controls the optional display of the stack trace. Use %+v to output the stack trace, or a precision such as
+// %+.5v to output only the innermost 5 frames, followed by a line with the number of frames left out. The flag comes
+// before the precision; %.5+v is not valid fmt syntax. Use %v or %s to output the wrapped error only, use %q to get a
+// single-quoted character literal safely escaped with Go syntax for the wrapped error. Format is safe to call from
+// several goroutines at once, such as when more than one layer logs the same error.
+func (e *errorStack) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			fmt.Fprintf(s, "%+v\n", e.Unwrap())
-			trace, _ := Trace(e, StandardFormat)
-			fmt.Fprintf(s, "%s", strings.Join(trace, "\n"))
+			o := currentFormatOptions()
+			if p, ok := s.Precision(); ok {
+				limited := *o
+				limited.maxFrames, limited.limitFrames = p, true
+				o = &limited
+			}
+			switch o.layout {
+			case layoutChained:
+				e.writeChained(s, o)
+				return
+			case layoutCausedBy:
+				e.writeCausedBy(s, o)
+				return
+			}
+			if e.resumed != nil {
+				// the wrapped error's own %+v would repeat the frames that are rendered first.
+				io.WriteString(s, e.Error()) // nolint: errcheck
+			} else {
+				fmt.Fprintf(s, "%+v", e.Unwrap())
+			}
+			trace, _ := e.renderTrace(StandardFormat, o)
+			io.WriteString(s, o.separator+o.joinFrames(trace)) // nolint: errcheck
+			if o.trailingNewline {
+				io.WriteString(s, "\n") // nolint: errcheck
+			}
 			return
 		}
 		io.WriteString(s, e.Error()) // nolint: errcheck
@@ -125,37 +200,57 @@ func (e errorStack) Format(s fmt.State, verb rune) {
 	}
 }
 
-// StandardFormat is the default template used to convert a *runtime.Frame to a string. Each entry is formatted as
-// "FUNCTION_NAME (FILE_NAME:LINE_NUMBER)"
-var StandardFormat = template.Must(template.New("standardFormat").Parse("{{.Function}} ({{.File}}:{{.Line}})"))
-
-// Trace returns the stack trace information as a slice of strings formatted using the provided Go template. The valid
-// fields in the template are Function, File, and Line. See StandardFormat for an example.
-func Trace(e error, t *template.Template) ([]string, error) {
-	var se errorStack
-	if !errors.As(e, &se) {
+// traceLines implements Trace.
+func traceLines(e error, t *frameTemplate, opts []Option) ([]string, error) {
+	se, ok := asStack(e)
+	if !ok {
 		return nil, nil
 	}
-	s := make([]string, 0, 20)
-	frames := se.StackTrace()
-	var b bytes.Buffer
-	for {
-		b.Reset()
-		frame, more := frames.Next()
-		err := t.Execute(&b, frame)
-		if err != nil {
-			return nil, Wrap(err)
-		}
-		s = append(s, b.String())
-		if !more {
-			break
+	if t == nil {
+		t = StandardFormat
+	}
+	return se.renderTrace(t, newRenderOptions(opts))
+}
+
+// renderTrace renders the errorStack's stack trace with the template and options. For an error returned by Resume,
+// the frames from the goroutine that received the error follow a boundaryLine. For an error returned by AttachLocal,
+// the decoded frames follow the remote header and the local frames follow a localLine.
+func (e *errorStack) renderTrace(t *frameTemplate, o *renderOptions) ([]string, error) {
+	lines, err := render(e.frames(), t, o)
+	if err != nil {
+		return nil, err
+	}
+	if n := e.dropped(); n > 0 {
+		if o.outermostFirst {
+			lines = append([]string{droppedLine(n)}, lines...)
+		} else {
+			lines = append(lines, droppedLine(n))
 		}
 	}
-	return s, nil
+	if e.resumed == nil {
+		return lines, nil
+	}
+	resumed, err := render(dropHelpers(e.resumed.frames()), t, o)
+	if err != nil {
+		return nil, err
+	}
+	if e.remote != nil {
+		out := append([]string{e.remote.header()}, lines...)
+		return append(append(out, localLine), resumed...), nil
+	}
+	return append(append(lines, boundaryLine), resumed...), nil
+}
+
+// frames returns the frames of the errorStack's stack trace, with any leading helper frames removed.
+func (e *errorStack) frames() []runtime.Frame {
+	if e.earlier != nil {
+		return e.earlier.frames()
+	}
+	return dropHelpers(e.trace.frames())
 }
 
 // HasStack returns true if there is a stack trace in the unwrap chain for the error.
 func HasStack(e error) bool {
-	var se errorStack
-	return errors.As(e, &se)
+	_, ok := asStack(e)
+	return ok
 }
@@ -3,6 +3,8 @@ package stackerr_test
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"text/template"
 
@@ -214,6 +216,138 @@ func TestErrorStack_Is(t *testing.T) {
 	}
 }
 
+type codeError struct {
+	code int
+}
+
+func (ce *codeError) Error() string {
+	return fmt.Sprintf("code %d", ce.code)
+}
+
+func TestErrorfEarlierInChain(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	inner := stackerr.Wrap(&codeError{code: 3})
+	mid := stackerr.Errorf("mid: %w", inner)
+	outer := stackerr.Errorf("outer: %w", mid)
+	joined := stackerr.Errorf("joined: %w, %w", sentinel, outer)
+
+	for _, err := range []error{mid, outer, joined} {
+		t.Run(err.Error(), func(t *testing.T) {
+			if !errors.Is(err, inner) {
+				t.Error("expected errors.Is to find the earlier errorStack")
+			}
+			if err != mid && !errors.Is(err, mid) {
+				t.Error("expected errors.Is to find the errorStack in the middle")
+			}
+			var ce *codeError
+			if !errors.As(err, &ce) || ce.code != 3 {
+				t.Error("expected errors.As to find the error wrapped by the earlier errorStack")
+			}
+			if diff := cmp.Diff(traceLines(t, inner), traceLines(t, err)); diff != "" {
+				t.Error(diff)
+			}
+			stacked := stackerr.StackedErrors(err)
+			if len(stacked) != 1 || stacked[0] != inner {
+				t.Errorf("expected only the earlier errorStack to have captured a trace, got %v", stacked)
+			}
+		})
+	}
+	if !errors.Is(joined, sentinel) {
+		t.Error("expected errors.Is to find the sentinel")
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	if stackerr.Rewrap(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+	cached := stackerr.New("cached")
+	err := func() error {
+		return stackerr.Rewrap(cached)
+	}()
+	if err.Error() != "cached" || !errors.Is(err, cached) {
+		t.Errorf("expected the rewrapped error to match the original, got %v", err)
+	}
+	if f := topFrame(t, err); !strings.HasPrefix(f, "github.com/jonbodner/stackerr_test.TestRewrap.func1 ") {
+		t.Errorf("expected a new stack trace starting at the caller, got %s", f)
+	}
+	if diff := cmp.Diff(traceLines(t, cached), func() []string {
+		lines, _ := stackerr.TraceOf(err, errors.Unwrap(cached), stackerr.StandardFormat)
+		return lines
+	}()); diff != "" {
+		t.Error(diff)
+	}
+	if stacked := stackerr.StackedErrors(err); len(stacked) != 2 {
+		t.Errorf("expected both stack traces to be in the tree, got %d", len(stacked))
+	}
+}
+
+type valueError struct {
+	msg string
+}
+
+func (ve valueError) Error() string {
+	return ve.msg
+}
+
+type pointerError struct {
+	msg string
+}
+
+func (pe *pointerError) Error() string {
+	return pe.msg
+}
+
+func TestErrorStackTargets(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	data := []struct {
+		name string
+		err  error
+	}{
+		{"value", stackerr.Wrap(valueError{msg: "value"})},
+		{"pointer", stackerr.Wrap(&pointerError{msg: "pointer"})},
+		{"errorf value", stackerr.Errorf("wrapped: %w", valueError{msg: "value"})},
+		{"errorf pointer", stackerr.Errorf("wrapped: %w", &pointerError{msg: "pointer"})},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			var ve valueError
+			var pe *pointerError
+			if !errors.As(v.err, &ve) && !errors.As(v.err, &pe) {
+				t.Error("expected errors.As to find the wrapped error")
+			}
+			if !errors.Is(v.err, v.err) {
+				t.Error("expected the error to match itself")
+			}
+			if stackerr.Wrap(v.err) != v.err {
+				t.Error("expected Wrap to return the same error")
+			}
+			if stackerr.Errorf("again: %w", v.err) == v.err {
+				t.Error("expected Errorf to return a new error")
+			}
+		})
+	}
+
+	// two errorStacks match when the errors they wrap match, even though their stack traces are different.
+	a := stackerr.Wrap(sentinel)
+	b := func() error {
+		return stackerr.Wrap(sentinel)
+	}()
+	if !errors.Is(a, b) || !errors.Is(b, a) || !errors.Is(a, sentinel) {
+		t.Error("expected errorStacks that wrap the same error to match")
+	}
+	if errors.Is(a, stackerr.New("sentinel")) {
+		t.Error("expected errorStacks that wrap different errors not to match")
+	}
+
+	// the earlier errorStack is the same one that is in the unwrap chain.
+	inner := stackerr.New("inner")
+	earlier, _ := stackerr.Earlier(stackerr.Errorf("outer: %w", inner))
+	if earlier != inner {
+		t.Error("expected Earlier to return the error from the unwrap chain")
+	}
+}
+
 func TestErrorPrinting(t *testing.T) {
 	err := stackerr.New("error message")
 	err2 := stackerr.Errorf("wrapped %w", err)
@@ -294,6 +428,27 @@ func TestWithStackNil(t *testing.T) {
 	}
 }
 
+var errBare = stackerr.NewBare("bare")
+
+func TestNewBare(t *testing.T) {
+	if stackerr.HasStack(errBare) {
+		t.Error("expected no stack trace")
+	}
+	if errBare.Error() != "bare" {
+		t.Errorf("expected `bare`, got `%s`", errBare.Error())
+	}
+	if errBare == stackerr.NewBare("bare") || errors.Is(errBare, stackerr.NewBare("bare")) {
+		t.Error("expected sentinels with the same message not to match")
+	}
+	wrapped := stackerr.Wrap(errBare)
+	if !errors.Is(wrapped, errBare) {
+		t.Error("expected errors.Is to find the sentinel")
+	}
+	if top := topFrame(t, wrapped); !strings.HasPrefix(top, "github.com/jonbodner/stackerr_test.TestNewBare ") {
+		t.Errorf("expected the stack trace to start where the sentinel was wrapped, got %s", top)
+	}
+}
+
 func TestHasStack(t *testing.T) {
 	e := errors.New("innermost error")
 	s := stackerr.Wrap(e)
@@ -308,4 +463,74 @@ func TestHasStack(t *testing.T) {
 	if !stackerr.HasStack(f) {
 		t.Error("f does have a stack trace")
 	}
+	if !stackerr.HasStack(errors.Join(e, f)) {
+		t.Error("a joined error with f does have a stack trace")
+	}
+	if !stackerr.HasStack(asStacked{err: s}) {
+		t.Error("an error whose As method finds s does have a stack trace")
+	}
+	if as := (asStacked{err: s}); stackerr.Wrap(as) != error(as) {
+		t.Error("expected Wrap to return the error that was passed in")
+	}
+}
+
+// asStacked hides err from Unwrap, and only exposes it through its As method.
+type asStacked struct {
+	err error
+}
+
+func (as asStacked) Error() string {
+	return as.err.Error()
+}
+
+func (as asStacked) As(target interface{}) bool {
+	return errors.As(as.err, target)
+}
+
+func TestConcurrentFormatting(t *testing.T) {
+	// the frames for an error are symbolized and cached the first time they are needed, so formatting a new error from
+	// several goroutines at once exercises the caches. Run with -race to check them.
+	makers := []struct {
+		name string
+		make func() error
+	}{
+		{"new", func() error { return stackerr.New("shared") }},
+		{"deferred", func() error {
+			stackerr.SetDeferredCapture(true)
+			defer stackerr.SetDeferredCapture(false)
+			return stackerr.New("shared")
+		}},
+		{"errorf", func() error { return stackerr.Errorf("outer: %w", stackerr.New("shared")) }},
+		{"resumed", func() error {
+			shared := errors.New("shared")
+			return stackerr.Resume(stackerr.Handoff(shared), shared)
+		}},
+		{"report", func() error { return stackerr.FromReport(stackerr.Report(stackerr.New("shared"))) }},
+	}
+	for _, v := range makers {
+		t.Run(v.name, func(t *testing.T) {
+			err := v.make()
+			const workers = 8
+			results := make([]string, workers)
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+					if traceErr != nil {
+						t.Error(traceErr)
+					}
+					results[i] = fmt.Sprintf("%+v|%s|%s|%d|%d", err, strings.Join(lines, "\n"),
+						stackerr.Fingerprint(err), len(stackerr.Frames(err)), len(stackerr.Report(err).Frames))
+				}(i)
+			}
+			wg.Wait()
+			for i := 1; i < workers; i++ {
+				if diff := cmp.Diff(results[0], results[i]); diff != "" {
+					t.Error(diff)
+				}
+			}
+		})
+	}
 }
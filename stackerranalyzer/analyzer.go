@@ -0,0 +1,254 @@
+// Package stackerranalyzer provides go/analysis analyzers that check how a program uses stackerr.
+//
+// It is a separate module so that programs that use stackerr don't depend on golang.org/x/tools. Run the analyzers
+// with the stackerranalyzer command, or add them to a multichecker or to golangci-lint as a plugin.
+package stackerranalyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// stackerrPath is the import path of stackerr. Calls to functions in it or in its subpackages count as wrapping.
+const stackerrPath = "github.com/jonbodner/stackerr"
+
+// Analyzer reports return statements that return an error from another package without wrapping it with stackerr.
+// An error is from another package when it is the result of calling a function or method declared in another
+// package, either directly in the return statement or through a variable that was last assigned from such a call.
+//
+// Packages whose errors don't need to be wrapped can be listed with the -allow flag, as a comma-separated list of
+// import paths or package-qualified function names, such as "io,github.com/ourco/,os.Open". An import path also
+// allows the packages below it, so "io" covers io/fs but not a package named iox. Use it for packages that already
+// wrap their errors and for functions that return sentinel errors.
+var Analyzer = &analysis.Analyzer{
+	Name:     "stackerrwrap",
+	Doc:      "report errors from other packages that are returned without being wrapped by stackerr",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runWrap,
+}
+
+var allow string
+
+func init() {
+	Analyzer.Flags.StringVar(&allow, "allow", "", "comma-separated import paths, with the packages below them, and package-qualified functions whose errors may be returned without wrapping")
+}
+
+// allowList holds the parsed -allow flag.
+type allowList []string
+
+func parseAllowList(s string) allowList {
+	var out allowList
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// allows reports whether errors from the function may be returned without wrapping.
+func (al allowList) allows(fn *types.Func) bool {
+	pkg := fn.Pkg().Path()
+	name := pkg + "." + fn.Name()
+	for _, v := range al {
+		path := strings.TrimSuffix(v, "/")
+		if name == v || pkg == path || strings.HasPrefix(pkg, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func runWrap(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	al := parseAllowList(allow)
+	nodes := []ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}
+	insp.Preorder(nodes, func(n ast.Node) {
+		var ftype *ast.FuncType
+		var body *ast.BlockStmt
+		switch f := n.(type) {
+		case *ast.FuncDecl:
+			ftype, body = f.Type, f.Body
+		case *ast.FuncLit:
+			ftype, body = f.Type, f.Body
+		}
+		if body == nil {
+			return
+		}
+		errIndexes := errorResults(pass, ftype)
+		if len(errIndexes) == 0 {
+			return
+		}
+		c := checker{pass: pass, allow: al, assigns: map[types.Object][]assignment{}}
+		c.collectAssignments(body)
+		c.checkReturns(body, errIndexes)
+	})
+	return nil, nil
+}
+
+// errorResults returns the positions of the function's results that have type error.
+func errorResults(pass *analysis.Pass, ftype *ast.FuncType) []int {
+	if ftype.Results == nil {
+		return nil
+	}
+	var out []int
+	i := 0
+	for _, field := range ftype.Results.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		isErr := types.Identical(pass.TypesInfo.TypeOf(field.Type), errorType)
+		for j := 0; j < count; j++ {
+			if isErr {
+				out = append(out, i)
+			}
+			i++
+		}
+	}
+	return out
+}
+
+// assignment records that a variable was assigned at pos. external is the function from another package whose
+// error was assigned, or nil if the value came from somewhere else.
+type assignment struct {
+	pos      token.Pos
+	external *types.Func
+}
+
+type checker struct {
+	pass    *analysis.Pass
+	allow   allowList
+	assigns map[types.Object][]assignment
+}
+
+// collectAssignments records every assignment to a variable in the function body, skipping nested function
+// literals, which are checked on their own.
+func (c *checker) collectAssignments(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			c.recordAssign(s.Lhs, s.Rhs, s.Pos())
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(s.Names))
+			for i, v := range s.Names {
+				lhs[i] = v
+			}
+			c.recordAssign(lhs, s.Values, s.Pos())
+		}
+		return true
+	})
+}
+
+func (c *checker) recordAssign(lhs, rhs []ast.Expr, pos token.Pos) {
+	for i, v := range lhs {
+		id, ok := v.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := c.pass.TypesInfo.ObjectOf(id)
+		if obj == nil {
+			continue
+		}
+		var value ast.Expr
+		switch {
+		case len(rhs) == len(lhs):
+			value = rhs[i]
+		case len(rhs) == 1:
+			// v, err := f()
+			value = rhs[0]
+		}
+		c.assigns[obj] = append(c.assigns[obj], assignment{pos: pos, external: c.externalCall(value)})
+	}
+}
+
+// externalCall returns the function called by expr if expr is a call to a function or method from another package
+// whose errors need to be wrapped.
+func (c *checker) externalCall(expr ast.Expr) *types.Func {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return nil
+	}
+	path := fn.Pkg().Path()
+	if path == c.pass.Pkg.Path() || path == stackerrPath || strings.HasPrefix(path, stackerrPath+"/") {
+		return nil
+	}
+	if c.allow.allows(fn) {
+		return nil
+	}
+	return fn
+}
+
+func (c *checker) checkReturns(body *ast.BlockStmt, errIndexes []int) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			c.checkReturn(s, errIndexes)
+		}
+		return true
+	})
+}
+
+func (c *checker) checkReturn(s *ast.ReturnStmt, errIndexes []int) {
+	if len(s.Results) == 1 {
+		// return f(), where f returns all of the results.
+		if _, ok := c.pass.TypesInfo.TypeOf(s.Results[0]).(*types.Tuple); ok {
+			if fn := c.externalCall(s.Results[0]); fn != nil {
+				c.report(s.Results[0], fn)
+			}
+			return
+		}
+	}
+	for _, i := range errIndexes {
+		if i >= len(s.Results) {
+			return
+		}
+		expr := ast.Unparen(s.Results[i])
+		if fn := c.externalCall(expr); fn != nil {
+			c.report(expr, fn)
+			continue
+		}
+		id, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if fn := c.lastAssignment(c.pass.TypesInfo.ObjectOf(id), s.Pos()); fn != nil {
+			c.report(expr, fn)
+		}
+	}
+}
+
+// lastAssignment returns the external function for the last assignment to obj before pos.
+func (c *checker) lastAssignment(obj types.Object, pos token.Pos) *types.Func {
+	var last *assignment
+	for i, v := range c.assigns[obj] {
+		if v.pos < pos && (last == nil || v.pos > last.pos) {
+			last = &c.assigns[obj][i]
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	return last.external
+}
+
+func (c *checker) report(expr ast.Expr, fn *types.Func) {
+	c.pass.Reportf(expr.Pos(), "error from %s is returned without being wrapped by stackerr", fn.FullName())
+}
@@ -0,0 +1,25 @@
+package stackerranalyzer_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/jonbodner/stackerr/stackerranalyzer"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), stackerranalyzer.Analyzer, "wrap")
+}
+
+func TestAnalyzerAllow(t *testing.T) {
+	if err := stackerranalyzer.Analyzer.Flags.Set("allow", "io, os.Open, github.com/ourco/"); err != nil {
+		t.Fatal(err)
+	}
+	defer stackerranalyzer.Analyzer.Flags.Set("allow", "") // nolint: errcheck
+	analysistest.Run(t, analysistest.TestData(), stackerranalyzer.Analyzer, "allowed")
+}
+
+func TestMisuseAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), stackerranalyzer.MisuseAnalyzer, "misuse", "nostackerr")
+}
@@ -0,0 +1,14 @@
+// Command stackerranalyzer runs the stackerr analyzers.
+//
+//	go run github.com/jonbodner/stackerr/stackerranalyzer/cmd/stackerranalyzer ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+
+	"github.com/jonbodner/stackerr/stackerranalyzer"
+)
+
+func main() {
+	multichecker.Main(stackerranalyzer.Analyzer, stackerranalyzer.MisuseAnalyzer)
+}
@@ -0,0 +1,158 @@
+package stackerranalyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// MisuseAnalyzer reports two patterns that show up in code that uses stackerr:
+//
+//   - fmt.Errorf with %w in a package that imports stackerr, where stackerr.Errorf adds a stack trace as well.
+//   - stackerr.Wrap called on an error that already has a stack trace because it came straight from stackerr.New,
+//     stackerr.Errorf, stackerr.Wrap, or stackerr.Rewrap, either directly or through a variable that was last
+//     assigned from one of them. Wrap returns such errors unchanged, so the call does nothing.
+var MisuseAnalyzer = &analysis.Analyzer{
+	Name:     "stackerrmisuse",
+	Doc:      "report fmt.Errorf with %w in packages that use stackerr, and redundant calls to stackerr.Wrap",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runMisuse,
+}
+
+// stacking holds the stackerr functions whose results always have a stack trace.
+var stacking = map[string]bool{
+	"New":    true,
+	"Errorf": true,
+	"Wrap":   true,
+	"Rewrap": true,
+}
+
+func runMisuse(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	usesStackerr := false
+	for _, v := range pass.Pkg.Imports() {
+		if v.Path() == stackerrPath {
+			usesStackerr = true
+		}
+	}
+
+	m := misuse{pass: pass, assigns: map[types.Object][]stackAssign{}}
+	insp.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node) {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			m.record(s.Lhs, s.Rhs, s.Pos())
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(s.Names))
+			for i, v := range s.Names {
+				lhs[i] = v
+			}
+			m.record(lhs, s.Values, s.Pos())
+		}
+	})
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			return
+		}
+		switch {
+		case usesStackerr && fn.Pkg().Path() == "fmt" && fn.Name() == "Errorf":
+			m.checkErrorf(call)
+		case fn.Pkg().Path() == stackerrPath && fn.Name() == "Wrap" && len(call.Args) == 1:
+			m.checkWrap(call)
+		}
+	})
+	return nil, nil
+}
+
+// stackAssign records that a variable was assigned at pos, and the stackerr function it was assigned from, if any.
+type stackAssign struct {
+	pos  token.Pos
+	from string
+}
+
+type misuse struct {
+	pass    *analysis.Pass
+	assigns map[types.Object][]stackAssign
+}
+
+func (m *misuse) record(lhs, rhs []ast.Expr, pos token.Pos) {
+	for i, v := range lhs {
+		id, ok := v.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := m.pass.TypesInfo.ObjectOf(id)
+		if obj == nil {
+			continue
+		}
+		var from string
+		if len(rhs) == len(lhs) {
+			from = m.stackingCall(rhs[i])
+		}
+		m.assigns[obj] = append(m.assigns[obj], stackAssign{pos: pos, from: from})
+	}
+}
+
+// stackingCall returns the name of the stackerr function called by expr if its result always has a stack trace.
+func (m *misuse) stackingCall(expr ast.Expr) string {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	fn, ok := typeutil.Callee(m.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != stackerrPath || !stacking[fn.Name()] {
+		return ""
+	}
+	return fn.Name()
+}
+
+func (m *misuse) checkErrorf(call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	tv := m.pass.TypesInfo.Types[call.Args[0]]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	if strings.Contains(constant.StringVal(tv.Value), "%w") {
+		m.pass.Reportf(call.Pos(), "use stackerr.Errorf instead of fmt.Errorf to wrap errors in packages that use stackerr")
+	}
+}
+
+func (m *misuse) checkWrap(call *ast.CallExpr) {
+	arg := ast.Unparen(call.Args[0])
+	from := m.stackingCall(arg)
+	if from == "" {
+		id, ok := arg.(*ast.Ident)
+		if !ok {
+			return
+		}
+		from = m.lastAssignment(m.pass.TypesInfo.ObjectOf(id), call.Pos())
+	}
+	if from != "" {
+		m.pass.Reportf(call.Pos(), "redundant stackerr.Wrap: the error from stackerr.%s already has a stack trace", from)
+	}
+}
+
+// lastAssignment returns the stackerr function for the last assignment to obj before pos.
+func (m *misuse) lastAssignment(obj types.Object, pos token.Pos) string {
+	var last *stackAssign
+	for i, v := range m.assigns[obj] {
+		if v.pos < pos && (last == nil || v.pos > last.pos) {
+			last = &m.assigns[obj][i]
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return last.from
+}
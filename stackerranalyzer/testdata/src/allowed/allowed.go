@@ -0,0 +1,40 @@
+package allowed
+
+import (
+	"io"
+	"io/fs"
+	"iox"
+	"os"
+	"strconv"
+
+	"github.com/ourco/store"
+)
+
+func read(r io.Reader) (int, error) {
+	var buf [8]byte
+	return r.Read(buf[:])
+}
+
+func sub(fsys fs.FS) (fs.FS, error) {
+	return fs.Sub(fsys, "static")
+}
+
+func readAll() ([]byte, error) {
+	return iox.ReadAll() // want `error from iox.ReadAll is returned without being wrapped by stackerr`
+}
+
+func get(key string) (string, error) {
+	return store.Get(key)
+}
+
+func open(name string) (*os.File, error) {
+	return os.Open(name)
+}
+
+func create(name string) (*os.File, error) {
+	return os.Create(name) // want `error from os.Create is returned without being wrapped by stackerr`
+}
+
+func atoi(s string) (int, error) {
+	return strconv.Atoi(s) // want `error from strconv.Atoi is returned without being wrapped by stackerr`
+}
@@ -0,0 +1,11 @@
+package stackerr
+
+func Wrap(err error) error { return err }
+
+func New(msg string) error { return nil }
+
+func Errorf(format string, vals ...interface{}) error { return nil }
+
+func Wrap2[T any](v T, err error) (T, error) { return v, err }
+
+func Rewrap(err error) error { return err }
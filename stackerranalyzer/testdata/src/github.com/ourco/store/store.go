@@ -0,0 +1,7 @@
+package store
+
+import "errors"
+
+func Get(key string) (string, error) {
+	return "", errors.New("store: not found")
+}
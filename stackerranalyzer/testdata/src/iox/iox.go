@@ -0,0 +1,7 @@
+package iox
+
+import "errors"
+
+func ReadAll() ([]byte, error) {
+	return nil, errors.New("iox: not implemented")
+}
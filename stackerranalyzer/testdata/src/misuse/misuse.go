@@ -0,0 +1,40 @@
+package misuse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func errorf(err error) error {
+	return fmt.Errorf("loading: %w", err) // want `use stackerr.Errorf instead of fmt.Errorf to wrap errors in packages that use stackerr`
+}
+
+func errorfNoWrap(name string) error {
+	return fmt.Errorf("no such file: %s", name)
+}
+
+func nested(err error) error {
+	return stackerr.Wrap(stackerr.Wrap(err)) // want `redundant stackerr.Wrap: the error from stackerr.Wrap already has a stack trace`
+}
+
+func afterNew() error {
+	err := stackerr.New("failed")
+	return stackerr.Wrap(err) // want `redundant stackerr.Wrap: the error from stackerr.New already has a stack trace`
+}
+
+func afterErrorf(name string) error {
+	return stackerr.Wrap(stackerr.Errorf("open %s", name)) // want `redundant stackerr.Wrap: the error from stackerr.Errorf already has a stack trace`
+}
+
+func reassigned(name string) error {
+	err := stackerr.New("failed")
+	_, err = os.Open(name)
+	return stackerr.Wrap(err)
+}
+
+func plain() error {
+	return stackerr.Wrap(errors.New("plain"))
+}
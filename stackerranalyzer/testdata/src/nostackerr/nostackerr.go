@@ -0,0 +1,7 @@
+package nostackerr
+
+import "fmt"
+
+func errorf(err error) error {
+	return fmt.Errorf("loading: %w", err)
+}
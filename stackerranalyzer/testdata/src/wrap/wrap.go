@@ -0,0 +1,69 @@
+package wrap
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func direct(name string) error {
+	_, err := os.Open(name)
+	return err // want `error from os.Open is returned without being wrapped by stackerr`
+}
+
+func passThrough(name string) (*os.File, error) {
+	return os.Open(name) // want `error from os.Open is returned without being wrapped by stackerr`
+}
+
+func inline(w io.Writer) error {
+	return w.(io.Closer).Close() // want `error from \(io.Closer\).Close is returned without being wrapped by stackerr`
+}
+
+func wrapped(name string) error {
+	_, err := os.Open(name)
+	return stackerr.Wrap(err)
+}
+
+func wrappedLater(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		err = stackerr.Wrap(err)
+		return 0, err
+	}
+	return n, nil
+}
+
+func wrapped2(name string) (*os.File, error) {
+	return stackerr.Wrap2(os.Open(name))
+}
+
+func local() error {
+	return helper()
+}
+
+func helper() error {
+	return stackerr.New("local")
+}
+
+func method(r io.Reader) (int, error) {
+	var buf [8]byte
+	n, err := r.Read(buf[:])
+	if err != nil {
+		return n, err // want `error from \(io.Reader\).Read is returned without being wrapped by stackerr`
+	}
+	return n, nil
+}
+
+func closure() {
+	_ = func() error {
+		_, err := strconv.Atoi("x")
+		return err // want `error from strconv.Atoi is returned without being wrapped by stackerr`
+	}
+}
+
+func noError(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
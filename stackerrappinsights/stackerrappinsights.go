@@ -0,0 +1,84 @@
+// Package stackerrappinsights converts errors into the exception details that Azure Application Insights records, so
+// the stack trace shown for an exception is the one the error captured rather than the one where it was tracked.
+//
+// The package doesn't import the Application Insights SDK. The types here mirror ExceptionDetails and StackFrame in
+// github.com/microsoft/ApplicationInsights-Go/appinsights/contracts, with the same JSON field names, so their values
+// can be copied into an appinsights.ExceptionTelemetry.
+package stackerrappinsights
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// ExceptionDetails describes an exception, with its stack trace.
+type ExceptionDetails struct {
+	// TypeName is the type of the innermost error in the chain, such as "*errors.errorString".
+	TypeName string `json:"typeName"`
+	// Message is the error's message.
+	Message string `json:"message"`
+	// HasFullStack reports whether ParsedStack holds every captured frame.
+	HasFullStack bool `json:"hasFullStack"`
+	// Stack is the stack trace as text, in the format used by %+v.
+	Stack string `json:"stack,omitempty"`
+	// ParsedStack holds the frames of the stack trace, innermost frame first.
+	ParsedStack []StackFrame `json:"parsedStack,omitempty"`
+}
+
+// StackFrame is a frame in the stack trace of an ExceptionDetails.
+type StackFrame struct {
+	// Level is the position of the frame in the stack trace, starting at 0 for the innermost frame.
+	Level int `json:"level"`
+	// Method is the function name without its package, such as "(*Server).Handle".
+	Method string `json:"method"`
+	// Assembly is the import path of the function's package. Application Insights groups frames by assembly.
+	Assembly string `json:"assembly,omitempty"`
+	// FileName is the file that contains the function.
+	FileName string `json:"fileName,omitempty"`
+	// Line is the line number in the file.
+	Line int `json:"line"`
+}
+
+// Exception converts an error into an ExceptionDetails. The frames come from the error's stack trace; an error
+// without one has no frames. Exception returns nil when a nil error is passed in.
+func Exception(err error) *ExceptionDetails {
+	if err == nil {
+		return nil
+	}
+	d := &ExceptionDetails{
+		TypeName:     reflect.TypeOf(stackerr.Cause(err)).String(),
+		Message:      err.Error(),
+		HasFullStack: true,
+		ParsedStack:  ParsedStack(err),
+	}
+	if lines, _ := stackerr.Trace(err, stackerr.StandardFormat); len(lines) > 0 {
+		d.Stack = strings.Join(lines, "\n")
+	}
+	return d
+}
+
+// ParsedStack returns the frames of the error's stack trace, innermost frame first, split into method and assembly
+// the same way as the Application Insights SDK. Use it to replace the Frames of an appinsights.ExceptionTelemetry,
+// which otherwise holds the stack of the call to NewExceptionTelemetry.
+func ParsedStack(err error) []StackFrame {
+	frames := stackerr.Frames(err)
+	if len(frames) == 0 {
+		return nil
+	}
+	out := make([]StackFrame, len(frames))
+	for i, v := range frames {
+		out[i] = StackFrame{
+			Level:    i,
+			Method:   v.Function,
+			FileName: v.File,
+			Line:     v.Line,
+		}
+		if pkg := v.Package(); pkg != v.Function {
+			out[i].Assembly = pkg
+			out[i].Method = v.Function[len(pkg)+1:]
+		}
+	}
+	return out
+}
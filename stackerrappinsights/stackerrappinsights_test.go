@@ -0,0 +1,71 @@
+package stackerrappinsights_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrappinsights"
+)
+
+func TestException(t *testing.T) {
+	if stackerrappinsights.Exception(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	_, openErr := os.Open("/does/not/exist")
+	err := stackerr.Errorf("load config: %w", openErr)
+	d := stackerrappinsights.Exception(err)
+	// the innermost error of an *fs.PathError from os.Open is the syscall error.
+	if d.TypeName != "syscall.Errno" {
+		t.Errorf("unexpected type name %q", d.TypeName)
+	}
+	if d.Message != err.Error() || !d.HasFullStack {
+		t.Errorf("unexpected details %+v", d)
+	}
+	lines, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	if d.Stack != strings.Join(lines, "\n") {
+		t.Errorf("unexpected stack %q", d.Stack)
+	}
+	frames := stackerr.Frames(err)
+	expected := stackerrappinsights.StackFrame{
+		Level:    0,
+		Method:   "TestException",
+		Assembly: "github.com/jonbodner/stackerr/stackerrappinsights_test",
+		FileName: frames[0].File,
+		Line:     frames[0].Line,
+	}
+	if diff := cmp.Diff(expected, d.ParsedStack[0]); diff != "" {
+		t.Error(diff)
+	}
+	if len(d.ParsedStack) != len(frames) || d.ParsedStack[1].Level != 1 {
+		t.Errorf("expected a frame for each frame of the trace, got %+v", d.ParsedStack)
+	}
+
+	data, _ := json.Marshal(stackerrappinsights.Exception(errors.New("no stack")))
+	if diff := cmp.Diff(`{"typeName":"*errors.errorString","message":"no stack","hasFullStack":true}`, string(data)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParsedStack(t *testing.T) {
+	err := stackerr.NewFactory(stackerr.FixedStack(
+		stackerr.Frame{Function: "github.com/ourco/app/store.(*DB).Load", File: "/src/app/store/db.go", Line: 10},
+		stackerr.Frame{Function: "main.main", File: "/src/app/main.go", Line: 3},
+	)).New("boom")
+	expected := []stackerrappinsights.StackFrame{
+		{Level: 0, Method: "(*DB).Load", Assembly: "github.com/ourco/app/store", FileName: "/src/app/store/db.go", Line: 10},
+		{Level: 1, Method: "main", Assembly: "main", FileName: "/src/app/main.go", Line: 3},
+	}
+	if diff := cmp.Diff(expected, stackerrappinsights.ParsedStack(err)); diff != "" {
+		t.Error(diff)
+	}
+	if stackerrappinsights.ParsedStack(errors.New("no stack")) != nil {
+		t.Error("expected no frames for an error without a stack trace")
+	}
+}
@@ -0,0 +1,176 @@
+// Package stackerrconnect carries the stack traces of errors from ConnectRPC handlers to their clients.
+//
+// It is a separate module so that programs that don't use ConnectRPC don't depend on it. On the server, the
+// interceptor returned by NewInterceptor adds the error's LogReport to the Connect error as an error detail. On the
+// client, the same interceptor turns the detail back into an error with the server's stack trace. The detail is a
+// google.protobuf.Struct with a single "stackerr" field that holds the report, so tools that don't know about
+// stackerr can still show it.
+package stackerrconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrhttp"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerrconnect")
+}
+
+// detailField is the field of the google.protobuf.Struct detail that holds the LogReport.
+const detailField = "stackerr"
+
+// NewInterceptor returns an interceptor for both handlers and clients, to pass to connect.WithInterceptors.
+//
+// In a handler, an error returned by a unary or streaming RPC gets the request ID from the request headers, as with
+// stackerrhttp.WithRequestID, and an error detail with its LogReport. Errors that aren't a *connect.Error are wrapped
+// in one with the code for their category (see ToError). Only install it on handlers whose clients you trust with the
+// server's stack traces, such as calls between your own services.
+//
+// In a client, an error returned by a unary RPC or received from a stream that has the detail is replaced by an error
+// with the handler's message and frames, followed by the client's own stack trace (see stackerr.AttachLocal). The
+// *connect.Error is still in the unwrap tree, so connect.CodeOf works as before.
+func NewInterceptor() connect.Interceptor {
+	return interceptor{}
+}
+
+type interceptor struct{}
+
+func (interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Spec().IsClient {
+			return resp, FromError(err, req.Spec().Procedure)
+		}
+		return resp, ToError(stackerrhttp.WithRequestID(err, req.Header()))
+	}
+}
+
+func (interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return clientConn{StreamingClientConn: next(ctx, spec)}
+	}
+}
+
+// clientConn rebuilds the errors received from a streaming handler, like WrapUnary does for unary calls.
+type clientConn struct {
+	connect.StreamingClientConn
+}
+
+func (cc clientConn) Receive(msg interface{}) error {
+	return FromError(cc.StreamingClientConn.Receive(msg), cc.Spec().Procedure)
+}
+
+func (interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := next(ctx, conn); err != nil {
+			return ToError(stackerrhttp.WithRequestID(err, conn.RequestHeader()))
+		}
+		return nil
+	}
+}
+
+// ToError returns a *connect.Error for err with an error detail that holds the error's LogReport. If there is a
+// *connect.Error in the unwrap chain, the detail is added to it; otherwise err is wrapped in one with the code for its
+// category from stackerr.GRPCCodeFor, which is connect.CodeUnknown if it has none. ToError returns nil when a nil
+// error is passed in.
+func ToError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		ce = connect.NewError(connect.Code(stackerr.GRPCCodeFor(err)), err)
+	}
+	if detail, detailErr := newDetail(err); detailErr == nil {
+		ce.AddDetail(detail)
+	}
+	return ce
+}
+
+// newDetail converts the error's LogReport into an error detail.
+func newDetail(err error) (*connect.ErrorDetail, error) {
+	data, jsonErr := json.Marshal(stackerr.Report(err))
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	var report map[string]interface{}
+	if jsonErr := json.Unmarshal(data, &report); jsonErr != nil {
+		return nil, jsonErr
+	}
+	s, structErr := structpb.NewStruct(map[string]interface{}{detailField: report})
+	if structErr != nil {
+		return nil, structErr
+	}
+	return connect.NewErrorDetail(s)
+}
+
+// remoteError keeps the *connect.Error from a client call in the unwrap tree, next to the error rebuilt from its
+// detail.
+type remoteError struct {
+	connectErr *connect.Error
+	remote     error
+}
+
+func (re remoteError) Error() string {
+	return re.connectErr.Error()
+}
+
+// Unwrap returns the rebuilt error first, so that stackerr finds its stack trace.
+func (re remoteError) Unwrap() []error {
+	return []error{re.remote, re.connectErr}
+}
+
+// FromError looks for the error detail added by ToError in the *connect.Error in err's unwrap chain. If there is one,
+// FromError returns an error whose stack trace has the handler's frames, under a "remote:" line for the procedure,
+// followed by the current stack trace. Otherwise it returns err.
+func FromError(err error, procedure string) error {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		return err
+	}
+	for _, d := range ce.Details() {
+		remote, ok := fromDetail(d)
+		if ok {
+			return remoteError{
+				connectErr: ce,
+				remote:     stackerr.AttachLocal(remote, stackerr.Remote{Service: procedure}),
+			}
+		}
+	}
+	return err
+}
+
+// fromDetail rebuilds the error from an error detail added by ToError.
+func fromDetail(d *connect.ErrorDetail) (error, bool) {
+	v, valueErr := d.Value()
+	if valueErr != nil {
+		return nil, false
+	}
+	s, ok := v.(*structpb.Struct)
+	if !ok {
+		return nil, false
+	}
+	field, ok := s.GetFields()[detailField]
+	if !ok {
+		return nil, false
+	}
+	data, jsonErr := field.MarshalJSON()
+	if jsonErr != nil {
+		return nil, false
+	}
+	remote, decodeErr := stackerr.DecodeJSON(data)
+	if decodeErr != nil {
+		return nil, false
+	}
+	return remote, true
+}
@@ -0,0 +1,101 @@
+package stackerrconnect_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrconnect"
+)
+
+const procedure = "/test.v1.TestService/Fail"
+
+func failHandler(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+	return nil, stackerr.New("handler failed")
+}
+
+func TestInterceptor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, failHandler,
+		connect.WithInterceptors(stackerrconnect.NewInterceptor())))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+procedure,
+		connect.WithInterceptors(stackerrconnect.NewInterceptor()))
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if connect.CodeOf(err) != connect.CodeUnknown {
+		t.Errorf("expected code unknown, got %s", connect.CodeOf(err))
+	}
+	lines, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	if len(lines) < 3 || lines[0] != "remote: "+procedure || !strings.HasPrefix(lines[1], "github.com/jonbodner/stackerr/stackerrconnect_test.failHandler ") {
+		t.Errorf("expected the handler's frames, got %v", lines)
+	}
+}
+
+const streamProcedure = "/test.v1.TestService/FailStream"
+
+func failStreamHandler(ctx context.Context, req *connect.Request[emptypb.Empty], stream *connect.ServerStream[emptypb.Empty]) error {
+	if err := stream.Send(&emptypb.Empty{}); err != nil {
+		return err
+	}
+	return stackerr.New("stream failed")
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, failStreamHandler,
+		connect.WithInterceptors(stackerrconnect.NewInterceptor())))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+streamProcedure,
+		connect.WithInterceptors(stackerrconnect.NewInterceptor()))
+	stream, err := client.CallServerStream(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := 0
+	for stream.Receive() {
+		received++
+	}
+	if received != 1 {
+		t.Errorf("expected one message, got %d", received)
+	}
+	err = stream.Err()
+	if connect.CodeOf(err) != connect.CodeUnknown {
+		t.Errorf("expected code unknown, got %s", connect.CodeOf(err))
+	}
+	lines, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	if len(lines) < 3 || lines[0] != "remote: "+streamProcedure || !strings.HasPrefix(lines[1], "github.com/jonbodner/stackerr/stackerrconnect_test.failStreamHandler ") {
+		t.Errorf("expected the handler's frames, got %v", lines)
+	}
+}
+
+func TestFromErrorWithoutDetail(t *testing.T) {
+	for _, err := range []error{
+		errors.New("plain"),
+		connect.NewError(connect.CodeNotFound, errors.New("not found")),
+	} {
+		if out := stackerrconnect.FromError(err, procedure); out != err {
+			t.Errorf("expected %q to be returned unchanged, got %q", err, out)
+		}
+	}
+	if stackerrconnect.ToError(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+	var ce *connect.Error
+	if err := stackerrconnect.ToError(errors.New("plain")); !errors.As(err, &ce) || len(ce.Details()) != 1 {
+		t.Errorf("expected a *connect.Error with a detail, got %v", err)
+	}
+}
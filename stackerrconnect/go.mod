@@ -0,0 +1,11 @@
+module github.com/jonbodner/stackerr/stackerrconnect
+
+go 1.21
+
+replace github.com/jonbodner/stackerr => ../
+
+require (
+	connectrpc.com/connect v1.18.1
+	github.com/jonbodner/stackerr v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.36.5
+)
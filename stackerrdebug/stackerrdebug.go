@@ -0,0 +1,102 @@
+// Package stackerrdebug serves the most recent errors with stack traces created in the process, for debugging
+// environments where you can't read the logs.
+//
+// Like net/http/pprof, importing the package registers its handler with http.DefaultServeMux, at /debug/errors:
+//
+//	import _ "github.com/jonbodner/stackerr/stackerrdebug"
+//
+// It also turns on the buffer of recent errors with stackerr.SetRecentErrors, keeping Size errors; call
+// SetRecentErrors in main to keep a different number.
+//
+// If your program doesn't serve http.DefaultServeMux, mount the handler returned by Handler on your own mux. Only
+// serve it where you would serve pprof: the page shows error messages and stack traces in full.
+//
+// The page is HTML, or JSON when the request has format=json in its query or accepts application/json. The fingerprint
+// query parameter keeps only the errors with that fingerprint, and the package parameter keeps only the errors with a
+// frame in that package or one of the packages under it.
+package stackerrdebug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// Size is the number of errors kept for the handler.
+const Size = 100
+
+func init() {
+	stackerr.SetRecentErrors(Size)
+	http.Handle("/debug/errors", Handler())
+}
+
+// Entry is an error shown by the handler.
+type Entry struct {
+	Time        time.Time        `json:"time"`
+	Message     string           `json:"message"`
+	Fingerprint string           `json:"fingerprint"`
+	Frames      []stackerr.Frame `json:"frames"`
+}
+
+// Handler returns the handler that serves the recent errors.
+func Handler() http.Handler {
+	return handler{}
+}
+
+type handler struct{}
+
+func (handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	entries := filter(stackerr.RecentErrors(), q.Get("fingerprint"), q.Get("package"))
+	if q.Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries) // nolint: errcheck
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.Execute(w, entries) // nolint: errcheck
+}
+
+// filter returns the entries for the errors with the fingerprint and a frame in the package, when they aren't empty.
+func filter(errs []stackerr.RecentError, fingerprint, pkg string) []Entry {
+	out := []Entry{}
+	for _, v := range errs {
+		if fingerprint != "" && v.Fingerprint != fingerprint {
+			continue
+		}
+		frames := stackerr.Frames(v.Err)
+		if pkg != "" && !inPackage(frames, pkg) {
+			continue
+		}
+		out = append(out, Entry{Time: v.Time, Message: v.Err.Error(), Fingerprint: v.Fingerprint, Frames: frames})
+	}
+	return out
+}
+
+func inPackage(frames []stackerr.Frame, pkg string) bool {
+	for _, f := range frames {
+		if p := f.Package(); p == pkg || strings.HasPrefix(p, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><title>/debug/errors</title></head>
+<body>
+<h1>Recent errors</h1>
+{{if not .}}<p>No errors.</p>{{end}}
+{{range .}}<h2>{{.Message}}</h2>
+<p>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}} · <a href="?fingerprint={{.Fingerprint}}">{{.Fingerprint}}</a></p>
+<pre>{{range .Frames}}{{.Function}}
+	{{.File}}:{{.Line}}
+{{end}}</pre>
+{{end}}</body>
+</html>
+`))
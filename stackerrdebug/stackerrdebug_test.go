@@ -0,0 +1,79 @@
+package stackerrdebug_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrdebug"
+)
+
+func get(t *testing.T, url string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(rec, req)
+	return rec
+}
+
+// entries waits for the handler to return n entries for the query.
+func entries(t *testing.T, query string, n int) []stackerrdebug.Entry {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var out []stackerrdebug.Entry
+		rec := get(t, "/debug/errors?format=json"+query, "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) >= n || time.Now().After(deadline) {
+			return out
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	first := stackerr.New("cache miss")
+	second := stackerr.New("upstream timeout")
+
+	all := entries(t, "", 2)
+	if len(all) < 2 || all[0].Message != "upstream timeout" || all[1].Message != "cache miss" {
+		t.Fatalf("expected the errors newest first, got %v", all)
+	}
+	if all[0].Fingerprint != stackerr.Fingerprint(second) || len(all[0].Frames) == 0 {
+		t.Errorf("unexpected entry %v", all[0])
+	}
+
+	byFingerprint := entries(t, "&fingerprint="+stackerr.Fingerprint(first), 1)
+	for _, v := range byFingerprint {
+		if v.Message != "cache miss" {
+			t.Errorf("expected only the first error, got %v", v)
+		}
+	}
+	if byPackage := entries(t, "&package=github.com/jonbodner/stackerr/stackerrdebug_test", 2); len(byPackage) < 2 {
+		t.Errorf("expected both errors, got %v", byPackage)
+	}
+	if byPackage := entries(t, "&package=github.com/jonbodner", 2); len(byPackage) < 2 {
+		t.Errorf("expected both errors for a parent package, got %v", byPackage)
+	}
+	if byPackage := entries(t, "&package=example.com/other", 0); len(byPackage) != 0 {
+		t.Errorf("expected no errors, got %v", byPackage)
+	}
+
+	rec := get(t, "/debug/errors", "application/json")
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON for Accept: application/json, got %q", rec.Header().Get("Content-Type"))
+	}
+	rec = get(t, "/debug/errors", "")
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(rec.Body.String(), "<h2>upstream timeout</h2>") {
+		t.Errorf("unexpected page %q\n%s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
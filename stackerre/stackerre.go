@@ -0,0 +1,111 @@
+// Package stackerre builds structured errors in the style of upspin's errors.E: an operation, a kind, a message, and
+// the error that caused it, passed to E in any order. The errors it returns have a stack trace, and their operation
+// and kind are attached with stackerr.WithOp and stackerr.WithCategory, so stackerr.Ops, stackerr.CategoryOf,
+// stackerr.HTTPStatusFor, and the encoders all see them.
+package stackerre
+
+import (
+	"strings"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerre")
+}
+
+// Op is the name of the operation that failed, such as "users.Get".
+type Op string
+
+// Kind classifies an error. It is the same type as stackerr.Category, so the stackerr.Category constants, such as
+// stackerr.CategoryNotFound, are kinds.
+type Kind = stackerr.Category
+
+// Error is the structured error built by E. Use errors.As to get it back from an error returned by E.
+type Error struct {
+	// Op is the operation that failed. It is empty if none was passed to E.
+	Op Op
+	// Kind classifies the error. It is 0 if none was passed to E.
+	Kind Kind
+	// Msg describes what went wrong. It is empty if no message was passed to E.
+	Msg string
+	// Err is the error that caused this one, or nil.
+	Err error
+}
+
+// Error returns the message and the message of the wrapped error, separated by ": ", leaving out the ones that aren't
+// set. If neither is set, it returns the kind's name. The operation isn't part of the message, since it is attached
+// with stackerr.WithOp; use stackerr.Summary to put the operation path in front of the message.
+func (e *Error) Error() string {
+	var parts []string
+	if e.Msg != "" {
+		parts = append(parts, e.Msg)
+	}
+	if e.Err != nil {
+		parts = append(parts, e.Err.Error())
+	}
+	if len(parts) == 0 && e.Kind != 0 {
+		parts = append(parts, e.Kind.String())
+	}
+	return strings.Join(parts, ": ")
+}
+
+// Unwrap returns the error that caused this one.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// E builds an error from its arguments, which can be passed in any order:
+//
+//   - an Op sets the operation
+//   - a Kind, such as stackerr.CategoryNotFound, sets the kind
+//   - a string sets the message; more than one are joined with ": "
+//   - an error is wrapped
+//
+// For example:
+//
+//	return stackerre.E(stackerre.Op("users.Get"), stackerr.CategoryNotFound, "no such user", err)
+//
+// The result is an *Error with a stack trace captured where E was called, unless the wrapped error already has one.
+// The operation is attached with stackerr.WithOp and the kind with stackerr.WithCategory. When no kind is passed, the
+// kind of the wrapped error is used, if it has one. E returns an error that describes the problem if it is called with
+// no arguments or with an argument of any other type.
+func E(args ...interface{}) error {
+	if len(args) == 0 {
+		return stackerr.New("stackerre: E called with no arguments")
+	}
+	e := &Error{}
+	var msgs []string
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case Op:
+			e.Op = arg
+		case Kind:
+			e.Kind = arg
+		case string:
+			msgs = append(msgs, arg)
+		case error:
+			e.Err = arg
+		default:
+			return stackerr.Errorf("stackerre: E called with an argument of type %T", arg)
+		}
+	}
+	e.Msg = strings.Join(msgs, ": ")
+	if e.Kind == 0 {
+		e.Kind, _ = stackerr.CategoryOf(e.Err)
+	}
+	err := stackerr.Wrap(e)
+	if e.Kind != 0 {
+		err = stackerr.WithCategory(err, e.Kind)
+	}
+	if e.Op != "" {
+		err = stackerr.WithOp(err, string(e.Op))
+	}
+	return err
+}
+
+// Is reports whether the kind of err, from stackerr.CategoryOf, is kind.
+func Is(kind Kind, err error) bool {
+	c, ok := stackerr.CategoryOf(err)
+	return ok && c == kind
+}
@@ -0,0 +1,71 @@
+package stackerre_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerre"
+)
+
+func TestE(t *testing.T) {
+	cause := errors.New("connection refused")
+	inner := stackerre.E(stackerre.Op("db.query"), cause, stackerr.CategoryUnavailable)
+	err := stackerre.E("load user", stackerre.Op("users.Get"), inner)
+
+	if msg := err.Error(); msg != "load user: connection refused" {
+		t.Errorf("unexpected message `%s`", msg)
+	}
+	if s := stackerr.Summary(err); s != "users.Get: db.query: load user: connection refused" {
+		t.Errorf("unexpected summary `%s`", s)
+	}
+	if diff := cmp.Diff([]string{"users.Get", "db.query"}, stackerr.Ops(err)); diff != "" {
+		t.Error(diff)
+	}
+	if !stackerre.Is(stackerr.CategoryUnavailable, err) || stackerre.Is(stackerr.CategoryNotFound, err) {
+		t.Error("expected the kind of the wrapped error")
+	}
+	if status := stackerr.HTTPStatusFor(err); status != 503 {
+		t.Errorf("expected 503, got %d", status)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected errors.Is to find the cause")
+	}
+	var e *stackerre.Error
+	if !errors.As(err, &e) || e.Op != "users.Get" || e.Kind != stackerr.CategoryUnavailable || e.Msg != "load user" || e.Err != inner {
+		t.Errorf("unexpected *Error %+v", e)
+	}
+
+	// the stack trace is the one captured by the innermost call to E, and starts in the test, not in stackerre.
+	frames := stackerr.Frames(err)
+	if len(frames) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	lines, traceErr := stackerr.Trace(err, stackerr.StandardFormat)
+	if traceErr != nil || !strings.HasPrefix(lines[0], "github.com/jonbodner/stackerr/stackerre_test.TestE ") {
+		t.Errorf("expected the trace to start in the test, got %v, %v", lines, traceErr)
+	}
+}
+
+func TestEKindOnly(t *testing.T) {
+	err := stackerre.E(stackerr.CategoryNotFound)
+	if msg := err.Error(); msg != "not_found" {
+		t.Errorf("expected the kind's name, got `%s`", msg)
+	}
+	err = stackerre.E("first", "second")
+	if msg := err.Error(); msg != "first: second" {
+		t.Errorf("expected joined messages, got `%s`", msg)
+	}
+}
+
+func TestEBadArguments(t *testing.T) {
+	if err := stackerre.E(); err == nil || !strings.Contains(err.Error(), "no arguments") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := stackerre.E(42); err == nil || !strings.Contains(err.Error(), "type int") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
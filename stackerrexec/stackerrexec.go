@@ -0,0 +1,108 @@
+// Package stackerrexec wraps the errors from running commands with os/exec with a stack trace, the command's exit
+// code, and the end of its standard error output.
+//
+// The functions in this package are registered as stackerr helpers, so stack traces start at the code that called them.
+package stackerrexec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerrexec")
+}
+
+// CommandError adds details about a failed command to the error it returned. Use errors.As to get it from an error
+// returned by this package.
+type CommandError struct {
+	// Err is the original error, usually an *exec.ExitError or an error from starting the command.
+	Err error
+	// Path is the path of the command that was run.
+	Path string
+	// ExitCode is the exit code of the command, or -1 if the command didn't start or was terminated by a signal.
+	ExitCode int
+	// Stderr holds the last bytes that the command wrote to standard error, if they were captured.
+	Stderr []byte
+}
+
+// Error returns the command path, followed by the original error message.
+func (ce *CommandError) Error() string {
+	return fmt.Sprintf("exec %s: %v", ce.Path, ce.Err)
+}
+
+// Unwrap exposes the original error.
+func (ce *CommandError) Unwrap() error {
+	return ce.Err
+}
+
+// Run runs cmd and waits for it to finish, like cmd.Run. The last n bytes the command writes to standard error are
+// kept; if cmd.Stderr is already set, standard error is still written to it as well. If the command fails, the error
+// is wrapped in a *CommandError with a stack trace.
+func Run(cmd *exec.Cmd, n int) error {
+	tail := &tailBuffer{max: n}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, tail)
+	} else {
+		cmd.Stderr = tail
+	}
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	return wrap(cmd, err, tail.bytes())
+}
+
+// Wrap wraps an error returned by cmd.Run, cmd.Start, cmd.Wait, cmd.Output, or cmd.CombinedOutput in a *CommandError
+// with a stack trace. If the error is an *exec.ExitError from cmd.Output, its Stderr field is kept. Wrap returns nil
+// when a nil error is passed in.
+func Wrap(cmd *exec.Cmd, err error) error {
+	if err == nil {
+		return nil
+	}
+	var stderr []byte
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		stderr = ee.Stderr
+	}
+	return wrap(cmd, err, stderr)
+}
+
+func wrap(cmd *exec.Cmd, err error, stderr []byte) error {
+	ce := &CommandError{
+		Err:      err,
+		Path:     cmd.Path,
+		ExitCode: -1,
+		Stderr:   stderr,
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		ce.ExitCode = ee.ExitCode()
+	}
+	return stackerr.Wrap(ce)
+}
+
+// tailBuffer is an io.Writer that keeps the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	if tb.max <= 0 {
+		return len(p), nil
+	}
+	tb.buf = append(tb.buf, p...)
+	if extra := len(tb.buf) - tb.max; extra > 0 {
+		tb.buf = append(tb.buf[:0], tb.buf[extra:]...)
+	}
+	return len(p), nil
+}
+
+func (tb *tailBuffer) bytes() []byte {
+	return tb.buf
+}
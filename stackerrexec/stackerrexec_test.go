@@ -0,0 +1,75 @@
+package stackerrexec_test
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrexec"
+)
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", "echo 'first line' >&2; echo 'last line' >&2; exit 3")
+	cmd.Stderr = &stderr
+	err := stackerrexec.Run(cmd, 10)
+
+	var ce *stackerrexec.CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a *CommandError, got `%v`", err)
+	}
+	if ce.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", ce.ExitCode)
+	}
+	if string(ce.Stderr) != "last line\n" {
+		t.Errorf("expected `last line\\n`, got `%q`", ce.Stderr)
+	}
+	if stderr.String() != "first line\nlast line\n" {
+		t.Errorf("expected stderr to still be written to cmd.Stderr, got `%q`", stderr.String())
+	}
+	if err.Error() != "exec "+cmd.Path+": exit status 3" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	origin, _ := stackerr.Origin(err)
+	if origin.Function != "github.com/jonbodner/stackerr/stackerrexec_test.TestRun" {
+		t.Errorf("expected stack trace to start in the test, got `%s`", origin.Function)
+	}
+
+	if err = stackerrexec.Run(exec.Command("sh", "-c", "exit 0"), 10); err != nil {
+		t.Errorf("expected nil error, got `%v`", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if stackerrexec.Wrap(exec.Command("true"), nil) != nil {
+		t.Error("Got non-nil for nil passed to Wrap")
+	}
+
+	cmd := exec.Command("/this/command/does/not/exist")
+	err := stackerrexec.Wrap(cmd, cmd.Run())
+	var ce *stackerrexec.CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a *CommandError, got `%v`", err)
+	}
+	if ce.ExitCode != -1 {
+		t.Errorf("expected exit code -1 for a command that didn't start, got %d", ce.ExitCode)
+	}
+
+	if _, lookErr := exec.LookPath("sh"); lookErr != nil {
+		t.Skip("sh is not available")
+	}
+	cmd = exec.Command("sh", "-c", "echo oops >&2; exit 1")
+	_, err = cmd.Output()
+	err = stackerrexec.Wrap(cmd, err)
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a *CommandError, got `%v`", err)
+	}
+	if ce.ExitCode != 1 || string(ce.Stderr) != "oops\n" {
+		t.Errorf("unexpected CommandError %+v", ce)
+	}
+}
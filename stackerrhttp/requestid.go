@@ -0,0 +1,37 @@
+package stackerrhttp
+
+import (
+	"net/http"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// requestIDHeaders are the headers that RequestIDFromHeader looks in, in order.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id", "X-Amzn-RequestId"}
+
+// RequestIDFromHeader returns the request ID from the first of the X-Request-ID, X-Correlation-ID, Request-Id, and
+// X-Amzn-RequestId headers that is set, or an empty string if none of them are.
+func RequestIDFromHeader(h http.Header) string {
+	for _, v := range requestIDHeaders {
+		if id := h.Get(v); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// WithRequestID attaches the request ID from the headers to err with stackerr.WithRequestID, unless err already has a
+// request ID or the headers don't have one. WithRequestID returns nil when a nil error is passed in.
+func WithRequestID(err error, h http.Header) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := stackerr.RequestID(err); ok {
+		return err
+	}
+	id := RequestIDFromHeader(h)
+	if id == "" {
+		return err
+	}
+	return stackerr.WithRequestID(err, id)
+}
@@ -0,0 +1,62 @@
+package stackerrhttp_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrhttp"
+)
+
+func TestRequestIDFromHeader(t *testing.T) {
+	data := []struct {
+		header http.Header
+		id     string
+	}{
+		{http.Header{}, ""},
+		{http.Header{"X-Request-Id": {"abc"}}, "abc"},
+		{http.Header{"X-Correlation-Id": {"def"}}, "def"},
+		{http.Header{"X-Correlation-Id": {"def"}, "X-Request-Id": {"abc"}}, "abc"},
+		{http.Header{"X-Amzn-Requestid": {"ghi"}}, "ghi"},
+	}
+	for _, v := range data {
+		if id := stackerrhttp.RequestIDFromHeader(v.header); id != v.id {
+			t.Errorf("%v: expected %q, got %q", v.header, v.id, id)
+		}
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	if stackerrhttp.WithRequestID(nil, http.Header{"X-Request-Id": {"abc"}}) != nil {
+		t.Error("Got non-nil for nil passed to WithRequestID")
+	}
+	err := stackerrhttp.WithRequestID(errors.New("db down"), http.Header{"X-Request-Id": {"abc"}})
+	if id, _ := stackerr.RequestID(err); id != "abc" {
+		t.Errorf("expected the request ID, got %q", id)
+	}
+	err = stackerrhttp.WithRequestID(err, http.Header{"X-Request-Id": {"other"}})
+	if id, _ := stackerr.RequestID(err); id != "abc" {
+		t.Errorf("expected the first request ID to be kept, got %q", id)
+	}
+
+	var logs bytes.Buffer
+	ew := stackerrhttp.ErrorWriter{Logger: slog.New(slog.NewJSONHandler(&logs, nil))}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	ew.WriteError(httptest.NewRecorder(), req, errors.New("db down"))
+	if !strings.Contains(logs.String(), `"request_id":"req-7"`) {
+		t.Errorf("expected the request ID in the logs, got %s", logs.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	req.Header.Set("X-Request-ID", "req-8")
+	_, err = stackerrhttp.NewTransport(failingTransport{}).RoundTrip(req)
+	if id, _ := stackerr.RequestID(err); id != "req-8" {
+		t.Errorf("expected the request ID on the transport error, got %q", id)
+	}
+}
@@ -0,0 +1,64 @@
+// Package stackerrhttp connects stackerr to net/http.
+//
+// The functions in this package are registered as stackerr helpers, so stack traces start at the code that called them.
+package stackerrhttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerrhttp")
+}
+
+// RequestError adds details about an HTTP request to the error returned by a transport. Only the method, host, and
+// path are recorded; the query string, headers, and body are left out because they can contain secrets. Use
+// errors.As to get it from an error returned by an http.Client that uses a transport from NewTransport.
+type RequestError struct {
+	// Err is the original error.
+	Err    error
+	Method string
+	Host   string
+	Path   string
+}
+
+// Error returns the request details, followed by the original error message.
+func (re *RequestError) Error() string {
+	return fmt.Sprintf("%s %s%s: %v", re.Method, re.Host, re.Path, re.Err)
+}
+
+// Unwrap exposes the original error.
+func (re *RequestError) Unwrap() error {
+	return re.Err
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport returns an http.RoundTripper that calls base and wraps any error it returns (DNS failures, TLS
+// failures, timeouts, and so on) in a *RequestError with a stack trace. The request ID from the outgoing request's
+// headers is attached to the error, as with WithRequestID. If base is nil, http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base: base}
+}
+
+// RoundTrip calls RoundTrip on the base transport.
+func (t transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		return resp, WithRequestID(stackerr.Wrap(&RequestError{
+			Err:    err,
+			Method: r.Method,
+			Host:   r.URL.Host,
+			Path:   r.URL.Path,
+		}), r.Header)
+	}
+	return resp, nil
+}
@@ -0,0 +1,51 @@
+package stackerrhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrhttp"
+)
+
+var errDial = errors.New("dial failed")
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errDial
+}
+
+type okTransport struct{}
+
+func (okTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+}
+
+func TestNewTransport(t *testing.T) {
+	client := &http.Client{Transport: stackerrhttp.NewTransport(failingTransport{})}
+	_, err := client.Get("https://example.com/users/42?token=secret")
+	if !errors.Is(err, errDial) {
+		t.Errorf("expected error to wrap `%v`, got `%v`", errDial, err)
+	}
+	if !stackerr.HasStack(err) {
+		t.Error("expected a stack trace")
+	}
+	var re *stackerrhttp.RequestError
+	if !errors.As(err, &re) {
+		t.Fatal("expected a *RequestError")
+	}
+	if re.Error() != "GET example.com/users/42: dial failed" {
+		t.Errorf("unexpected message `%s`", re.Error())
+	}
+
+	client = &http.Client{Transport: stackerrhttp.NewTransport(okTransport{})}
+	resp, err := client.Get("https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
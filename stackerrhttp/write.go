@@ -0,0 +1,117 @@
+package stackerrhttp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// ErrorBody holds the details of an error that are safe to send to a client.
+type ErrorBody struct {
+	// Status is the HTTP status code of the response.
+	Status int `json:"-"`
+	// Code is the code attached to the error with stackerr.WithCode, or 0 if there isn't one.
+	Code int `json:"code,omitempty"`
+	// Message is the message attached to the error with stackerr.WithUserMessage, or the text for the status code,
+	// such as "Internal Server Error".
+	Message string `json:"message"`
+	// ReferenceID identifies the error in the logs. It is empty unless the ErrorWriter has a ReferenceID function or
+	// SetReferenceIDs was turned on.
+	ReferenceID string `json:"reference_id,omitempty"`
+}
+
+// ErrorWriter writes error responses for HTTP handlers. Its fields are all optional; the zero value writes the default
+// envelope with DefaultStatus and logs to slog.Default.
+type ErrorWriter struct {
+	// Status chooses the HTTP status code for an error. If it is nil, DefaultStatus is used.
+	Status func(err error) int
+	// Envelope returns the value to encode as JSON for the response body. If it is nil, the body is
+	// {"error": body}.
+	Envelope func(body ErrorBody) interface{}
+	// ReferenceID returns an ID for the error that is sent to the client and logged. If it is nil, there is no
+	// reference ID, unless SetReferenceIDs was turned on.
+	ReferenceID func(err error) string
+	// Logger is the logger for the error's message and stack trace. If it is nil, slog.Default is used.
+	Logger *slog.Logger
+}
+
+// DefaultStatus returns the code attached to the error with stackerr.WithCode if it is an HTTP status code for an
+// error (400 to 599). Otherwise it returns the status for the error's category from stackerr.HTTPStatusFor, such as
+// 404 for stackerr.CategoryNotFound, 504 for a deadline that was exceeded, and 500 for an error without a category.
+//
+// An error that matches context.Canceled gets 499, the status nginx logs when the client closes the connection. Before
+// categories were added, DefaultStatus returned 500 for it; set ErrorWriter.Status to keep the old behavior.
+func DefaultStatus(err error) int {
+	if c, ok := stackerr.Code(err); ok && c >= 400 && c <= 599 {
+		return c
+	}
+	return stackerr.HTTPStatusFor(err)
+}
+
+var defaultErrorWriter ErrorWriter
+
+var referenceIDs int32
+
+// SetReferenceIDs turns on or off sending a reference ID from stackerr.ReferenceID with every error response written
+// by an ErrorWriter without its own ReferenceID function, including WriteError. The ID is logged with the error, so a
+// user who reports it can be matched to the exact stack trace. It is off by default.
+func SetReferenceIDs(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&referenceIDs, v)
+}
+
+// WriteError writes an error response with the zero ErrorWriter. See ErrorWriter.WriteError.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	defaultErrorWriter.WriteError(w, r, err)
+}
+
+// WriteError logs err, with its stack trace and the request's method and path, and writes an error response for it as
+// JSON. If err has no request ID, the one from the request's headers is attached, as with WithRequestID. The response
+// only has the details in ErrorBody; the error's own message and stack trace stay in the logs. WriteError does nothing
+// when a nil error is passed in.
+func (ew *ErrorWriter) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	err = WithRequestID(err, r.Header)
+	status := ew.Status
+	if status == nil {
+		status = DefaultStatus
+	}
+	body := ErrorBody{Status: status(err)}
+	body.Code, _ = stackerr.Code(err)
+	if msg, ok := stackerr.UserMessage(err); ok {
+		body.Message = msg
+	} else {
+		body.Message = http.StatusText(body.Status)
+	}
+	if ew.ReferenceID != nil {
+		body.ReferenceID = ew.ReferenceID(err)
+	} else if atomic.LoadInt32(&referenceIDs) != 0 {
+		body.ReferenceID = stackerr.ReferenceID(err)
+	}
+
+	logger := ew.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With(slog.String("method", r.Method), slog.String("path", r.URL.Path))
+	if body.ReferenceID != "" {
+		logger = logger.With(slog.String("reference_id", body.ReferenceID))
+	}
+	stackerr.WrapAndLog(logger, err, "request failed") // nolint: errcheck
+
+	var envelope interface{} = map[string]ErrorBody{"error": body}
+	if ew.Envelope != nil {
+		envelope = ew.Envelope(body)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(body.Status)
+	json.NewEncoder(w).Encode(envelope) // nolint: errcheck
+}
@@ -0,0 +1,111 @@
+package stackerrhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrhttp"
+)
+
+func TestDefaultStatus(t *testing.T) {
+	data := []struct {
+		err    error
+		status int
+	}{
+		{errors.New("plain"), http.StatusInternalServerError},
+		{stackerr.WithCode(errors.New("missing"), http.StatusNotFound), http.StatusNotFound},
+		{stackerr.WithCode(errors.New("app code"), 42), http.StatusInternalServerError},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{stackerr.WithCategory(errors.New("no user"), stackerr.CategoryNotFound), http.StatusNotFound},
+		{stackerr.WithCode(stackerr.WithCategory(errors.New("both"), stackerr.CategoryNotFound), http.StatusGone), http.StatusGone},
+	}
+	for _, v := range data {
+		if status := stackerrhttp.DefaultStatus(v.err); status != v.status {
+			t.Errorf("%v: expected %d, got %d", v.err, v.status, status)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	var logs bytes.Buffer
+	ew := stackerrhttp.ErrorWriter{
+		ReferenceID: func(error) string { return "ref-1" },
+		Logger:      slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+	err := stackerr.WithUserMessage(stackerr.WithCode(errors.New("no row for id 7"), http.StatusNotFound), "order not found")
+	rec := httptest.NewRecorder()
+	ew.WriteError(rec, httptest.NewRequest(http.MethodGet, "/orders/7", nil), err)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	expected := `{"error":{"code":404,"message":"order not found","reference_id":"ref-1"}}` + "\n"
+	if diff := cmp.Diff(expected, rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+	for _, v := range []string{`"path":"/orders/7"`, `"reference_id":"ref-1"`, `"msg":"no row for id 7"`, `"frames":`} {
+		if !strings.Contains(logs.String(), v) {
+			t.Errorf("expected %s in the logs, got %s", v, logs.String())
+		}
+	}
+
+	ew = stackerrhttp.ErrorWriter{
+		Status:   func(error) int { return http.StatusServiceUnavailable },
+		Envelope: func(body stackerrhttp.ErrorBody) interface{} { return map[string]string{"detail": body.Message} },
+		Logger:   slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+	rec = httptest.NewRecorder()
+	ew.WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("db down"))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if diff := cmp.Diff(`{"detail":"Service Unavailable"}`+"\n", rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	rec = httptest.NewRecorder()
+	stackerrhttp.WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected nothing written for a nil error, got %q", rec.Body.String())
+	}
+}
+
+func TestSetReferenceIDs(t *testing.T) {
+	var logs bytes.Buffer
+	ew := stackerrhttp.ErrorWriter{Logger: slog.New(slog.NewJSONHandler(&logs, nil))}
+	err := stackerr.New("db down")
+	rec := httptest.NewRecorder()
+	ew.WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	if strings.Contains(rec.Body.String(), "reference_id") {
+		t.Errorf("expected no reference ID by default, got %s", rec.Body.String())
+	}
+
+	stackerrhttp.SetReferenceIDs(true)
+	defer stackerrhttp.SetReferenceIDs(false)
+	logs.Reset()
+	rec = httptest.NewRecorder()
+	ew.WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	var body struct {
+		Error stackerrhttp.ErrorBody `json:"error"`
+	}
+	if jsonErr := json.Unmarshal(rec.Body.Bytes(), &body); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	id := body.Error.ReferenceID
+	if !strings.HasPrefix(id, stackerr.Fingerprint(err)[:8]+"-") {
+		t.Errorf("expected a reference ID, got %q", id)
+	}
+	if !strings.Contains(logs.String(), `"reference_id":"`+id+`"`) {
+		t.Errorf("expected the reference ID in the logs, got %s", logs.String())
+	}
+}
@@ -0,0 +1,94 @@
+// Package stackerrjson wraps the errors from decoding JSON with encoding/json with a stack trace, the path of the field
+// that couldn't be decoded, and the position of the error in the input.
+//
+// The functions in this package are registered as stackerr helpers, so stack traces start at the code that called them.
+package stackerrjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerrjson")
+}
+
+// DecodeError adds the location of a JSON decoding failure to the error that encoding/json returned. Use errors.As
+// to get it from an error returned by this package.
+type DecodeError struct {
+	// Err is the original error, usually a *json.UnmarshalTypeError or a *json.SyntaxError.
+	Err error
+	// Field is the path to the field that couldn't be decoded, such as "Items.3.Price". It is empty for syntax errors.
+	Field string
+	// Offset is the number of bytes of input that were read before the error, or -1 if it isn't known, as for a
+	// *json.InvalidUnmarshalError.
+	Offset int64
+	// Line and Column are the 1-based position of the last byte that was read, which is the invalid character for a
+	// syntax error. They are 0 if the input wasn't available, as with Decode.
+	Line   int
+	Column int
+}
+
+// Error returns the original error message, followed by the position of the error if it is known.
+func (de *DecodeError) Error() string {
+	if de.Line > 0 {
+		return fmt.Sprintf("%v (line %d, column %d, offset %d)", de.Err, de.Line, de.Column, de.Offset)
+	}
+	if de.Offset < 0 {
+		return de.Err.Error()
+	}
+	return fmt.Sprintf("%v (offset %d)", de.Err, de.Offset)
+}
+
+// Unwrap exposes the original error.
+func (de *DecodeError) Unwrap() error {
+	return de.Err
+}
+
+// Unmarshal calls json.Unmarshal. If it fails, the error is wrapped in a *DecodeError with a stack trace.
+func Unmarshal(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	de := newDecodeError(err, -1)
+	if de.Offset > 0 && de.Offset <= int64(len(data)) {
+		before := data[:de.Offset-1]
+		de.Line = bytes.Count(before, []byte("\n")) + 1
+		de.Column = len(before) - bytes.LastIndexByte(before, '\n')
+	}
+	return stackerr.Wrap(de)
+}
+
+// Decode calls d.Decode. If it fails with anything other than io.EOF, including io.ErrUnexpectedEOF for truncated
+// input and errors from the decoder's reader, the error is wrapped in a *DecodeError with a stack trace. When the error
+// doesn't report its own offset, the decoder's input offset is used.
+func Decode(d *json.Decoder, v interface{}) error {
+	err := d.Decode(v)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return stackerr.Wrap(newDecodeError(err, d.InputOffset()))
+}
+
+func newDecodeError(err error, offset int64) *DecodeError {
+	de := &DecodeError{
+		Err:    err,
+		Offset: offset,
+	}
+	var ute *json.UnmarshalTypeError
+	var se *json.SyntaxError
+	switch {
+	case errors.As(err, &ute):
+		de.Field = ute.Field
+		de.Offset = ute.Offset
+	case errors.As(err, &se):
+		de.Offset = se.Offset
+	}
+	return de
+}
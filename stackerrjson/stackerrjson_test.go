@@ -0,0 +1,108 @@
+package stackerrjson_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrjson"
+)
+
+type order struct {
+	Items []struct {
+		Price int
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := []struct {
+		name     string
+		in       string
+		field    string
+		offset   int64
+		line     int
+		column   int
+		expected string
+	}{
+		{
+			"type error",
+			"{\n  \"Items\": [{\"Price\": 1}, {\"Price\": \"free\"}]\n}",
+			"Items.1.Price",
+			44, 2, 42,
+			"json: cannot unmarshal string into Go struct field order.Items.1.Price of type int (line 2, column 42, offset 44)",
+		},
+		{
+			"syntax error",
+			"{\n  \"Items\": [,\n}",
+			"",
+			15, 2, 13,
+			"invalid character ',' looking for beginning of value (line 2, column 13, offset 15)",
+		},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			var o order
+			err := stackerrjson.Unmarshal([]byte(v.in), &o)
+			var de *stackerrjson.DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("expected a *DecodeError, got `%v`", err)
+			}
+			if de.Field != v.field || de.Offset != v.offset || de.Line != v.line || de.Column != v.column {
+				t.Errorf("unexpected DecodeError %+v", de)
+			}
+			if err.Error() != v.expected {
+				t.Errorf("expected `%s`, got `%s`", v.expected, err.Error())
+			}
+			origin, _ := stackerr.Origin(err)
+			if origin.Function != "github.com/jonbodner/stackerr/stackerrjson_test.TestUnmarshal.func1" {
+				t.Errorf("expected stack trace to start in the test, got `%s`", origin.Function)
+			}
+		})
+	}
+
+	var o order
+	if err := stackerrjson.Unmarshal([]byte(`{"Items": [{"Price": 3}]}`), &o); err != nil {
+		t.Errorf("expected nil error, got `%v`", err)
+	}
+
+	// the offset is left out of the message when it isn't known.
+	err := stackerrjson.Unmarshal([]byte(`{}`), nil)
+	var de *stackerrjson.DecodeError
+	if !errors.As(err, &de) || de.Offset != -1 {
+		t.Fatalf("expected a *DecodeError without an offset, got `%v`", err)
+	}
+	if err.Error() != "json: Unmarshal(nil)" {
+		t.Errorf("expected `json: Unmarshal(nil)`, got `%s`", err.Error())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	d := json.NewDecoder(strings.NewReader(`{"Items": [{"Price": 3}]} {"Items": [{"Price": "x"}]}`))
+	var o order
+	if err := stackerrjson.Decode(d, &o); err != nil {
+		t.Fatalf("expected nil error, got `%v`", err)
+	}
+	err := stackerrjson.Decode(d, &o)
+	var de *stackerrjson.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("expected a *DecodeError, got `%v`", err)
+	}
+	if de.Field != "Items.0.Price" || de.Line != 0 {
+		t.Errorf("unexpected DecodeError %+v", de)
+	}
+	if err = stackerrjson.Decode(d, &o); err != io.EOF {
+		t.Errorf("expected io.EOF, got `%v`", err)
+	}
+
+	d = json.NewDecoder(strings.NewReader(`{"Items": [`))
+	err = stackerrjson.Decode(d, &o)
+	if !errors.As(err, &de) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a *DecodeError for io.ErrUnexpectedEOF, got `%v`", err)
+	}
+	if err.Error() != "unexpected EOF (offset 0)" {
+		t.Errorf("expected `unexpected EOF (offset 0)`, got `%s`", err.Error())
+	}
+}
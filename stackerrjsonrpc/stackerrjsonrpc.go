@@ -0,0 +1,82 @@
+// Package stackerrjsonrpc converts errors to and from JSON-RPC 2.0 error objects. A server builds the error object for
+// a response with NewError, and a client turns the error object it receives back into an error with a stack trace
+// with DecodeError.
+package stackerrjsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// CodeInternalError is the JSON-RPC code for an internal error. NewError uses it for errors without a code.
+const CodeInternalError = -32603
+
+// Error is a JSON-RPC 2.0 error object.
+type Error struct {
+	// Code is the code attached to the error with stackerr.WithCode, or CodeInternalError.
+	Code int `json:"code"`
+	// Message is the message attached to the error with stackerr.WithUserMessage, or "Internal error".
+	Message string `json:"message"`
+	// Data holds the stack trace, if it was included.
+	Data *ErrorData `json:"data,omitempty"`
+}
+
+// ErrorData is the data member of an Error.
+type ErrorData struct {
+	// Stack holds the frames of the error's stack trace, innermost frame first.
+	Stack []stackerr.Frame `json:"stack,omitempty"`
+}
+
+// Error returns the error object's message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError builds the JSON-RPC error object for an error. The error's own message is not included, since it may have
+// details that aren't meant for clients; attach the message to send with stackerr.WithUserMessage. When withStack is
+// true and the error has a stack trace, its frames are included as data.stack. Only include them when the clients
+// are trusted, such as between services you run. NewError returns nil when a nil error is passed in.
+func NewError(err error, withStack bool) *Error {
+	if err == nil {
+		return nil
+	}
+	out := &Error{Code: CodeInternalError, Message: "Internal error"}
+	if c, ok := stackerr.Code(err); ok {
+		out.Code = c
+	}
+	if msg, ok := stackerr.UserMessage(err); ok {
+		out.Message = msg
+	}
+	if withStack {
+		if frames := stackerr.Frames(err); len(frames) > 0 {
+			out.Data = &ErrorData{Stack: frames}
+		}
+	}
+	return out
+}
+
+// DecodeError decodes the JSON encoding of a JSON-RPC error object, such as the error member of a response, into an
+// error. The decoded error has the object's message and code; if the object has data.stack, Trace, Frames, and %+v
+// use its frames. Pass the decoded error to stackerr.AttachLocal to add the client's own stack trace.
+func DecodeError(data []byte) (error, error) {
+	var e struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, stackerr.Wrap(err)
+	}
+	if e.Message == "" && e.Code == 0 {
+		return nil, errors.New("stackerrjsonrpc: not a JSON-RPC error object")
+	}
+	r := stackerr.LogReport{Message: e.Message, Code: e.Code}
+	// other servers may put anything in data, so anything but an ErrorData is ignored.
+	var d ErrorData
+	if json.Unmarshal(e.Data, &d) == nil {
+		r.Frames = d.Stack
+	}
+	return stackerr.FromReport(r), nil
+}
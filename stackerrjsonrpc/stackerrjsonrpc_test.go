@@ -0,0 +1,68 @@
+package stackerrjsonrpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrjsonrpc"
+)
+
+func TestNewError(t *testing.T) {
+	if stackerrjsonrpc.NewError(nil, true) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	data, _ := json.Marshal(stackerrjsonrpc.NewError(errors.New("dial tcp 10.0.0.7:5432: connection refused"), true))
+	if diff := cmp.Diff(`{"code":-32603,"message":"Internal error"}`, string(data)); diff != "" {
+		t.Error(diff)
+	}
+
+	err := stackerr.WithUserMessage(stackerr.WithCode(errors.New("no row for id 7"), -32001), "order not found")
+	e := stackerrjsonrpc.NewError(err, false)
+	if e.Code != -32001 || e.Message != "order not found" || e.Data != nil {
+		t.Errorf("unexpected error object %+v", e)
+	}
+	e = stackerrjsonrpc.NewError(err, true)
+	if diff := cmp.Diff(stackerr.Frames(err), e.Data.Stack); diff != "" {
+		t.Error(diff)
+	}
+	if e.Error() != "order not found" {
+		t.Errorf("unexpected message %q", e.Error())
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	err := stackerr.WithUserMessage(stackerr.WithCode(errors.New("no row for id 7"), -32001), "order not found")
+	data, _ := json.Marshal(stackerrjsonrpc.NewError(err, true))
+	decoded, decodeErr := stackerrjsonrpc.DecodeError(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if decoded.Error() != "order not found" {
+		t.Errorf("unexpected message %q", decoded)
+	}
+	if code, _ := stackerr.Code(decoded); code != -32001 {
+		t.Errorf("expected code -32001, got %d", code)
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), stackerr.Frames(decoded)); diff != "" {
+		t.Error(diff)
+	}
+
+	decoded, decodeErr = stackerrjsonrpc.DecodeError([]byte(`{"code":-32602,"message":"Invalid params","data":"id must be a number"}`))
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if decoded.Error() != "Invalid params" || len(stackerr.Frames(decoded)) != 0 {
+		t.Errorf("unexpected decoded error %+v", decoded)
+	}
+
+	for _, v := range []string{`{`, `{}`, `[1]`} {
+		if _, err := stackerrjsonrpc.DecodeError([]byte(v)); err == nil {
+			t.Errorf("expected an error for %s", v)
+		}
+	}
+}
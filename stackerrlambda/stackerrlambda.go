@@ -0,0 +1,63 @@
+// Package stackerrlambda converts errors into the error response that AWS Lambda reports for a failed invocation, so
+// the Lambda console, CloudWatch, and Step Functions show the frames from the error's stack trace.
+//
+// The package doesn't import the AWS SDK. With a custom runtime, send the JSON encoding of an ErrorResponse to the
+// runtime API's invocation error endpoint. With github.com/aws/aws-lambda-go, return a messages.InvokeResponse_Error
+// with the same fields from the handler.
+package stackerrlambda
+
+import (
+	"reflect"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// ErrorResponse has the shape of the error response for a failed Lambda invocation.
+type ErrorResponse struct {
+	// Message is the error's message.
+	Message string `json:"errorMessage"`
+	// Type is the name of the type of the innermost error in the chain, which Step Functions matches against the
+	// ErrorEquals field of Retry and Catch rules.
+	Type string `json:"errorType"`
+	// StackTrace holds the frames from the error's stack trace, innermost frame first.
+	StackTrace []StackFrame `json:"stackTrace,omitempty"`
+}
+
+// StackFrame is a frame in the stack trace of an ErrorResponse.
+type StackFrame struct {
+	// Path is the file that contains the frame's function.
+	Path string `json:"path"`
+	// Line is the line number in the file.
+	Line int32 `json:"line"`
+	// Label is the function name without the directories of its import path, such as "handler.(*Server).Invoke".
+	Label string `json:"label"`
+}
+
+// Response converts an error into an ErrorResponse. The frames come from the error's stack trace; an error without
+// one has no frames. Response returns nil when a nil error is passed in.
+func Response(err error) *ErrorResponse {
+	if err == nil {
+		return nil
+	}
+	r := &ErrorResponse{
+		Message: err.Error(),
+		Type:    typeName(stackerr.Cause(err)),
+	}
+	for _, f := range stackerr.Frames(err) {
+		r.StackTrace = append(r.StackTrace, StackFrame{
+			Path:  f.File,
+			Line:  int32(f.Line),
+			Label: f.ShortFunc(),
+		})
+	}
+	return r
+}
+
+// typeName names the type of an error the same way as the Lambda runtime for Go, without a leading *.
+func typeName(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
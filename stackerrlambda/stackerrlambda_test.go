@@ -0,0 +1,51 @@
+package stackerrlambda_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrlambda"
+)
+
+type quotaError struct{}
+
+func (*quotaError) Error() string {
+	return "over quota"
+}
+
+func TestResponse(t *testing.T) {
+	if stackerrlambda.Response(nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+
+	err := stackerr.Errorf("invoke: %w", stackerr.Wrap(&quotaError{}))
+	r := stackerrlambda.Response(err)
+	if r.Message != "invoke: over quota" || r.Type != "quotaError" {
+		t.Errorf("unexpected message and type %q %q", r.Message, r.Type)
+	}
+	frames := stackerr.Frames(err)
+	if len(r.StackTrace) != len(frames) {
+		t.Fatalf("expected %d frames, got %d", len(frames), len(r.StackTrace))
+	}
+	expected := stackerrlambda.StackFrame{Path: frames[0].File, Line: int32(frames[0].Line), Label: "stackerrlambda_test.TestResponse"}
+	if diff := cmp.Diff(expected, r.StackTrace[0]); diff != "" {
+		t.Error(diff)
+	}
+
+	data, _ := json.Marshal(r)
+	out := string(data)
+	if !strings.HasPrefix(out, `{"errorMessage":"invoke: over quota","errorType":"quotaError","stackTrace":[{"path":`) {
+		t.Errorf("unexpected JSON %s", out)
+	}
+
+	data, _ = json.Marshal(stackerrlambda.Response(fmt.Errorf("plain: %w", errors.New("no stack"))))
+	if diff := cmp.Diff(`{"errorMessage":"plain: no stack","errorType":"errorString"}`, string(data)); diff != "" {
+		t.Error(diff)
+	}
+}
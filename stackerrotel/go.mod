@@ -0,0 +1,14 @@
+module github.com/jonbodner/stackerr/stackerrotel
+
+go 1.25.0
+
+replace github.com/jonbodner/stackerr => ../
+
+require (
+	github.com/google/go-cmp v0.7.0
+	github.com/jonbodner/stackerr v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/log v0.22.0
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
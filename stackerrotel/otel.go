@@ -0,0 +1,102 @@
+// Package stackerrotel exports stackerr errors as OpenTelemetry log records.
+//
+// It is a separate module so that programs that don't use OpenTelemetry don't depend on it. The records use the
+// exception and code semantic-convention attributes, so OTLP backends show the message, type, and stack trace in
+// their usual places.
+package stackerrotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// The attribute keys set on a record.
+const (
+	ExceptionType       = "exception.type"
+	ExceptionMessage    = "exception.message"
+	ExceptionStacktrace = "exception.stacktrace"
+	CodeFunction        = "code.function.name"
+	CodeFile            = "code.file.path"
+	CodeLine            = "code.line.number"
+	Fingerprint         = "stackerr.fingerprint"
+	Code                = "stackerr.code"
+)
+
+var severities = map[stackerr.Severity]log.Severity{
+	stackerr.SeverityDebug:    log.SeverityDebug,
+	stackerr.SeverityInfo:     log.SeverityInfo,
+	stackerr.SeverityWarning:  log.SeverityWarn,
+	stackerr.SeverityError:    log.SeverityError,
+	stackerr.SeverityCritical: log.SeverityFatal,
+}
+
+// NewRecord builds a log record for the error. The body is the error's message, and the severity comes from
+// stackerr.SeverityOf. The exception.stacktrace attribute holds the message followed by the stack trace in
+// stackerr.StandardFormat, and the code attributes describe the frame where the stack trace was captured. Attributes
+// that depend on a stack trace or a code are left out when the error doesn't have one.
+func NewRecord(err error) log.Record {
+	var r log.Record
+	if err == nil {
+		return r
+	}
+	now := time.Now()
+	r.SetTimestamp(now)
+	r.SetObservedTimestamp(now)
+	report := stackerr.Report(err)
+	r.SetSeverity(severities[report.Severity])
+	r.SetSeverityText(report.Severity.String())
+	r.SetBody(attribute.StringValue(report.Message))
+	r.SetErr(err)
+	r.AddAttributes(
+		attribute.String(ExceptionType, fmt.Sprintf("%T", rootCause(err))),
+		attribute.String(ExceptionMessage, report.Message),
+	)
+	if report.Code != 0 {
+		r.AddAttributes(attribute.Int(Code, report.Code))
+	}
+	if len(report.Frames) == 0 {
+		return r
+	}
+	trace, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	r.AddAttributes(
+		attribute.String(ExceptionStacktrace, report.Message+"\n"+strings.Join(trace, "\n")),
+		attribute.String(CodeFunction, report.OriginFrame.Function),
+		attribute.String(CodeFile, report.OriginFrame.File),
+		attribute.Int(CodeLine, report.OriginFrame.Line),
+		attribute.String(Fingerprint, report.Fingerprint),
+	)
+	return r
+}
+
+// Emit sends a record for the error to the logger. Nothing is sent for a nil error or when the logger is not enabled
+// for the error's severity.
+func Emit(ctx context.Context, logger log.Logger, err error) {
+	if err == nil {
+		return
+	}
+	param := log.EnabledParameters{Severity: severities[stackerr.SeverityOf(err)]}
+	if !logger.Enabled(ctx, param) {
+		return
+	}
+	logger.Emit(ctx, NewRecord(err))
+}
+
+// rootCause follows Unwrap to the innermost error, which is the most useful type to report. Errors that wrap more
+// than one error stop the search.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
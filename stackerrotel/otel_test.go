@@ -0,0 +1,107 @@
+package stackerrotel_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrotel"
+)
+
+type recordingLogger struct {
+	embedded.Logger
+	min     log.Severity
+	records []log.Record
+}
+
+func (rl *recordingLogger) Emit(_ context.Context, r log.Record) {
+	rl.records = append(rl.records, r)
+}
+
+func (rl *recordingLogger) Enabled(_ context.Context, p log.EnabledParameters) bool {
+	return p.Severity >= rl.min
+}
+
+func attrs(r log.Record) map[string]attribute.Value {
+	out := map[string]attribute.Value{}
+	r.WalkAttributes(func(kv attribute.KeyValue) bool {
+		out[string(kv.Key)] = kv.Value
+		return true
+	})
+	return out
+}
+
+type notFound struct{}
+
+func (notFound) Error() string {
+	return "not found"
+}
+
+func TestNewRecord(t *testing.T) {
+	inner := stackerr.Wrap(notFound{})
+	err := stackerr.WithCode(fmt.Errorf("load user: %w", inner), 404)
+	r := stackerrotel.NewRecord(err)
+	if r.Severity() != log.SeverityError || r.SeverityText() != "error" {
+		t.Errorf("unexpected severity %v %q", r.Severity(), r.SeverityText())
+	}
+	if r.Body().AsString() != "load user: not found" {
+		t.Errorf("unexpected body %q", r.Body().AsString())
+	}
+	if r.Err() != err {
+		t.Error("expected the error to be set on the record")
+	}
+	if r.Timestamp().IsZero() {
+		t.Error("expected a timestamp")
+	}
+
+	origin, _ := stackerr.Origin(err)
+	trace, _ := stackerr.Trace(err, stackerr.StandardFormat)
+	expected := map[string]attribute.Value{
+		stackerrotel.ExceptionType:       attribute.StringValue("stackerrotel_test.notFound"),
+		stackerrotel.ExceptionMessage:    attribute.StringValue("load user: not found"),
+		stackerrotel.ExceptionStacktrace: attribute.StringValue("load user: not found\n" + strings.Join(trace, "\n")),
+		stackerrotel.CodeFunction:        attribute.StringValue(origin.Function),
+		stackerrotel.CodeFile:            attribute.StringValue(origin.File),
+		stackerrotel.CodeLine:            attribute.IntValue(origin.Line),
+		stackerrotel.Fingerprint:         attribute.StringValue(stackerr.Fingerprint(err)),
+		stackerrotel.Code:                attribute.IntValue(404),
+	}
+	if diff := cmp.Diff(expected, attrs(r), cmp.Comparer(func(a, b attribute.Value) bool { return a == b })); diff != "" {
+		t.Error(diff)
+	}
+
+	critical := stackerrotel.NewRecord(stackerr.WithSeverity(errors.New("critical"), stackerr.SeverityCritical))
+	if critical.Severity() != log.SeverityFatal {
+		t.Errorf("expected fatal severity, got %v", critical.Severity())
+	}
+
+	got := attrs(stackerrotel.NewRecord(errors.New("plain")))
+	if _, ok := got[stackerrotel.ExceptionStacktrace]; ok {
+		t.Error("expected no stack trace attribute")
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 attributes, got %d", len(got))
+	}
+}
+
+func TestEmit(t *testing.T) {
+	logger := &recordingLogger{min: log.SeverityWarn}
+	ctx := context.Background()
+	stackerrotel.Emit(ctx, logger, nil)
+	stackerrotel.Emit(ctx, logger, stackerr.WithSeverity(stackerr.New("quiet"), stackerr.SeverityInfo))
+	stackerrotel.Emit(ctx, logger, stackerr.New("loud"))
+	if len(logger.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(logger.records))
+	}
+	if logger.records[0].Body().AsString() != "loud" {
+		t.Errorf("unexpected body %q", logger.records[0].Body().AsString())
+	}
+}
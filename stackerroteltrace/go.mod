@@ -0,0 +1,13 @@
+module github.com/jonbodner/stackerr/stackerroteltrace
+
+go 1.25.0
+
+replace github.com/jonbodner/stackerr => ../
+
+require (
+	github.com/jonbodner/stackerr v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
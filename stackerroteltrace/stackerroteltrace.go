@@ -0,0 +1,50 @@
+// Package stackerroteltrace records the active OpenTelemetry trace and span on errors created with the context-aware
+// stackerr constructors.
+//
+// It is a separate module so that programs that don't use OpenTelemetry don't depend on it. Importing the package
+// installs a stackerr.SpanContextFunc that reads the span from the context, so stackerr.NewCtx, stackerr.ErrorfCtx,
+// and stackerr.WrapCtx attach its IDs with no other code:
+//
+//	import _ "github.com/jonbodner/stackerr/stackerroteltrace"
+//
+// Use Baggage with stackerr.SetContextValues to copy baggage members onto the errors as well.
+package stackerroteltrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.SetSpanContextFunc(SpanContext)
+}
+
+// SpanContext returns the trace and span IDs of the span in ctx, as found by trace.SpanContextFromContext. The last
+// return value is false if the span context isn't valid, such as when there is no span in ctx.
+func SpanContext(ctx context.Context) (traceID, spanID string, ok bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", "", false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), true
+}
+
+// Baggage returns a stackerr.ContextValue that reads the value of the baggage member with the given key from the
+// context's baggage:
+//
+//	stackerr.SetContextValues(map[string]stackerr.ContextValue{
+//		"tenant": stackerroteltrace.Baggage("tenant.id"),
+//	})
+func Baggage(key string) stackerr.ContextValue {
+	return func(ctx context.Context) (string, bool) {
+		m := baggage.FromContext(ctx).Member(key)
+		if m.Key() == "" {
+			return "", false
+		}
+		return m.Value(), true
+	}
+}
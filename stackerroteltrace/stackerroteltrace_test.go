@@ -0,0 +1,52 @@
+package stackerroteltrace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerroteltrace"
+)
+
+func TestSpanContext(t *testing.T) {
+	if _, _, ok := stackerroteltrace.SpanContext(context.Background()); ok {
+		t.Error("didn't expect a span without one in the context")
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	err := stackerr.NewCtx(ctx, "payment declined")
+	traceID, spanID, ok := stackerr.SpanIDs(err)
+	if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected IDs %q %q", traceID, spanID)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	tenant, memberErr := baggage.NewMember("tenant.id", "acme")
+	if memberErr != nil {
+		t.Fatal(memberErr)
+	}
+	b, bagErr := baggage.New(tenant)
+	if bagErr != nil {
+		t.Fatal(bagErr)
+	}
+	stackerr.SetContextValues(map[string]stackerr.ContextValue{
+		"tenant": stackerroteltrace.Baggage("tenant.id"),
+		"flags":  stackerroteltrace.Baggage("feature.flags"),
+	})
+	defer stackerr.SetContextValues(nil)
+
+	err := stackerr.WrapCtx(baggage.ContextWithBaggage(context.Background(), b), errors.New("quota exceeded"))
+	values := stackerr.ContextValues(err)
+	if len(values) != 1 || values["tenant"] != "acme" {
+		t.Errorf("unexpected context values %v", values)
+	}
+}
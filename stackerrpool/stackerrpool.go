@@ -0,0 +1,183 @@
+// Package stackerrpool runs functions over a slice of items with a bounded number of goroutines, and collects every
+// item's error with a stack trace.
+package stackerrpool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// ItemError is the error for a single item passed to ForEach.
+type ItemError struct {
+	// Index is the position of the item in the slice passed to ForEach.
+	Index int
+	// Err is the error returned by the function, or the error for a recovered panic.
+	Err error
+}
+
+// Error returns the item's index, followed by the message for Err.
+func (ie *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", ie.Index, ie.Err)
+}
+
+// Unwrap exposes the error for the item.
+func (ie *ItemError) Unwrap() error {
+	return ie.Err
+}
+
+// Format prefixes the item error's formatted output with its index, so %+v still outputs the stack trace.
+func (ie *ItemError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "item %d: %+v", ie.Index, ie.Err)
+			return
+		}
+		io.WriteString(s, ie.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, ie.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", ie.Error())
+	}
+}
+
+// PoolError is returned by ForEach when any item fails or the context is cancelled before every item has started.
+type PoolError struct {
+	// Items holds the errors for the items that failed, in index order.
+	Items []*ItemError
+	// Skipped is the number of items that never started because the context was cancelled.
+	Skipped int
+	// Err is the context's error, with a stack trace, if any items were skipped.
+	Err error
+}
+
+// Error summarizes the failures, followed by the messages for each error.
+func (pe *PoolError) Error() string {
+	summary := fmt.Sprintf("%d items failed", len(pe.Items))
+	msgs := make([]string, 0, len(pe.Items)+1)
+	if pe.Err != nil {
+		summary += fmt.Sprintf(", %d skipped", pe.Skipped)
+		msgs = append(msgs, pe.Err.Error())
+	}
+	for _, v := range pe.Items {
+		msgs = append(msgs, v.Error())
+	}
+	return summary + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the context's error, if any, and the errors for each item, so errors.Is and errors.As check all of
+// them.
+func (pe *PoolError) Unwrap() []error {
+	out := make([]error, 0, len(pe.Items)+1)
+	if pe.Err != nil {
+		out = append(out, pe.Err)
+	}
+	for _, v := range pe.Items {
+		out = append(out, v)
+	}
+	return out
+}
+
+// Format outputs each error with its stack trace, separated by blank lines, for %+v. Other verbs output the result
+// of Error.
+func (pe *PoolError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			traces := make([]string, 0, len(pe.Items)+1)
+			if pe.Err != nil {
+				traces = append(traces, fmt.Sprintf("%d skipped: %+v", pe.Skipped, pe.Err))
+			}
+			for _, v := range pe.Items {
+				traces = append(traces, fmt.Sprintf("%+v", v))
+			}
+			io.WriteString(s, strings.Join(traces, "\n\n")) // nolint: errcheck
+			return
+		}
+		io.WriteString(s, pe.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, pe.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", pe.Error())
+	}
+}
+
+// ForEach calls fn for each item, using at most n goroutines at a time (n less than 1 is treated as 1). It waits
+// for every call to finish. Panics in fn are recovered and reported as errors. Errors without a stack trace get the
+// stack trace of the code that called ForEach, so it's possible to tell which pipeline they came from. Once ctx is
+// done, no more items are started.
+//
+// ForEach returns nil if every item succeeded. Otherwise it returns a *PoolError.
+func ForEach[T any](ctx context.Context, n int, items []T, fn func(ctx context.Context, item T) error) error {
+	if n < 1 {
+		n = 1
+	}
+	caller := stackerr.CaptureStack(1)
+	var mu sync.Mutex
+	itemErrs := make([]*ItemError, len(items))
+	failed := false
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < n && i < len(items); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				if err := call(ctx, items[idx], fn, caller); err != nil {
+					mu.Lock()
+					itemErrs[idx] = &ItemError{Index: idx, Err: err}
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	started := 0
+dispatch:
+	for started < len(items) && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case indexes <- started:
+			started++
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if !failed && started == len(items) {
+		return nil
+	}
+	pe := &PoolError{}
+	for _, v := range itemErrs {
+		if v != nil {
+			pe.Items = append(pe.Items, v)
+		}
+	}
+	if started < len(items) {
+		pe.Skipped = len(items) - started
+		pe.Err = stackerr.AttachStack(ctx.Err(), caller)
+	}
+	return pe
+}
+
+// call runs fn for a single item, converting a panic into an error.
+func call[T any](ctx context.Context, item T, fn func(ctx context.Context, item T) error, caller stackerr.Stack) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = stackerr.FromRecovered(r)
+		}
+	}()
+	err = fn(ctx, item)
+	if err != nil && !stackerr.HasStack(err) {
+		err = stackerr.AttachStack(err, caller)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package stackerrpool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrpool"
+)
+
+var errOdd = errors.New("odd")
+
+func TestForEach(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5}
+	var running, maxRunning int32
+	err := stackerrpool.ForEach(context.Background(), 2, items, func(_ context.Context, item int) error {
+		cur := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			prev := atomic.LoadInt32(&maxRunning)
+			if cur <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, cur) {
+				break
+			}
+		}
+		switch {
+		case item == 5:
+			panic("five")
+		case item%2 == 1:
+			return errOdd
+		}
+		return nil
+	})
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 goroutines, got %d", maxRunning)
+	}
+
+	var pe *stackerrpool.PoolError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *PoolError, got `%v`", err)
+	}
+	if len(pe.Items) != 3 || pe.Items[0].Index != 1 || pe.Items[1].Index != 3 || pe.Items[2].Index != 5 {
+		t.Fatalf("unexpected item errors %v", pe.Items)
+	}
+	if err.Error() != "3 items failed: item 1: odd; item 3: odd; item 5: panic: five" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if !errors.Is(err, errOdd) {
+		t.Error("expected errors.Is to find errOdd")
+	}
+	origin, _ := stackerr.Origin(pe.Items[0])
+	if origin.Function != "github.com/jonbodner/stackerr/stackerrpool_test.TestForEach" {
+		t.Errorf("expected item errors to have the caller's stack trace, got `%s`", origin.Function)
+	}
+	if v, ok := stackerr.PanicValue(pe.Items[2]); !ok || v != "five" {
+		t.Errorf("expected panic value `five`, got `%v`", v)
+	}
+	if !strings.HasPrefix(fmt.Sprintf("%+v", err), "item 1: odd\ngithub.com/jonbodner/stackerr/stackerrpool_test.TestForEach ") {
+		t.Errorf("unexpected %%+v output `%+v`", err)
+	}
+
+	if err = stackerrpool.ForEach(context.Background(), 0, items, func(context.Context, int) error { return nil }); err != nil {
+		t.Errorf("expected nil error, got `%v`", err)
+	}
+}
+
+func TestForEachCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := stackerrpool.ForEach(ctx, 2, []int{0, 1, 2, 3}, func(context.Context, int) error {
+		t.Error("didn't expect any items to start")
+		return nil
+	})
+	var pe *stackerrpool.PoolError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *PoolError, got `%v`", err)
+	}
+	if pe.Skipped != 4 || len(pe.Items) != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected PoolError %+v", pe)
+	}
+	if err.Error() != "0 items failed, 4 skipped: context canceled" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+}
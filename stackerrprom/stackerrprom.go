@@ -0,0 +1,152 @@
+// Package stackerrprom counts errors by where they were created and serves the counts in the Prometheus text
+// exposition format, so a dashboard can show the most common error origins without sending every error to an
+// error-tracking service.
+//
+// It has no dependencies beyond the standard library. Serve a Collector on its own path and add it as a scrape
+// target, or on the same path as your other metrics by calling ServeHTTP from your metrics handler.
+package stackerrprom
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// DefaultName is the metric name used when NewCollector is passed an empty name.
+const DefaultName = "app_errors_total"
+
+// OtherOrigin is the origin label of the series that holds the counts evicted from the collector.
+const OtherOrigin = "other"
+
+// series is the key of a counter.
+type series struct {
+	origin      string
+	fingerprint string
+}
+
+type entry struct {
+	series series
+	count  uint64
+}
+
+// Collector counts errors by the function where their stack trace was captured and by their fingerprint. To keep the
+// number of series bounded, it holds at most the number of series passed to NewCollector. When a new series would go
+// over the limit, the one that was incremented least recently is removed and its count is added to a series with the
+// origin label "other" and an empty fingerprint, so the total over all series keeps going up. An evicted series that
+// comes back starts over from its new count, which Prometheus reads as a counter reset: rate and increase handle it,
+// but the counts from before the eviction stay in the "other" series. Choose a size above the number of distinct error
+// origins the program has in normal operation, so that evictions only happen during a flood of new errors; a growing
+// "other" series means the size is too small. A Collector is safe for concurrent use.
+type Collector struct {
+	name string
+	size int
+
+	mu      sync.Mutex
+	entries map[series]*list.Element
+	lru     *list.List
+	other   uint64
+}
+
+// NewCollector returns a Collector for the metric with the given name, which holds at most size series. If name is
+// empty, DefaultName is used. A size less than 1 is treated as 1.
+func NewCollector(name string, size int) *Collector {
+	if name == "" {
+		name = DefaultName
+	}
+	if size < 1 {
+		size = 1
+	}
+	return &Collector{
+		name:    name,
+		size:    size,
+		entries: map[series]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Observe counts err once. Errors without a stack trace are counted with an empty origin and fingerprint. Nil errors
+// are ignored.
+func (c *Collector) Observe(err error) {
+	c.add(err, 1)
+}
+
+func (c *Collector) add(err error, n uint64) {
+	if err == nil {
+		return
+	}
+	var s series
+	if frames := stackerr.Frames(err); len(frames) > 0 {
+		s.origin = frames[0].Function
+	}
+	s.fingerprint = stackerr.Fingerprint(err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[s]; ok {
+		el.Value.(*entry).count += n
+		c.lru.MoveToFront(el)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		e := oldest.Value.(*entry)
+		c.other += e.count
+		delete(c.entries, e.series)
+		c.lru.Remove(oldest)
+	}
+	c.entries[s] = c.lru.PushFront(&entry{series: s, count: n})
+}
+
+// Watch counts every error reported by stackerr.Subscribe until ctx is done. Events held back by
+// stackerr.SetDedupWindow are counted through the Repeats field of the next event for their fingerprint. Run it in its
+// own goroutine:
+//
+//	go collector.Watch(ctx)
+func (c *Collector) Watch(ctx context.Context) {
+	events := stackerr.Subscribe()
+	defer stackerr.Unsubscribe(events)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-events:
+			c.add(e.Err, 1+uint64(e.Repeats))
+		}
+	}
+}
+
+// WriteTo writes the counters to w in the Prometheus text exposition format, most recently incremented first.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Errors by origin function and fingerprint.\n", c.name)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", c.name)
+	c.mu.Lock()
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*entry)
+		fmt.Fprintf(&b, "%s{origin=%s,fingerprint=%s} %d\n", c.name, labelValue(e.series.origin),
+			labelValue(e.series.fingerprint), e.count)
+	}
+	if c.other > 0 {
+		fmt.Fprintf(&b, "%s{origin=%s,fingerprint=\"\"} %d\n", c.name, labelValue(OtherOrigin), c.other)
+	}
+	c.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP writes the counters as a response in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w) // nolint: errcheck
+}
+
+// labelValue quotes a label value, escaping backslashes, double quotes, and line feeds as the exposition format
+// requires.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,90 @@
+package stackerrprom_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrprom"
+)
+
+func loadUser() error {
+	return stackerr.New("user not found")
+}
+
+func saveOrder() error {
+	return stackerr.New("order conflict")
+}
+
+func TestCollector(t *testing.T) {
+	c := stackerrprom.NewCollector("", 2)
+	user := loadUser()
+	c.Observe(user)
+	c.Observe(user)
+	c.Observe(errors.New("no stack"))
+	c.Observe(nil)
+
+	var b strings.Builder
+	if _, err := c.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP app_errors_total Errors by origin function and fingerprint.
+# TYPE app_errors_total counter
+app_errors_total{origin="",fingerprint=""} 1
+app_errors_total{origin="github.com/jonbodner/stackerr/stackerrprom_test.loadUser",fingerprint="` + stackerr.Fingerprint(user) + `"} 2
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	// a third series evicts the least recently incremented one into "other".
+	order := saveOrder()
+	c.Observe(order)
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	expected = `# HELP app_errors_total Errors by origin function and fingerprint.
+# TYPE app_errors_total counter
+app_errors_total{origin="github.com/jonbodner/stackerr/stackerrprom_test.saveOrder",fingerprint="` + stackerr.Fingerprint(order) + `"} 1
+app_errors_total{origin="",fingerprint=""} 1
+app_errors_total{origin="other",fingerprint=""} 2
+`
+	if diff := cmp.Diff(expected, rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c := stackerrprom.NewCollector("svc_errors_total", 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Watch(ctx)
+		close(done)
+	}()
+	// wait for Watch to subscribe before creating the error.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		loadUser() // nolint: errcheck
+		var b strings.Builder
+		c.WriteTo(&b) // nolint: errcheck
+		if strings.Contains(b.String(), "svc_errors_total{origin=\"github.com/jonbodner/stackerr/stackerrprom_test.loadUser\"") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the error to be counted, got\n%s", b.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,137 @@
+// Package stackerrretry retries a function and keeps the error from every attempt, so it's possible to tell whether
+// the failures were all the same or different.
+package stackerrretry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// Policy controls how many times Retry calls a function and how long it waits between calls.
+type Policy struct {
+	// Attempts is the maximum number of calls. Values less than 1 are treated as 1.
+	Attempts int
+	// Delay is how long to wait after the first failed call.
+	Delay time.Duration
+	// Multiplier increases the delay after each failed call. Values less than 1 are treated as 1.
+	Multiplier float64
+	// MaxDelay limits the delay between calls, if it is greater than 0.
+	MaxDelay time.Duration
+}
+
+// delay returns how long to wait after the attempt with the specified 0-based index.
+func (p Policy) delay(attempt int) time.Duration {
+	d := float64(p.Delay)
+	for i := 0; i < attempt && p.Multiplier > 1; i++ {
+		d *= p.Multiplier
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+// Attempt records a single failed call.
+type Attempt struct {
+	// Err is the error returned by the call, with a stack trace.
+	Err error
+	// Start is when the call started.
+	Start time.Time
+	// Duration is how long the call ran.
+	Duration time.Duration
+}
+
+// RetryError is returned by Retry when every attempt failed, or the context was cancelled between attempts.
+type RetryError struct {
+	// Attempts holds every failed call, in order.
+	Attempts []Attempt
+	// Err is the context's error, with a stack trace, if the context was cancelled before all attempts were made.
+	Err error
+}
+
+// Error returns the number of attempts, followed by the message for the last error.
+func (re *RetryError) Error() string {
+	last := re.Attempts[len(re.Attempts)-1].Err
+	if re.Err != nil {
+		return fmt.Sprintf("gave up after %d attempts: %v; last error: %v", len(re.Attempts), re.Err, last)
+	}
+	return fmt.Sprintf("failed after %d attempts: %v", len(re.Attempts), last)
+}
+
+// Unwrap returns the error for each attempt, along with the context's error, if any, so errors.Is and errors.As
+// check all of them.
+func (re *RetryError) Unwrap() []error {
+	out := make([]error, 0, len(re.Attempts)+1)
+	for _, v := range re.Attempts {
+		out = append(out, v.Err)
+	}
+	if re.Err != nil {
+		out = append(out, re.Err)
+	}
+	return out
+}
+
+// Format outputs the result of Error for most verbs. For %+v, it also outputs a line for each attempt with the
+// attempt's start time, duration, message, and the frame where its stack trace was captured.
+func (re *RetryError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			lines := []string{re.Error()}
+			for i, v := range re.Attempts {
+				line := fmt.Sprintf("attempt %d at %s (%s): %v", i+1, v.Start.Format(time.RFC3339Nano), v.Duration, v.Err)
+				if origin, ok := stackerr.Origin(v.Err); ok {
+					line += fmt.Sprintf("\n\t%s (%s:%d)", origin.Function, origin.File, origin.Line)
+				}
+				lines = append(lines, line)
+			}
+			io.WriteString(s, strings.Join(lines, "\n")) // nolint: errcheck
+			return
+		}
+		io.WriteString(s, re.Error()) // nolint: errcheck
+	case 's':
+		io.WriteString(s, re.Error()) // nolint: errcheck
+	case 'q':
+		fmt.Fprintf(s, "%q", re.Error())
+	}
+}
+
+// Retry calls fn until it succeeds or the policy's attempts are used up, waiting between calls as the policy
+// specifies. It returns nil as soon as a call succeeds. Otherwise it returns a *RetryError with every attempt's
+// error. Errors without a stack trace get the stack trace of the code that called Retry. If ctx is done while
+// waiting between calls, Retry stops early.
+func Retry(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	caller := stackerr.CaptureStack(1)
+	re := &RetryError{}
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(policy.delay(i - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				re.Err = stackerr.AttachStack(ctx.Err(), caller)
+				return re
+			case <-timer.C:
+			}
+		}
+		start := time.Now()
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if !stackerr.HasStack(err) {
+			err = stackerr.AttachStack(err, caller)
+		}
+		re.Attempts = append(re.Attempts, Attempt{Err: err, Start: start, Duration: time.Since(start)})
+	}
+	return re
+}
@@ -0,0 +1,93 @@
+package stackerrretry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrretry"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	err := stackerrretry.Retry(context.Background(), stackerrretry.Policy{Attempts: 3, Delay: time.Millisecond, Multiplier: 2}, func(context.Context) error {
+		calls++
+		if calls == 2 {
+			return stackerr.New("timeout")
+		}
+		return errUnavailable
+	})
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	var re *stackerrretry.RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected a *RetryError, got `%v`", err)
+	}
+	if len(re.Attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(re.Attempts))
+	}
+	if err.Error() != "failed after 3 attempts: unavailable" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+	if !errors.Is(err, errUnavailable) {
+		t.Error("expected errors.Is to find errUnavailable")
+	}
+	for _, v := range re.Attempts {
+		if !stackerr.HasStack(v.Err) || v.Start.IsZero() {
+			t.Errorf("unexpected attempt %+v", v)
+		}
+	}
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 lines, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "attempt 1 at ") || !strings.HasSuffix(lines[1], "): unavailable") {
+		t.Errorf("unexpected attempt line `%s`", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "\tgithub.com/jonbodner/stackerr/stackerrretry_test.TestRetry (") {
+		t.Errorf("expected the caller's origin for a plain error, got `%s`", lines[2])
+	}
+	if !strings.HasPrefix(lines[4], "\tgithub.com/jonbodner/stackerr/stackerrretry_test.TestRetry.func1 (") {
+		t.Errorf("expected the error's own origin for a stacked error, got `%s`", lines[4])
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	calls := 0
+	err := stackerrretry.Retry(context.Background(), stackerrretry.Policy{Attempts: 5}, func(context.Context) error {
+		calls++
+		if calls < 2 {
+			return errUnavailable
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("expected success after 2 calls, got `%v` after %d", err, calls)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := stackerrretry.Retry(ctx, stackerrretry.Policy{Attempts: 5, Delay: time.Hour}, func(context.Context) error {
+		cancel()
+		return errUnavailable
+	})
+	var re *stackerrretry.RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected a *RetryError, got `%v`", err)
+	}
+	if len(re.Attempts) != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected RetryError %+v", re)
+	}
+	if err.Error() != "gave up after 1 attempts: context canceled; last error: unavailable" {
+		t.Errorf("unexpected message `%s`", err.Error())
+	}
+}
@@ -0,0 +1,186 @@
+// Package stackerrsql wraps the errors returned by database/sql with a stack trace and details about the call that
+// failed. The query text in those details has its literal values redacted, so it is safe to log.
+//
+// The functions in this package are registered as stackerr helpers, so stack traces start at the code that called them.
+package stackerrsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func init() {
+	stackerr.RegisterHelperPackage("github.com/jonbodner/stackerr/stackerrsql")
+}
+
+// Operations reported in QueryError.Op.
+const (
+	OpQuery    = "query"
+	OpQueryRow = "query row"
+	OpExec     = "exec"
+	OpScan     = "scan"
+)
+
+// QueryError adds details about a failed database/sql call to the error it returned. Use errors.As to get it from an
+// error returned by this package.
+type QueryError struct {
+	// Err is the original error.
+	Err error
+	// Op is the operation that failed.
+	Op string
+	// Query is the query text with its literal values replaced by ?. It is empty for OpScan.
+	Query string
+	// Fingerprint identifies the redacted query. It is empty for OpScan.
+	Fingerprint string
+	// RowsAffected is the number of rows affected by an OpExec call, or -1 if it isn't known.
+	RowsAffected int64
+}
+
+// Error returns the operation and query fingerprint, followed by the original error message.
+func (qe *QueryError) Error() string {
+	if qe.Fingerprint == "" {
+		return fmt.Sprintf("sql %s: %v", qe.Op, qe.Err)
+	}
+	return fmt.Sprintf("sql %s (query %s): %v", qe.Op, qe.Fingerprint, qe.Err)
+}
+
+// Unwrap exposes the original error, so errors.Is(err, sql.ErrNoRows) works on errors from this package.
+func (qe *QueryError) Unwrap() error {
+	return qe.Err
+}
+
+// Queryer is implemented by *sql.DB, *sql.Conn, and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Scanner is implemented by *sql.Row and *sql.Rows.
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Query calls QueryContext on q. If it fails, the error is wrapped in a *QueryError with a stack trace.
+func Query(ctx context.Context, q Queryer, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrap(err, OpQuery, query, -1)
+	}
+	return rows, nil
+}
+
+// Exec calls ExecContext on q. If it fails, the error is wrapped in a *QueryError with a stack trace.
+func Exec(ctx context.Context, q Queryer, query string, args ...interface{}) (sql.Result, error) {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		rowsAffected := int64(-1)
+		if res != nil {
+			if n, rowsErr := res.RowsAffected(); rowsErr == nil {
+				rowsAffected = n
+			}
+		}
+		return res, wrap(err, OpExec, query, rowsAffected)
+	}
+	return res, nil
+}
+
+// Row is returned by QueryRow. Its Scan method wraps errors in a *QueryError with a stack trace.
+type Row struct {
+	row   *sql.Row
+	query string
+}
+
+// QueryRow calls QueryRowContext on q. Errors are reported when Scan is called on the returned Row.
+func QueryRow(ctx context.Context, q Queryer, query string, args ...interface{}) *Row {
+	return &Row{
+		row:   q.QueryRowContext(ctx, query, args...),
+		query: query,
+	}
+}
+
+// Scan works like sql.Row.Scan. If it fails, including when there are no rows, the error is wrapped in a
+// *QueryError with a stack trace.
+func (r *Row) Scan(dest ...interface{}) error {
+	if err := r.row.Scan(dest...); err != nil {
+		return wrap(err, OpQueryRow, r.query, -1)
+	}
+	return nil
+}
+
+// Scan calls Scan on s. If it fails, the error is wrapped in a *QueryError with a stack trace.
+func Scan(s Scanner, dest ...interface{}) error {
+	if err := s.Scan(dest...); err != nil {
+		return wrap(err, OpScan, "", -1)
+	}
+	return nil
+}
+
+func wrap(err error, op string, query string, rowsAffected int64) error {
+	qe := &QueryError{
+		Err:          err,
+		Op:           op,
+		RowsAffected: rowsAffected,
+	}
+	if query != "" {
+		qe.Query = Redact(query)
+		h := fnv.New32a()
+		h.Write([]byte(qe.Query)) // nolint: errcheck
+		qe.Fingerprint = fmt.Sprintf("%08x", h.Sum32())
+	}
+	return stackerr.Wrap(qe)
+}
+
+// Redact replaces the string and number literals in a SQL query with ? and collapses runs of whitespace, so queries
+// that only differ in their literal values are redacted to the same text. Placeholders such as $1 are kept.
+func Redact(query string) string {
+	var b strings.Builder
+	runes := []rune(query)
+	space := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '\'':
+			// skip to the closing quote; a doubled quote is an escaped quote.
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			r = '?'
+		case unicode.IsDigit(r) && !partOfWord(runes, i):
+			for i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+			r = '?'
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// partOfWord reports whether the digit at position i belongs to an identifier or a placeholder like $1.
+func partOfWord(runes []rune, i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := runes[i-1]
+	return prev == '_' || prev == '$' || prev == '@' || unicode.IsLetter(prev) || unicode.IsDigit(prev)
+}
@@ -0,0 +1,133 @@
+package stackerrsql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrsql"
+)
+
+var errDriver = errors.New("driver failure")
+
+// failingDriver fails every Exec and Query, except that queries starting with "select empty" return no rows.
+type failingDriver struct{}
+
+func (failingDriver) Open(string) (driver.Conn, error) { return failingConn{}, nil }
+
+type failingConn struct{}
+
+func (failingConn) Prepare(string) (driver.Stmt, error) { return nil, errDriver }
+func (failingConn) Close() error                        { return nil }
+func (failingConn) Begin() (driver.Tx, error)           { return nil, errDriver }
+
+func (failingConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, errDriver
+}
+
+func (failingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.HasPrefix(query, "select empty") {
+		return emptyRows{}, nil
+	}
+	return nil, errDriver
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("stackerrsql_failing", failingDriver{})
+}
+
+func TestQueryError(t *testing.T) {
+	db, err := sql.Open("stackerrsql_failing", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	_, execErr := stackerrsql.Exec(ctx, db, "update users set name = 'bob' where id = 42")
+	_, queryErr := stackerrsql.Query(ctx, db, "select * from users where id = $1", 42)
+	var id int
+	rowErr := stackerrsql.QueryRow(ctx, db, "select empty from users where id = 7").Scan(&id)
+
+	data := []struct {
+		name     string
+		err      error
+		target   error
+		op       string
+		query    string
+		expected string
+	}{
+		{"exec", execErr, errDriver, stackerrsql.OpExec, "update users set name = ? where id = ?", "sql exec (query "},
+		{"query", queryErr, errDriver, stackerrsql.OpQuery, "select * from users where id = $1", "sql query (query "},
+		{"query row", rowErr, sql.ErrNoRows, stackerrsql.OpQueryRow, "select empty from users where id = ?", "sql query row (query "},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			if !errors.Is(v.err, v.target) {
+				t.Errorf("expected error to wrap `%v`, got `%v`", v.target, v.err)
+			}
+			var qe *stackerrsql.QueryError
+			if !errors.As(v.err, &qe) {
+				t.Fatal("expected a *QueryError")
+			}
+			if qe.Op != v.op || qe.Query != v.query || len(qe.Fingerprint) != 8 || qe.RowsAffected != -1 {
+				t.Errorf("unexpected QueryError %+v", qe)
+			}
+			if !strings.HasPrefix(v.err.Error(), v.expected) {
+				t.Errorf("expected message to start with `%s`, got `%s`", v.expected, v.err.Error())
+			}
+			origin, ok := stackerr.Origin(v.err)
+			if !ok || origin.Function != "github.com/jonbodner/stackerr/stackerrsql_test.TestQueryError" {
+				t.Errorf("expected stack trace to start in the test, got `%s`", origin.Function)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	scanErr := stackerrsql.Scan(failingScanner{})
+	if !errors.Is(scanErr, errDriver) {
+		t.Errorf("expected error to wrap `%v`, got `%v`", errDriver, scanErr)
+	}
+	if scanErr.Error() != "sql scan: driver failure" {
+		t.Errorf("unexpected message `%s`", scanErr.Error())
+	}
+	if stackerrsql.Scan(okScanner{}) != nil {
+		t.Error("expected nil error")
+	}
+}
+
+type failingScanner struct{}
+
+func (failingScanner) Scan(...interface{}) error { return errDriver }
+
+type okScanner struct{}
+
+func (okScanner) Scan(...interface{}) error { return nil }
+
+func TestRedact(t *testing.T) {
+	data := []struct {
+		in       string
+		expected string
+	}{
+		{"select * from t where a = 'x' and b = 12", "select * from t where a = ? and b = ?"},
+		{"select *\n  from t2\twhere name = 'it''s' and ratio > 1.5", "select * from t2 where name = ? and ratio > ?"},
+		{"insert into t (a, b) values ($1, $2)", "insert into t (a, b) values ($1, $2)"},
+	}
+	for _, v := range data {
+		if out := stackerrsql.Redact(v.in); out != v.expected {
+			t.Errorf("expected `%s`, got `%s`", v.expected, out)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package stackerrtemporal carries the stack traces of errors through Temporal workflow histories. Temporal turns
+// the errors returned by activities into failures that keep only the message and type, so the frames are lost by
+// the time the workflow sees the error. Pass Details as the details of an application error in the activity, and
+// call FromError in the workflow to get the error back with its frames:
+//
+//	// in the activity
+//	return temporal.NewApplicationError(err.Error(), stackerrtemporal.Type(err), stackerrtemporal.Details(err))
+//
+//	// in the workflow
+//	err = stackerrtemporal.FromError(workflow.ExecuteActivity(ctx, Charge, order).Get(ctx, nil))
+//
+// The package doesn't import the Temporal SDK. The details are a stackerr.LogReport, which Temporal's default data
+// converter encodes as JSON.
+package stackerrtemporal
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// Details returns the details to attach to the application error for err: its LogReport, with its message, chain,
+// code, severity, and frames.
+func Details(err error) stackerr.LogReport {
+	return stackerr.Report(err)
+}
+
+// Type returns the name of the type of the innermost error in the chain, for the type of the application error. It
+// is the name matched by the NonRetryableErrorTypes of a retry policy. Type returns an empty string when a nil error
+// is passed in.
+func Type(err error) string {
+	if err == nil {
+		return ""
+	}
+	t := reflect.TypeOf(stackerr.Cause(err))
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// applicationError is the part of *temporal.ApplicationError that FromError uses.
+type applicationError interface {
+	error
+	HasDetails() bool
+	Details(d ...interface{}) error
+}
+
+// FromError finds the application error in err's unwrap chain, such as the one wrapped by the *temporal.ActivityError
+// returned by an activity, and rebuilds the error described by its details with FromReport. The rebuilt error has the
+// activity's message, code, severity, and frames. If there is no application error, or its details weren't created by
+// Details, FromError returns err.
+func FromError(err error) error {
+	var ae applicationError
+	if !errors.As(err, &ae) || !ae.HasDetails() {
+		return err
+	}
+	var r stackerr.LogReport
+	if ae.Details(&r) != nil || r.Message == "" {
+		return err
+	}
+	return stackerr.FromReport(r)
+}
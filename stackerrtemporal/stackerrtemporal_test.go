@@ -0,0 +1,78 @@
+package stackerrtemporal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrtemporal"
+)
+
+// applicationError stands in for *temporal.ApplicationError, holding its details as JSON like Temporal's default data
+// converter.
+type applicationError struct {
+	msg     string
+	details []byte
+}
+
+func (ae *applicationError) Error() string {
+	return ae.msg
+}
+
+func (ae *applicationError) HasDetails() bool {
+	return ae.details != nil
+}
+
+func (ae *applicationError) Details(d ...interface{}) error {
+	return json.Unmarshal(ae.details, d[0])
+}
+
+type declinedError struct{}
+
+func (*declinedError) Error() string {
+	return "card declined"
+}
+
+func TestRoundTrip(t *testing.T) {
+	err := stackerr.WithCode(stackerr.Errorf("charge: %w", &declinedError{}), 402)
+	if typ := stackerrtemporal.Type(err); typ != "declinedError" {
+		t.Errorf("unexpected type %q", typ)
+	}
+	details, _ := json.Marshal(stackerrtemporal.Details(err))
+	// activity errors reach the workflow wrapped in a *temporal.ActivityError.
+	received := fmt.Errorf("activity error: %w", &applicationError{msg: err.Error(), details: details})
+
+	rebuilt := stackerrtemporal.FromError(received)
+	if rebuilt.Error() != "charge: card declined" {
+		t.Errorf("unexpected message %q", rebuilt)
+	}
+	if code, _ := stackerr.Code(rebuilt); code != 402 {
+		t.Errorf("expected code 402, got %d", code)
+	}
+	if diff := cmp.Diff(stackerr.Frames(err), stackerr.Frames(rebuilt)); diff != "" {
+		t.Error(diff)
+	}
+	if stackerr.Fingerprint(rebuilt) != stackerr.Fingerprint(err) {
+		t.Error("expected the fingerprint to match")
+	}
+}
+
+func TestFromErrorWithoutDetails(t *testing.T) {
+	if stackerrtemporal.FromError(nil) != nil || stackerrtemporal.Type(nil) != "" {
+		t.Error("expected nil and an empty type for a nil error")
+	}
+	for _, err := range []error{
+		errors.New("plain"),
+		&applicationError{msg: "no details"},
+		&applicationError{msg: "other details", details: []byte(`["retry later"]`)},
+		&applicationError{msg: "other object", details: []byte(`{"reason":"retry later"}`)},
+	} {
+		if out := stackerrtemporal.FromError(err); out != err {
+			t.Errorf("expected %q to be returned unchanged, got %q", err, out)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package stackerrtest reports unexpected errors in tests with their full stack traces. Assertion helpers that only
+// print err.Error() lose the stack trace, which is usually the most useful part of the failure.
+package stackerrtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// Must stops the test with t.Fatal if err is not nil, reporting the error's message, chain, code, severity, and stack
+// trace. The failure is reported at the line of the test that called Must.
+func Must(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(describe(err))
+	}
+}
+
+// NoError works like Must, but marks the test as failed with t.Error and lets it keep running.
+func NoError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Error(describe(err))
+	}
+}
+
+// describe formats an unexpected error for a test failure.
+func describe(err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "unexpected error: %s\n", err.Error())
+	if chain := stackerr.Chain(err); len(chain) > 1 {
+		b.WriteString("chain:\n")
+		for _, v := range chain {
+			fmt.Fprintf(&b, "\t%s\n", v)
+		}
+	}
+	if code, ok := stackerr.Code(err); ok {
+		fmt.Fprintf(&b, "code: %d\n", code)
+	}
+	fmt.Fprintf(&b, "severity: %s\n", stackerr.SeverityOf(err))
+	if lines, _ := stackerr.Trace(err, stackerr.StandardFormat); len(lines) > 0 {
+		b.WriteString("stack trace:\n")
+		for _, v := range lines {
+			fmt.Fprintf(&b, "\t%s\n", v)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,74 @@
+package stackerrtest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+	"github.com/jonbodner/stackerr/stackerrtest"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	helpers int
+	fatal   bool
+	failed  bool
+	msg     string
+}
+
+func (r *recorder) Helper() {
+	r.helpers++
+}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.fatal = true
+	r.failed = true
+	r.msg = fmt.Sprint(args...)
+}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.failed = true
+	r.msg = fmt.Sprint(args...)
+}
+
+func TestMust(t *testing.T) {
+	r := &recorder{}
+	stackerrtest.Must(r, nil)
+	if r.failed {
+		t.Error("expected no failure for a nil error")
+	}
+	if r.helpers != 1 {
+		t.Error("expected Must to call Helper")
+	}
+
+	err := stackerr.WithCode(fmt.Errorf("load: %w", stackerr.New("missing")), 404)
+	stackerrtest.Must(r, err)
+	if !r.fatal {
+		t.Error("expected Must to call Fatal")
+	}
+	for _, v := range []string{
+		"unexpected error: load: missing\n",
+		"chain:\n\tload: missing\n\tmissing\n",
+		"code: 404\n",
+		"severity: error\n",
+		"stack trace:\n\tgithub.com/jonbodner/stackerr/stackerrtest_test.TestMust (",
+	} {
+		if !strings.Contains(r.msg, v) {
+			t.Errorf("expected %q in\n%s", v, r.msg)
+		}
+	}
+}
+
+func TestNoError(t *testing.T) {
+	r := &recorder{}
+	stackerrtest.NoError(r, errors.New("plain"))
+	if r.fatal || !r.failed {
+		t.Error("expected NoError to call Error")
+	}
+	if r.msg != "unexpected error: plain\nseverity: error" {
+		t.Errorf("unexpected message %q", r.msg)
+	}
+}
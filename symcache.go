@@ -0,0 +1,67 @@
+package stackerr
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultSymbolCacheSize is the number of stack traces kept in the symbol cache until SetSymbolCacheSize is called.
+const defaultSymbolCacheSize = 1024
+
+var symbolCacheSize int64 = defaultSymbolCacheSize
+
+// symbolEntry is a stack trace in the symbol cache.
+type symbolEntry struct {
+	pcs    []uintptr
+	frames []runtime.Frame
+}
+
+var symbolCache = struct {
+	sync.RWMutex
+	entries map[uint64]symbolEntry
+}{
+	entries: map[uint64]symbolEntry{},
+}
+
+// SetSymbolCacheSize sets how many stack traces are kept in the symbol cache. Each stack trace is symbolized the first
+// time it is rendered; the cache lets errors with identical stack traces, such as errors created by the same call
+// site in a loop, share the symbolized frames instead of each converting their own program counters. When the cache
+// is full, an arbitrary entry is dropped to make room. Pass 0 to turn the cache off, which also releases it. The cache
+// holds 1024 stack traces by default.
+func SetSymbolCacheSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	symbolCache.Lock()
+	symbolCache.entries = map[uint64]symbolEntry{}
+	symbolCache.Unlock()
+	atomic.StoreInt64(&symbolCacheSize, int64(n))
+}
+
+// cachedSymbolize converts program counters into frames, using the frames in the symbol cache if the same program
+// counters were converted before. The returned slice must not be modified.
+func cachedSymbolize(pcs []uintptr) []runtime.Frame {
+	size := int(atomic.LoadInt64(&symbolCacheSize))
+	if size == 0 || len(pcs) == 0 {
+		return symbolize(pcs)
+	}
+	h := hashPCs(pcs)
+	symbolCache.RLock()
+	e, ok := symbolCache.entries[h]
+	symbolCache.RUnlock()
+	if ok && equalPCs(e.pcs, pcs) {
+		return e.frames
+	}
+	frames := symbolize(pcs)
+	symbolCache.Lock()
+	if len(symbolCache.entries) >= size {
+		for k := range symbolCache.entries {
+			delete(symbolCache.entries, k)
+			break
+		}
+	}
+	symbolCache.entries[h] = symbolEntry{pcs: pcs, frames: frames}
+	symbolCache.Unlock()
+	return frames
+}
@@ -0,0 +1,44 @@
+package stackerr_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func newInLoop(n int) []error {
+	out := make([]error, n)
+	for i := range out {
+		out[i] = stackerr.New("loop")
+	}
+	return out
+}
+
+func newElsewhere() error {
+	return stackerr.New("elsewhere")
+}
+
+func TestSymbolCache(t *testing.T) {
+	for _, size := range []int{0, 1, 1024} {
+		stackerr.SetSymbolCacheSize(size)
+		errs := newInLoop(3)
+		other := newElsewhere()
+		// with a cache of one, each error replaces the other's entry.
+		for i := 0; i < 2; i++ {
+			for _, err := range errs {
+				if diff := cmp.Diff(stackerr.Frames(errs[0]), stackerr.Frames(err)); diff != "" {
+					t.Errorf("size %d: %s", size, diff)
+				}
+			}
+			if top := stackerr.Frames(other)[0].Function; top != "github.com/jonbodner/stackerr_test.newElsewhere" {
+				t.Errorf("size %d: unexpected top frame %s", size, top)
+			}
+		}
+		if top := stackerr.Frames(errs[0])[0].Function; top != "github.com/jonbodner/stackerr_test.newInLoop" {
+			t.Errorf("size %d: unexpected top frame %s", size, top)
+		}
+	}
+	stackerr.SetSymbolCacheSize(1024)
+}
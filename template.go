@@ -0,0 +1,73 @@
+//go:build !tinygo
+
+package stackerr
+
+import (
+	"bytes"
+	"runtime"
+	"text/template"
+	"text/template/parse"
+)
+
+// frameTemplate is the type of the templates that render frames. Under TinyGo, whose reflection doesn't support
+// text/template, it is a stand-in that only renders the built-in formats (see template_tinygo.go).
+type frameTemplate = template.Template
+
+// StandardFormat is the default template used to convert a *runtime.Frame to a string. Each entry is formatted as
+// "FUNCTION_NAME (FILE_NAME:LINE_NUMBER)"
+var StandardFormat = template.Must(template.New("standardFormat").Parse("{{.Function}} ({{.File}}:{{.Line}})"))
+
+// compactFormat renders each frame of the logfmt trace field.
+var compactFormat = template.Must(template.New("compactFormat").Parse("{{.Function}}:{{.Line}}"))
+
+// The built-in templates and their parse trees, recorded at init so that executeFrame only takes the fast path while
+// they are unchanged.
+var (
+	standardTemplate = StandardFormat
+	standardTree     = StandardFormat.Tree
+	compactTemplate  = compactFormat
+	compactTree      = compactFormat.Tree
+)
+
+// Trace returns the stack trace information as a slice of strings formatted using the provided Go template. The
+// template is executed with a TemplateFrame for each frame, so it can use the fields of runtime.Frame, such as
+// Function, File, and Line, and the computed fields Package, ShortFunction, BaseFile, and RelFile. See StandardFormat
+// for an example. Any options passed in change which frames are rendered. If the WithFormatter option is passed, the
+// frames are rendered with the formatter instead. If t is nil, StandardFormat is used. Like Format, Trace is safe to
+// call concurrently for the same error.
+func Trace(e error, t *template.Template, opts ...Option) ([]string, error) {
+	return traceLines(e, t, opts)
+}
+
+// TraceOf works like Trace, but renders the stack trace attached to target instead of the first one in the unwrap
+// chain. It uses the innermost stack trace in the unwrap tree for err that has target (as matched by errors.Is) in its
+// own chain, so it picks the right trace when the error joins several errors that each have one. It returns nil for
+// both values if target is not in the tree or has no stack trace.
+func TraceOf(err, target error, t *template.Template, opts ...Option) ([]string, error) {
+	return traceLinesOf(err, target, t, opts)
+}
+
+// TemplateFormatter returns a TraceFormatter that renders each frame with the template, as Trace does, and joins the
+// lines with newlines. If the template returns an error, the text written before the error is used.
+func TemplateFormatter(t *template.Template) TraceFormatter {
+	return templateFormatter{t: t}
+}
+
+// executeFrame renders a frame with the template. The built-in formats are rendered directly, since executing a
+// template for every frame is slow when errors are logged under load. Other templates are executed.
+func executeFrame(b *bytes.Buffer, t *template.Template, frame runtime.Frame) error {
+	switch {
+	case isTemplate(t, standardTemplate, standardTree):
+		renderStandard(b, frame)
+		return nil
+	case isTemplate(t, compactTemplate, compactTree):
+		renderCompact(b, frame)
+		return nil
+	}
+	return t.Execute(b, newTemplateFrame(frame))
+}
+
+// isTemplate reports whether t is the built-in template, and it hasn't been parsed again since init.
+func isTemplate(t, builtin *template.Template, tree *parse.Tree) bool {
+	return t == builtin && t.Tree == tree
+}
@@ -0,0 +1,25 @@
+package stackerr_test
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+// TestStandardFormatFastPath checks that the direct rendering of StandardFormat matches executing the template.
+func TestStandardFormatFastPath(t *testing.T) {
+	slow := template.Must(template.New("slow").Parse("{{.Function}} ({{.File}}:{{.Line}})"))
+	for _, err := range []error{stackerr.New("local"), stackerr.WrapCgo(stackerr.New("nested"))} {
+		fast, fastErr := stackerr.Trace(err, stackerr.StandardFormat, stackerr.CollapseStdlib())
+		executed, slowErr := stackerr.Trace(err, slow, stackerr.CollapseStdlib())
+		if fastErr != nil || slowErr != nil {
+			t.Fatal(fastErr, slowErr)
+		}
+		if diff := cmp.Diff(executed, fast); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
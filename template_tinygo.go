@@ -0,0 +1,46 @@
+//go:build tinygo
+
+package stackerr
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// frameTemplate stands in for text/template's Template under TinyGo, whose reflection doesn't support text/template,
+// so the package doesn't import it. Only the built-in formats exist, and they are rendered directly.
+type frameTemplate struct {
+	render func(b *bytes.Buffer, frame runtime.Frame)
+}
+
+// StandardFormat is the default format used to convert a frame to a string. Each entry is formatted as
+// "FUNCTION_NAME (FILE_NAME:LINE_NUMBER)". Under TinyGo it is the only format, and it isn't a text/template.
+var StandardFormat = &frameTemplate{render: renderStandard}
+
+// compactFormat renders each frame of the logfmt trace field.
+var compactFormat = &frameTemplate{render: renderCompact}
+
+// Trace returns the stack trace information as a slice of strings formatted with StandardFormat; nil also means
+// StandardFormat. Any options passed in change which frames are rendered. If the WithFormatter option is passed, the
+// frames are rendered with the formatter instead.
+func Trace(e error, t *frameTemplate, opts ...Option) ([]string, error) {
+	return traceLines(e, t, opts)
+}
+
+// TraceOf works like Trace, but renders the stack trace attached to target instead of the first one in the unwrap
+// chain. It returns nil for both values if target is not in the tree or has no stack trace.
+func TraceOf(err, target error, t *frameTemplate, opts ...Option) ([]string, error) {
+	return traceLinesOf(err, target, t, opts)
+}
+
+// TemplateFormatter returns a TraceFormatter that renders each frame with the format, as Trace does, and joins the
+// lines with newlines.
+func TemplateFormatter(t *frameTemplate) TraceFormatter {
+	return templateFormatter{t: t}
+}
+
+// executeFrame renders a frame with the format.
+func executeFrame(b *bytes.Buffer, t *frameTemplate, frame runtime.Frame) error {
+	t.render(b, frame)
+	return nil
+}
@@ -0,0 +1,71 @@
+package stackerr
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned by RunWithTimeout when the function it ran was still running when the timeout expired or
+// the context was cancelled.
+type TimeoutError struct {
+	// Operation is the fully-qualified name of the function that called RunWithTimeout.
+	Operation string
+	// Timeout is the timeout that was passed to RunWithTimeout.
+	Timeout time.Duration
+	// Err is the context's error, usually context.DeadlineExceeded.
+	Err error
+}
+
+// Error reports the operation that was still running and the timeout, followed by the context's error.
+func (te *TimeoutError) Error() string {
+	return fmt.Sprintf("%s still running after %s: %v", te.Operation, te.Timeout, te.Err)
+}
+
+// Unwrap exposes the context's error.
+func (te *TimeoutError) Unwrap() error {
+	return te.Err
+}
+
+// RunWithTimeout calls fn with a context that times out after d, and waits for fn to return or for the context to be
+// done, whichever happens first. There are three possible outcomes:
+//
+//   - If fn returns, its error is returned. If it doesn't have a stack trace, it gets the stack trace of the code
+//     that called RunWithTimeout.
+//   - If fn panics, the panic is recovered and returned as an error from FromRecovered, with the stack trace of the
+//     code that panicked.
+//   - If fn is still running when the context is done, a *TimeoutError with the stack trace of the code that called
+//     RunWithTimeout is returned. fn keeps running in the background until it returns; it should stop work when its
+//     context is done.
+func RunWithTimeout(ctx context.Context, d time.Duration, fn func(ctx context.Context) error) error {
+	caller := captureStack(1)
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- FromRecovered(r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || HasStack(err) {
+			return err
+		}
+		return publish(&errorStack{Err: err, trace: caller})
+	case <-ctx.Done():
+		var op string
+		if frames := caller.frames(); len(frames) > 0 {
+			op = frames[0].Function
+		}
+		return publish(&errorStack{
+			Err:   &TimeoutError{Operation: op, Timeout: d, Err: ctx.Err()},
+			trace: caller,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package stackerr_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	err := stackerr.RunWithTimeout(context.Background(), time.Millisecond, func(context.Context) error {
+		<-release
+		return nil
+	})
+	var te *stackerr.TimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected a *TimeoutError, got `%v`", err)
+	}
+	if te.Operation != "github.com/jonbodner/stackerr_test.TestRunWithTimeout" || te.Timeout != time.Millisecond {
+		t.Errorf("unexpected TimeoutError %+v", te)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected error to wrap context.DeadlineExceeded")
+	}
+	expected := "github.com/jonbodner/stackerr_test.TestRunWithTimeout still running after 1ms: context deadline exceeded"
+	if err.Error() != expected {
+		t.Errorf("expected `%s`, got `%s`", expected, err.Error())
+	}
+	if origin, _ := stackerr.Origin(err); origin.Function != te.Operation {
+		t.Errorf("expected the caller's stack trace, got `%s`", origin.Function)
+	}
+}
+
+func TestRunWithTimeoutReturns(t *testing.T) {
+	plain := errors.New("plain")
+	data := []struct {
+		name string
+		fn   func(context.Context) error
+		test func(t *testing.T, err error)
+	}{
+		{
+			"success",
+			func(context.Context) error { return nil },
+			func(t *testing.T, err error) {
+				if err != nil {
+					t.Errorf("expected nil error, got `%v`", err)
+				}
+			},
+		},
+		{
+			"error",
+			func(context.Context) error { return plain },
+			func(t *testing.T, err error) {
+				origin, _ := stackerr.Origin(err)
+				if !errors.Is(err, plain) || !strings.HasPrefix(origin.Function, "github.com/jonbodner/stackerr_test.TestRunWithTimeoutReturns.") {
+					t.Errorf("expected plain error with the caller's stack trace, got `%v` from `%s`", err, origin.Function)
+				}
+			},
+		},
+		{
+			"panic",
+			func(context.Context) error { panic("boom") },
+			func(t *testing.T, err error) {
+				if v, ok := stackerr.PanicValue(err); !ok || v != "boom" {
+					t.Errorf("expected panic value `boom`, got `%v`", v)
+				}
+			},
+		},
+	}
+	for _, v := range data {
+		t.Run(v.name, func(t *testing.T) {
+			v.test(t, stackerr.RunWithTimeout(context.Background(), time.Hour, v.fn))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package stackerr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Value implements driver.Valuer, so a Stored can be saved in a database column. The error is stored as its LogReport
+// encoded as JSON, which keeps the message, chain, code, severity, and frames. A nil Err is stored as NULL.
+func (s Stored) Value() (driver.Value, error) {
+	if s.Err == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(Report(s.Err))
+	if err != nil {
+		return nil, Wrap(err)
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner, so a column written with Value can be loaded back into a Stored. The column can hold
+// text or bytes; NULL sets Err to nil. Err is decoded with DecodeJSON, so it has the frames from when it was stored.
+func (s *Stored) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		s.Err = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("stackerr: cannot scan %T into a Stored", src)
+	}
+	err, decodeErr := DecodeJSON(data)
+	if decodeErr != nil {
+		return decodeErr
+	}
+	s.Err = err
+	return nil
+}
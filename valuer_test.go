@@ -0,0 +1,54 @@
+package stackerr_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestStoredValuer(t *testing.T) {
+	err := stackerr.WithCode(stackerr.New("job failed"), 3)
+	var v driver.Valuer = stackerr.Stored{Err: err}
+	value, vErr := v.Value()
+	if vErr != nil {
+		t.Fatal(vErr)
+	}
+	text, ok := value.(string)
+	if !ok || !strings.HasPrefix(text, `{"message":"job failed",`) {
+		t.Fatalf("expected JSON text, got %#v", value)
+	}
+
+	// drivers can return text columns as either strings or bytes.
+	for _, src := range []interface{}{text, []byte(text)} {
+		var s stackerr.Stored
+		var scanner sql.Scanner = &s
+		if err := scanner.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(stackerr.Report(err), stackerr.Report(s.Err)); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestStoredNull(t *testing.T) {
+	value, err := stackerr.Stored{}.Value()
+	if err != nil || value != nil {
+		t.Errorf("expected NULL for a nil error, got %v, %v", value, err)
+	}
+	s := stackerr.Stored{Err: stackerr.New("old")}
+	if err := s.Scan(nil); err != nil || s.Err != nil {
+		t.Errorf("expected NULL to clear Err, got %v, %v", s.Err, err)
+	}
+	if err := s.Scan(42); err == nil {
+		t.Error("expected an error for an integer column")
+	}
+	if err := s.Scan("not json"); err == nil {
+		t.Error("expected an error for text that isn't JSON")
+	}
+}
@@ -0,0 +1,14 @@
+package stackerr
+
+// Wrap2 passes v through and wraps err the same way as Wrap, so a call that returns a value and an error can be
+// wrapped in a single line:
+//
+//	return stackerr.Wrap2(repo.Get(id))
+func Wrap2[T any](v T, err error) (T, error) {
+	return v, wrap(err, 1)
+}
+
+// Wrap3 is like Wrap2, for calls that return two values and an error.
+func Wrap3[T, U any](v T, u U, err error) (T, U, error) {
+	return v, u, wrap(err, 1)
+}
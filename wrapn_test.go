@@ -0,0 +1,51 @@
+package stackerr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func get(fail bool) (int, error) {
+	if fail {
+		return 0, errors.New("get failed")
+	}
+	return 42, nil
+}
+
+func getPair(fail bool) (string, int, error) {
+	if fail {
+		return "", 0, errors.New("get pair failed")
+	}
+	return "answer", 42, nil
+}
+
+func TestWrap2(t *testing.T) {
+	v, err := stackerr.Wrap2(get(false))
+	if v != 42 || err != nil {
+		t.Errorf("expected 42 and nil, got %d and %v", v, err)
+	}
+	_, err = stackerr.Wrap2(get(true))
+	if !stackerr.HasStack(err) || err.Error() != "get failed" {
+		t.Fatalf("expected a stacked `get failed`, got %v", err)
+	}
+	if f := topFrame(t, err); !strings.HasPrefix(f, "github.com/jonbodner/stackerr_test.TestWrap2 ") {
+		t.Errorf("expected the trace to start at the caller, got %s", f)
+	}
+}
+
+func TestWrap3(t *testing.T) {
+	s, v, err := stackerr.Wrap3(getPair(false))
+	if s != "answer" || v != 42 || err != nil {
+		t.Errorf("expected answer, 42, and nil, got %s, %d, and %v", s, v, err)
+	}
+	_, _, err = stackerr.Wrap3(getPair(true))
+	if !stackerr.HasStack(err) || err.Error() != "get pair failed" {
+		t.Fatalf("expected a stacked `get pair failed`, got %v", err)
+	}
+	if f := topFrame(t, err); !strings.HasPrefix(f, "github.com/jonbodner/stackerr_test.TestWrap3 ") {
+		t.Errorf("expected the trace to start at the caller, got %s", f)
+	}
+}
@@ -0,0 +1,82 @@
+package stackerr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EncodeYAML renders an error as a YAML document with its message, code, severity, category, operation names,
+// fingerprint, request ID, trace and span IDs, context values, and frames, taken from its LogReport. Everything but the
+// message, severity, and frames is left out when it is not set. An empty string is returned for a nil error.
+func EncodeYAML(err error) string {
+	if err == nil {
+		return ""
+	}
+	r := Report(err)
+	var b strings.Builder
+	b.WriteString("message: " + yamlString(r.Message) + "\n")
+	if r.Code != 0 {
+		b.WriteString("code: " + strconv.Itoa(r.Code) + "\n")
+	}
+	b.WriteString("severity: " + yamlString(r.Severity.String()) + "\n")
+	if r.Category != 0 {
+		b.WriteString("category: " + yamlString(r.Category.String()) + "\n")
+	}
+	if len(r.Ops) > 0 {
+		b.WriteString("ops:\n")
+		for _, v := range r.Ops {
+			b.WriteString("  - " + yamlString(v) + "\n")
+		}
+	}
+	if r.Fingerprint != "" {
+		b.WriteString("fingerprint: " + yamlString(r.Fingerprint) + "\n")
+	}
+	if r.RequestID != "" {
+		b.WriteString("request_id: " + yamlString(r.RequestID) + "\n")
+	}
+	if r.TraceID != "" || r.SpanID != "" {
+		b.WriteString("trace_id: " + yamlString(r.TraceID) + "\n")
+		b.WriteString("span_id: " + yamlString(r.SpanID) + "\n")
+	}
+	if len(r.Context) > 0 {
+		b.WriteString("context:\n")
+		for _, k := range sortedContextValues(r.Context) {
+			b.WriteString("  " + yamlString(k) + ": " + yamlString(r.Context[k]) + "\n")
+		}
+	}
+	if len(r.Frames) == 0 {
+		b.WriteString("frames: []\n")
+		return b.String()
+	}
+	b.WriteString("frames:\n")
+	for _, f := range r.Frames {
+		b.WriteString("  - function: " + yamlString(f.Function) + "\n")
+		b.WriteString("    file: " + yamlString(f.File) + "\n")
+		b.WriteString("    line: " + strconv.Itoa(f.Line) + "\n")
+		b.WriteString("    kind: " + yamlString(string(f.Kind)) + "\n")
+	}
+	return b.String()
+}
+
+// yamlReserved holds the plain scalars that YAML parsers read as something other than a string.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+	"null": true, "~": true,
+}
+
+// yamlString writes s as a plain scalar when that is unambiguous, so function names and file paths stay readable.
+// Everything else is double quoted; Go's escape sequences are all valid in YAML double-quoted scalars.
+func yamlString(s string) string {
+	if s == "" || yamlReserved[strings.ToLower(s)] {
+		return strconv.Quote(s)
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == '/':
+		case i > 0 && (c >= '0' && c <= '9' || strings.ContainsRune(".()*-+@", c)):
+		default:
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/jonbodner/stackerr"
+)
+
+func TestEncodeYAML(t *testing.T) {
+	if out := stackerr.EncodeYAML(nil); out != "" {
+		t.Errorf("expected empty string, got %q", out)
+	}
+
+	inner := stackerr.New("inner")
+	err := stackerr.WithCode(fmt.Errorf("outer: %w", inner), 42)
+	var b strings.Builder
+	b.WriteString("message: \"outer: inner\"\n")
+	b.WriteString("code: 42\n")
+	b.WriteString("severity: error\n")
+	// a fingerprint that starts with a digit is quoted, so it isn't read as a number.
+	fp := stackerr.Fingerprint(err)
+	if fp[0] >= '0' && fp[0] <= '9' {
+		fp = strconv.Quote(fp)
+	}
+	b.WriteString("fingerprint: " + fp + "\n")
+	b.WriteString("frames:\n")
+	for _, f := range stackerr.Frames(err) {
+		fmt.Fprintf(&b, "  - function: %s\n    file: %s\n    line: %d\n    kind: %s\n", f.Function, f.File, f.Line, f.Kind)
+	}
+	if diff := cmp.Diff(b.String(), stackerr.EncodeYAML(err)); diff != "" {
+		t.Error(diff)
+	}
+
+	expected := "message: \"no\"\nseverity: error\nframes: []\n"
+	if diff := cmp.Diff(expected, stackerr.EncodeYAML(errors.New("no"))); diff != "" {
+		t.Error(diff)
+	}
+	expected = "message: \"line one\\nline two\"\nseverity: error\nframes: []\n"
+	if diff := cmp.Diff(expected, stackerr.EncodeYAML(errors.New("line one\nline two"))); diff != "" {
+		t.Error(diff)
+	}
+	expected = "message: \"123\"\nseverity: error\nframes: []\n"
+	if diff := cmp.Diff(expected, stackerr.EncodeYAML(errors.New("123"))); diff != "" {
+		t.Error(diff)
+	}
+}